@@ -3,17 +3,25 @@ package auth
 import (
 	"bufio"
 	"cmp"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/LarsEckart/huey/config"
 	"github.com/LarsEckart/huey/hue"
+	"github.com/LarsEckart/huey/hue/clip"
+	"github.com/LarsEckart/huey/hue/discovery"
+	"github.com/mattn/go-isatty"
 )
 
 // EnsureAuthenticated checks config and runs the auth flow if needed.
 // Returns the loaded/updated config, or error if auth fails.
-func EnsureAuthenticated() (*config.Config, error) {
+func EnsureAuthenticated(ctx context.Context) (*config.Config, error) {
 	cfg, err := config.Load()
 	if err != nil {
 		return nil, fmt.Errorf("load config: %w", err)
@@ -21,12 +29,15 @@ func EnsureAuthenticated() (*config.Config, error) {
 
 	// Already configured?
 	if cfg.IsConfigured() {
+		if cfg.BridgeID == "" {
+			rememberBridgeID(ctx, cfg)
+		}
 		return cfg, nil
 	}
 
 	// Need bridge IP?
 	if cfg.BridgeIP == "" {
-		ip, err := promptBridgeIP()
+		ip, err := promptBridgeIP(ctx)
 		if err != nil {
 			return nil, err
 		}
@@ -35,11 +46,18 @@ func EnsureAuthenticated() (*config.Config, error) {
 
 	// Need username?
 	if cfg.Username == "" {
-		username, err := registerWithBridge(cfg.BridgeIP)
+		creds, err := registerWithBridge(ctx, cfg.BridgeIP, pairingOptions(cfg)...)
 		if err != nil {
 			return nil, err
 		}
-		cfg.Username = username
+		cfg.SetCredentials(creds.Username, creds.ClientKey)
+	}
+
+	// Remember which bridge this is, to find it again if its IP changes
+	if cfg.BridgeID == "" {
+		if bridge, err := hue.NewClient(cfg.BridgeIP, "", pairingOptions(cfg)...).GetBridgeConfig(ctx); err == nil {
+			cfg.BridgeID = bridge.BridgeID
+		}
 	}
 
 	// Save the config
@@ -51,48 +69,278 @@ func EnsureAuthenticated() (*config.Config, error) {
 	return cfg, nil
 }
 
-// promptBridgeIP asks the user for the bridge IP address.
-func promptBridgeIP() (string, error) {
+// rememberBridgeID stores the bridge ID for configs written before huey
+// kept track of it. Failures are ignored; it is tried again next time.
+func rememberBridgeID(ctx context.Context, cfg *config.Config) {
+	bridge, err := hue.NewClient(cfg.BridgeIP, "", pairingOptions(cfg)...).GetBridgeConfig(ctx)
+	if err != nil {
+		return
+	}
+	cfg.BridgeID = bridge.BridgeID
+	_ = cfg.Save()
+}
+
+// NewClient returns a client for the configured bridge, using the timeout
+// and retry settings from cfg unless opts override them. If the bridge ID
+// is known, the client finds the bridge again when its IP changes, saves
+// the new IP and reports the move on log.
+func NewClient(cfg *config.Config, log io.Writer, opts ...hue.Option) *hue.Client {
+	cfgOpts := ClientOptions(cfg)
+	if cfg.HTTPS {
+		cfgOpts = append(cfgOpts, hue.WithHTTPS(certPolicy(cfg, log)))
+	} else {
+		// Checks the event stream, the only HTTPS connection then.
+		cfgOpts = append(cfgOpts, hue.WithCertPolicy(certPolicy(cfg, log)))
+	}
+	client := hue.NewClient(cfg.BridgeIP, cfg.Username, append(cfgOpts, opts...)...)
+	if cfg.BridgeID == "" {
+		return client
+	}
+
+	client.EnableRelocation(cfg.BridgeID, locateBridge, func(oldIP, newIP string) {
+		cfg.BridgeIP = newIP
+		if err := cfg.Save(); err != nil {
+			_, _ = fmt.Fprintf(log, "Bridge %s moved from %s to %s, but saving the config failed: %v\n", cfg.BridgeID, oldIP, newIP, err)
+			return
+		}
+		_, _ = fmt.Fprintf(log, "Bridge %s moved from %s to %s, config updated\n", cfg.BridgeID, oldIP, newIP)
+	})
+	return client
+}
+
+// ClientOptions returns the hue.Client options for the connection
+// settings in cfg.
+func ClientOptions(cfg *config.Config) []hue.Option {
+	var opts []hue.Option
+	if cfg.TimeoutMS > 0 {
+		opts = append(opts, hue.WithTimeout(time.Duration(cfg.TimeoutMS)*time.Millisecond))
+	}
+	if cfg.Retries != nil {
+		policy := hue.DefaultRetryPolicy
+		policy.MaxRetries = max(0, *cfg.Retries)
+		opts = append(opts, hue.WithRetryPolicy(policy))
+	}
+	if cfg.TransitionMS != nil {
+		opts = append(opts, hue.WithTransition(time.Duration(max(0, *cfg.TransitionMS))*time.Millisecond))
+	}
+	return opts
+}
+
+// NewClipClient returns a CLIP v2 client for the configured bridge, using
+// the timeout from cfg unless opts override it. The bridge certificate is
+// checked like for NewClient in HTTPS mode.
+func NewClipClient(cfg *config.Config, log io.Writer, opts ...clip.Option) *clip.Client {
+	cfgOpts := []clip.Option{clip.WithTLSConfig(certPolicy(cfg, log).TLSConfig())}
+	if cfg.TimeoutMS > 0 {
+		cfgOpts = append(cfgOpts, clip.WithTimeout(time.Duration(cfg.TimeoutMS)*time.Millisecond))
+	}
+	return clip.NewClient(cfg.BridgeIP, cfg.Username, append(cfgOpts, opts...)...)
+}
+
+// certPolicy returns the certificate policy for the configured bridge.
+// A certificate trusted on first use is saved in cfg and reported on log.
+func certPolicy(cfg *config.Config, log io.Writer) hue.CertPolicy {
+	return hue.CertPolicy{
+		BridgeID:    cfg.BridgeID,
+		Fingerprint: cfg.CertFingerprint,
+		OnPin: func(fingerprint string) {
+			cfg.CertFingerprint = fingerprint
+			if fingerprint == hue.CAVerified {
+				// Nothing to report; the bridge is remembered as CA-verified.
+				_ = cfg.Save()
+				return
+			}
+			if err := cfg.Save(); err != nil {
+				_, _ = fmt.Fprintf(log, "Pinned bridge certificate %s, but saving the config failed: %v\n", fingerprint, err)
+				return
+			}
+			_, _ = fmt.Fprintf(log, "Pinned bridge certificate %s\n", fingerprint)
+		},
+	}
+}
+
+// Repin trusts the certificate the configured bridge presents now in
+// place of the pinned one, for example after the bridge was reset, and
+// saves it. It returns the new fingerprint, or "" if the certificate is
+// verified through the Signify CA and needs no pin. A bridge that was
+// verified through the CA before is never pinned to another certificate.
+func Repin(ctx context.Context, cfg *config.Config) (string, error) {
+	var pinned string
+	connect := func(policy hue.CertPolicy) error {
+		policy.OnPin = func(fingerprint string) { pinned = fingerprint }
+		_, err := hue.NewClient(cfg.BridgeIP, "", append(ClientOptions(cfg), hue.WithHTTPS(policy))...).GetBridgeConfig(ctx)
+		return err
+	}
+
+	err := connect(hue.CertPolicy{BridgeID: cfg.BridgeID})
+	var certErr *hue.CertificateError
+	if errors.As(err, &certErr) && cfg.BridgeID != "" && cfg.CertFingerprint != hue.CAVerified {
+		// Not issued by the Signify CA: trust the certificate presented now.
+		err = connect(hue.CertPolicy{})
+	}
+	if err != nil {
+		return "", fmt.Errorf("connect to bridge: %w", err)
+	}
+
+	cfg.CertFingerprint = pinned
+	if err := cfg.Save(); err != nil {
+		return "", fmt.Errorf("save config: %w", err)
+	}
+	if pinned == hue.CAVerified {
+		return "", nil
+	}
+	return pinned, nil
+}
+
+// locateBridge finds the bridge with the given ID via mDNS and SSDP,
+// probing the local subnets if multicast finds nothing.
+func locateBridge(ctx context.Context, bridgeID string) (string, error) {
+	bridge, err := (&discovery.Discoverer{}).FindByID(ctx, bridgeID)
+	if err != nil {
+		bridge, err = (&discovery.Discoverer{Probe: true}).FindByID(ctx, bridgeID)
+	}
+	if err != nil {
+		return "", err
+	}
+	return bridge.IP, nil
+}
+
+// Repair pairs huey with the configured bridge again after the bridge
+// stopped accepting the saved username, keeping the rest of the config.
+// It only prompts when stdin is a terminal; otherwise it explains how to
+// fix credentials with huey auth reset. Messages and prompts go to log.
+func Repair(ctx context.Context, cfg *config.Config, log io.Writer) error {
+	_, _ = fmt.Fprintf(log, "The bridge at %s no longer accepts huey's credentials.\n", cfg.BridgeIP)
+	_, _ = fmt.Fprintln(log, "They may have been removed in the Hue app.")
+
+	if !IsTerminal(os.Stdin) {
+		return fmt.Errorf("run 'huey auth reset' to pair again: %w", hue.ErrUnauthorized)
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	answer, err := readLine(reader, log, "Pair again now? [Y/n] ")
+	if err != nil {
+		return err
+	}
+	if answer != "" && !strings.EqualFold(answer, "y") && !strings.EqualFold(answer, "yes") {
+		return fmt.Errorf("run 'huey auth reset' to pair again: %w", hue.ErrUnauthorized)
+	}
+
+	_, _ = fmt.Fprintln(log, "\nPress the link button on your Hue bridge.")
+	creds, err := WaitForLink(ctx, cfg.BridgeIP, DefaultLinkWait, Countdown(log), pairingOptions(cfg)...)
+	_, _ = fmt.Fprintln(log)
+	if err != nil {
+		return fmt.Errorf("registration failed: %w", err)
+	}
+
+	cfg.SetCredentials(creds.Username, creds.ClientKey)
+	if err := cfg.Save(); err != nil {
+		return fmt.Errorf("save config: %w", err)
+	}
+
+	_, _ = fmt.Fprintln(log, "✓ Paired again, configuration saved")
+	return nil
+}
+
+// IsTerminal reports whether f is an interactive terminal.
+func IsTerminal(f *os.File) bool {
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
+
+// promptBridgeIP searches the network for bridges and lets the user pick
+// one, falling back to typing an address when none are found.
+func promptBridgeIP(ctx context.Context) (string, error) {
 	fmt.Println("No Hue bridge configured.")
-	fmt.Println("Find your bridge IP at: https://discovery.meethue.com/")
-	fmt.Print("\nEnter bridge IP address: ")
+	fmt.Println("Searching for bridges on your network...")
+
+	bridges, err := discovery.Discover(ctx)
+	if err == nil && len(bridges) == 0 {
+		// Multicast may be blocked; try asking every local address directly.
+		fmt.Println("No bridges answered, probing the local network...")
+		bridges, err = (&discovery.Discoverer{Probe: true}).Discover(ctx)
+	}
+	if err != nil {
+		fmt.Printf("Discovery failed: %v\n", err)
+	}
 
 	reader := bufio.NewReader(os.Stdin)
-	input, err := reader.ReadString('\n')
+
+	if len(bridges) == 0 {
+		fmt.Println("No bridges found.")
+		fmt.Println("Find your bridge IP at: https://discovery.meethue.com/")
+		return readBridgeIP(reader, "\nEnter bridge IP address: ")
+	}
+
+	fmt.Println()
+	for i, b := range bridges {
+		fmt.Printf("  %d) %s\n", i+1, describeBridge(b))
+	}
+
+	input, err := readLine(reader, os.Stdout, fmt.Sprintf("\nSelect a bridge [1-%d] or enter an IP address: ", len(bridges)))
 	if err != nil {
-		return "", fmt.Errorf("read input: %w", err)
+		return "", err
+	}
+	if input == "" && len(bridges) == 1 {
+		return bridges[0].IP, nil
+	}
+	if n, err := strconv.Atoi(input); err == nil {
+		if n < 1 || n > len(bridges) {
+			return "", fmt.Errorf("invalid selection %d", n)
+		}
+		return bridges[n-1].IP, nil
+	}
+	if input == "" {
+		return "", fmt.Errorf("bridge IP cannot be empty")
+	}
+	return input, nil
+}
+
+// describeBridge formats a discovered bridge for the pick-list.
+func describeBridge(b discovery.Bridge) string {
+	name := cmp.Or(b.Name, "Hue Bridge")
+	details := []string{b.IP}
+	if b.ID != "" {
+		details = append(details, "ID "+b.ID)
+	}
+	if b.ModelID != "" {
+		details = append(details, b.ModelID)
 	}
+	return fmt.Sprintf("%s (%s)", name, strings.Join(details, ", "))
+}
 
-	ip := strings.TrimSpace(input)
+// readBridgeIP prompts for a bridge IP address.
+func readBridgeIP(reader *bufio.Reader, prompt string) (string, error) {
+	ip, err := readLine(reader, os.Stdout, prompt)
+	if err != nil {
+		return "", err
+	}
 	if ip == "" {
 		return "", fmt.Errorf("bridge IP cannot be empty")
 	}
-
 	return ip, nil
 }
 
-// registerWithBridge prompts user to press link button, then registers.
-func registerWithBridge(bridgeIP string) (string, error) {
-	fmt.Println("\nTo authorize huey, press the link button on your Hue bridge.")
-	fmt.Print("Press Enter when ready...")
-
-	reader := bufio.NewReader(os.Stdin)
-	_, err := reader.ReadString('\n')
+// readLine prints prompt on w and returns the trimmed line the user typed.
+func readLine(reader *bufio.Reader, w io.Writer, prompt string) (string, error) {
+	_, _ = fmt.Fprint(w, prompt)
+	input, err := reader.ReadString('\n')
 	if err != nil {
 		return "", fmt.Errorf("read input: %w", err)
 	}
+	return strings.TrimSpace(input), nil
+}
 
-	fmt.Println("Registering with bridge...")
-
-	client := hue.NewClient(bridgeIP, "")
-	hostname, _ := os.Hostname()
-	deviceType := fmt.Sprintf("huey#%s", cmp.Or(hostname, "cli"))
+// registerWithBridge asks the user to press the link button and waits
+// for it, showing a countdown.
+func registerWithBridge(ctx context.Context, bridgeIP string, opts ...hue.Option) (*hue.Credentials, error) {
+	fmt.Println("\nTo authorize huey, press the link button on your Hue bridge.")
 
-	username, err := client.Register(deviceType)
+	creds, err := WaitForLink(ctx, bridgeIP, DefaultLinkWait, Countdown(os.Stdout), opts...)
+	fmt.Println()
 	if err != nil {
-		return "", fmt.Errorf("registration failed: %w", err)
+		return nil, fmt.Errorf("registration failed: %w", err)
 	}
 
 	fmt.Println("✓ Registered successfully")
-	return username, nil
+	return creds, nil
 }
@@ -0,0 +1,29 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/LarsEckart/huey/config"
+	"github.com/LarsEckart/huey/hue"
+)
+
+func TestRepair_NonInteractive(t *testing.T) {
+	// Tests don't run with a terminal on stdin, so Repair must not prompt.
+	cfg := &config.Config{BridgeIP: "192.168.1.100", BridgeID: "001788fffe23bfc2", Username: "revoked"}
+
+	var log bytes.Buffer
+	err := Repair(context.Background(), cfg, &log)
+	if !errors.Is(err, hue.ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized, got: %v", err)
+	}
+	if cfg.Username != "revoked" || cfg.BridgeID != "001788fffe23bfc2" {
+		t.Errorf("config should be unchanged, got %+v", cfg)
+	}
+	if !strings.Contains(log.String(), "192.168.1.100 no longer accepts") {
+		t.Errorf("expected the explanation on log, got %q", log.String())
+	}
+}
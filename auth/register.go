@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/LarsEckart/huey/config"
+	"github.com/LarsEckart/huey/hue"
+)
+
+// DefaultLinkWait is how long registration waits for the link button.
+const DefaultLinkWait = 30 * time.Second
+
+// linkPollInterval is how often registration is retried while waiting.
+var linkPollInterval = time.Second
+
+// WaitForLink registers huey with the bridge at bridgeIP, retrying while
+// the link button has not been pressed, until it succeeds or wait elapses.
+// progress, if not nil, is called before each attempt with the time left.
+// Errors other than the link button not being pressed end the wait early.
+// opts configure the client, e.g. to register over HTTPS.
+func WaitForLink(ctx context.Context, bridgeIP string, wait time.Duration, progress func(remaining time.Duration), opts ...hue.Option) (*hue.Credentials, error) {
+	client := hue.NewClient(bridgeIP, "", opts...)
+	deadline := time.Now().Add(wait)
+
+	ticker := time.NewTicker(linkPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if progress != nil {
+			progress(max(0, time.Until(deadline)))
+		}
+
+		creds, err := client.Register(ctx, deviceType())
+		if err == nil {
+			return creds, nil
+		}
+		if !errors.Is(err, hue.ErrLinkButtonNotPressed) {
+			return nil, err
+		}
+		if !time.Now().Before(deadline) {
+			return nil, fmt.Errorf("link button was not pressed within %s", wait)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Countdown returns a progress function for WaitForLink that rewrites a
+// single status line on w.
+func Countdown(w io.Writer) func(remaining time.Duration) {
+	return func(remaining time.Duration) {
+		_, _ = fmt.Fprintf(w, "\rWaiting for the link button to be pressed... %2ds ", int(remaining.Round(time.Second).Seconds()))
+	}
+}
+
+// deviceType identifies this installation to the bridge, e.g. "huey#macbook".
+func deviceType() string {
+	hostname, _ := os.Hostname()
+	return fmt.Sprintf("huey#%s", cmp.Or(hostname, "cli"))
+}
+
+// Pair registers huey with the bridge at bridgeIP without prompting,
+// waiting up to wait for the link button, and saves the new credentials.
+// Other settings in the config are kept, and HTTPS is used if it is on.
+func Pair(ctx context.Context, bridgeIP string, wait time.Duration, progress func(remaining time.Duration)) (*config.Config, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+
+	if bridgeIP != cfg.BridgeIP {
+		// Another bridge: its ID and certificate are learned anew.
+		cfg.BridgeIP, cfg.BridgeID, cfg.CertFingerprint = bridgeIP, "", ""
+	}
+
+	creds, err := WaitForLink(ctx, bridgeIP, wait, progress, pairingOptions(cfg)...)
+	if err != nil {
+		return nil, fmt.Errorf("registration failed: %w", err)
+	}
+
+	cfg.SetCredentials(creds.Username, creds.ClientKey)
+	if bridge, err := hue.NewClient(bridgeIP, "", pairingOptions(cfg)...).GetBridgeConfig(ctx); err == nil {
+		cfg.BridgeID = bridge.BridgeID
+	}
+
+	if err := cfg.Save(); err != nil {
+		return nil, fmt.Errorf("save config: %w", err)
+	}
+	return cfg, nil
+}
+
+// pairingOptions returns the client options for talking to the bridge in
+// cfg before huey is paired with it: over HTTPS if the config asks for it,
+// checking the certificate like NewClient does. A certificate pinned on
+// the way is stored in cfg for the caller to save.
+func pairingOptions(cfg *config.Config) []hue.Option {
+	opts := ClientOptions(cfg)
+	if cfg.HTTPS {
+		opts = append(opts, hue.WithHTTPS(hue.CertPolicy{
+			BridgeID:    cfg.BridgeID,
+			Fingerprint: cfg.CertFingerprint,
+			OnPin:       func(fingerprint string) { cfg.CertFingerprint = fingerprint },
+		}))
+	}
+	return opts
+}
@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/LarsEckart/huey/hue"
+)
+
+func fastPolling(t *testing.T) {
+	t.Helper()
+	original := linkPollInterval
+	linkPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { linkPollInterval = original })
+}
+
+// linkServer answers registration with "link button not pressed" until
+// pressAfter attempts have been made.
+func linkServer(t *testing.T, pressAfter int32) (string, *atomic.Int32) {
+	t.Helper()
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api":
+			if attempts.Add(1) <= pressAfter {
+				_, _ = w.Write([]byte(`[{"error":{"type":101,"address":"","description":"link button not pressed"}}]`))
+				return
+			}
+			_, _ = w.Write([]byte(`[{"success":{"username":"abc123","clientkey":"0123456789ABCDEF0123456789ABCDEF"}}]`))
+		case "/api/config":
+			_, _ = w.Write([]byte(`{"name":"Hue Bridge","bridgeid":"001788FFFE23BFC2"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return strings.TrimPrefix(server.URL, "http://"), &attempts
+}
+
+func TestWaitForLink_PollsUntilPressed(t *testing.T) {
+	fastPolling(t)
+	addr, attempts := linkServer(t, 3)
+
+	var ticks int
+	creds, err := WaitForLink(context.Background(), addr, 5*time.Second, func(time.Duration) { ticks++ })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if creds.Username != "abc123" {
+		t.Errorf("expected username abc123, got %s", creds.Username)
+	}
+	if attempts.Load() != 4 {
+		t.Errorf("expected 4 attempts, got %d", attempts.Load())
+	}
+	if ticks != 4 {
+		t.Errorf("expected progress before each attempt, got %d calls", ticks)
+	}
+}
+
+func TestWaitForLink_TimesOut(t *testing.T) {
+	fastPolling(t)
+	addr, _ := linkServer(t, 1000)
+
+	_, err := WaitForLink(context.Background(), addr, 50*time.Millisecond, nil)
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if !strings.Contains(err.Error(), "not pressed within 50ms") {
+		t.Errorf("expected timeout error, got: %v", err)
+	}
+}
+
+func TestWaitForLink_StopsOnOtherErrors(t *testing.T) {
+	fastPolling(t)
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		_, _ = w.Write([]byte(`[{"error":{"type":7,"address":"/devicetype","description":"invalid value"}}]`))
+	}))
+	defer server.Close()
+
+	_, err := WaitForLink(context.Background(), strings.TrimPrefix(server.URL, "http://"), 5*time.Second, nil)
+	if err == nil || errors.Is(err, hue.ErrLinkButtonNotPressed) {
+		t.Fatalf("expected other bridge error, got: %v", err)
+	}
+	if attempts.Load() != 1 {
+		t.Errorf("expected a single attempt, got %d", attempts.Load())
+	}
+}
+
+func TestWaitForLink_Cancelled(t *testing.T) {
+	fastPolling(t)
+	addr, _ := linkServer(t, 1000)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+
+	_, err := WaitForLink(ctx, addr, 5*time.Second, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context deadline error, got: %v", err)
+	}
+}
+
+func TestPair_SavesConfig(t *testing.T) {
+	fastPolling(t)
+	t.Setenv("HOME", t.TempDir())
+	addr, _ := linkServer(t, 1)
+
+	cfg, err := Pair(context.Background(), addr, 5*time.Second, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.BridgeIP != addr || cfg.Username != "abc123" || cfg.BridgeID != "001788fffe23bfc2" {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+	if cfg.ClientKey != "0123456789ABCDEF0123456789ABCDEF" {
+		t.Errorf("expected client key to be saved, got %q", cfg.ClientKey)
+	}
+}
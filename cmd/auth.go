@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/LarsEckart/huey/auth"
+	"github.com/LarsEckart/huey/config"
+	"github.com/LarsEckart/huey/hue/discovery"
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagAuthBridge string
+	flagAuthWait   time.Duration
+	flagAuthRepin  bool
+)
+
+// AuthCmd pairs huey with a bridge without prompting, for use in scripts.
+var AuthCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Pair with a Hue bridge",
+	Long: `Pair with a Hue bridge without prompting. Press the link button on the
+bridge within --wait; huey keeps trying until it is pressed. Without
+--bridge, the bridge is found by discovery if exactly one is on the network.
+
+With --repin, huey instead trusts the HTTPS certificate the configured
+bridge presents now, replacing the pinned one, for example after the
+bridge was reset.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if flagAuthRepin {
+			return repin(cmd)
+		}
+
+		bridgeIP := flagAuthBridge
+		if bridgeIP == "" {
+			var err error
+			bridgeIP, err = discoverSingleBridge(cmd)
+			if err != nil {
+				return err
+			}
+		}
+
+		return pair(cmd, bridgeIP)
+	},
+}
+
+// authResetCmd forgets the saved username and pairs again.
+var authResetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Clear the saved credentials and pair again",
+	Long: `Clear the saved username and pair with the configured bridge again, for
+example after huey's access was removed in the Hue app. Press the link
+button on the bridge within --wait.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+
+		bridgeIP := cmp.Or(flagAuthBridge, cfg.BridgeIP)
+		if bridgeIP == "" {
+			bridgeIP, err = discoverSingleBridge(cmd)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Clear first, so a failed pairing doesn't leave revoked credentials behind.
+		cfg.SetCredentials("", "")
+		if err := cfg.Save(); err != nil {
+			return fmt.Errorf("save config: %w", err)
+		}
+
+		return pair(cmd, bridgeIP)
+	},
+}
+
+// pair waits for the link button on the bridge at bridgeIP and saves the
+// new credentials.
+func pair(cmd *cobra.Command, bridgeIP string) error {
+	stderr := cmd.ErrOrStderr()
+	_, _ = fmt.Fprintf(stderr, "Press the link button on the Hue bridge at %s.\n", bridgeIP)
+	cfg, err := auth.Pair(cmd.Context(), bridgeIP, flagAuthWait, linkProgress(stderr))
+	_, _ = fmt.Fprintln(stderr)
+	if err != nil {
+		return err
+	}
+
+	record := authRecord{BridgeIP: cfg.BridgeIP, BridgeID: cfg.BridgeID, Username: cfg.Username}
+	return render(cmd, record, func(w io.Writer) {
+		_, _ = fmt.Fprintf(w, "✓ Paired with bridge at %s, configuration saved\n", cfg.BridgeIP)
+	})
+}
+
+// repin pins the certificate of the configured bridge again.
+func repin(cmd *cobra.Command) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	if !cfg.IsConfigured() {
+		return fmt.Errorf("no bridge configured, run 'huey auth' first")
+	}
+
+	fingerprint, err := auth.Repin(cmd.Context(), cfg)
+	if err != nil {
+		return err
+	}
+
+	record := pinRecord{BridgeIP: cfg.BridgeIP, BridgeID: cfg.BridgeID, Fingerprint: fingerprint}
+	return render(cmd, record, func(w io.Writer) {
+		if fingerprint == "" {
+			_, _ = fmt.Fprintf(w, "✓ Bridge certificate is issued by Signify, no pin needed\n")
+			return
+		}
+		_, _ = fmt.Fprintf(w, "✓ Pinned bridge certificate %s\n", fingerprint)
+	})
+}
+
+// discoverSingleBridge returns the address of the only bridge on the network.
+func discoverSingleBridge(cmd *cobra.Command) (string, error) {
+	bridges, err := discovery.Discover(cmd.Context())
+	if err != nil {
+		return "", fmt.Errorf("discover bridges: %w", err)
+	}
+
+	switch len(bridges) {
+	case 0:
+		return "", fmt.Errorf("no bridge found, use --bridge <ip>")
+	case 1:
+		return bridges[0].IP, nil
+	default:
+		ips := make([]string, 0, len(bridges))
+		for _, b := range bridges {
+			ips = append(ips, b.IP)
+		}
+		return "", fmt.Errorf("found %d bridges (%s), use --bridge <ip> to pick one", len(bridges), strings.Join(ips, ", "))
+	}
+}
+
+// linkProgress shows a countdown while waiting for the link button when w
+// is a terminal, and stays quiet in scripts.
+func linkProgress(w io.Writer) func(time.Duration) {
+	if f, ok := w.(*os.File); ok && auth.IsTerminal(f) {
+		return auth.Countdown(w)
+	}
+	return nil
+}
+
+func init() {
+	AuthCmd.PersistentFlags().StringVar(&flagAuthBridge, "bridge", "", "Bridge IP address (default: configured bridge, or discover)")
+	AuthCmd.PersistentFlags().DurationVar(&flagAuthWait, "wait", auth.DefaultLinkWait, "How long to wait for the link button")
+	AuthCmd.Flags().BoolVar(&flagAuthRepin, "repin", false, "Trust the bridge's current HTTPS certificate instead of pairing")
+	AuthCmd.AddCommand(authResetCmd)
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/LarsEckart/huey/hue/discovery"
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagDiscoverTimeout time.Duration
+	flagDiscoverProbe   bool
+)
+
+// DiscoverCmd lists the Hue bridges on the local network.
+var DiscoverCmd = &cobra.Command{
+	Use:   "discover",
+	Short: "Find Hue bridges on the local network",
+	Long:  "Find Hue bridges on the local network using mDNS and SSDP, and optionally by probing every address in the local subnets.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		d := &discovery.Discoverer{Timeout: flagDiscoverTimeout, Probe: flagDiscoverProbe}
+		bridges, err := d.Discover(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("discover bridges: %w", err)
+		}
+
+		records := make([]bridgeRecord, 0, len(bridges))
+		for _, bridge := range bridges {
+			records = append(records, newBridgeRecord(bridge))
+		}
+
+		return render(cmd, records, func(w io.Writer) {
+			if len(bridges) == 0 {
+				_, _ = fmt.Fprintln(w, "No bridges found. Try --probe if your network blocks multicast.")
+				return
+			}
+			for _, bridge := range bridges {
+				_, _ = fmt.Fprintf(w, "%-15s  %-16s  %-20s  %s\n", bridge.IP, bridge.ID, bridge.Name, bridge.ModelID)
+			}
+		})
+	},
+}
+
+func init() {
+	DiscoverCmd.Flags().DurationVar(&flagDiscoverTimeout, "timeout", discovery.DefaultTimeout, "How long to wait for answers")
+	DiscoverCmd.Flags().BoolVar(&flagDiscoverProbe, "probe", false, "Also probe every address in the local subnets")
+}
@@ -1,36 +1,33 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"io"
 
 	"github.com/LarsEckart/huey/hue"
 	"github.com/spf13/cobra"
 )
 
 var (
-	groupFlagOn     bool
-	groupFlagOff    bool
-	groupFlagToggle bool
-	groupFlagName   string
-	groupFlagDelete bool
+	groupFlagOn       bool
+	groupFlagOff      bool
+	groupFlagToggle   bool
+	groupFlagName     string
+	groupFlagDelete   bool
+	groupFlagIdentify bool
+
+	groupStateFlags stateFlags
 )
 
 // GroupCmd controls a single group.
 var GroupCmd = &cobra.Command{
-	Use:   "group <id>",
+	Use:   "group <id|name>",
 	Short: "Control a single group",
-	Args:  cobra.ExactArgs(1),
+	Long: "Control a single group, identified by ID, name, unique name prefix, or a close match of its name.\n" +
+		"Group 0 holds all lights.",
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		groupID := args[0]
-
-		if groupFlagDelete {
-			return deleteGroup(groupID)
-		}
-
-		if groupFlagName != "" {
-			return renameGroup(groupID, groupFlagName)
-		}
-
 		flagCount := 0
 		if groupFlagOn {
 			flagCount++
@@ -42,144 +39,204 @@ var GroupCmd = &cobra.Command{
 			flagCount++
 		}
 
-		if flagCount == 0 {
-			return showGroup(groupID)
-		}
-
+		hasState := groupStateFlags.changed(cmd.Flags())
 		if flagCount > 1 {
 			return fmt.Errorf("use only one of --on, --off, or --toggle")
 		}
+		if groupFlagOff && hasState {
+			return fmt.Errorf("--off cannot be combined with brightness, color or effect flags")
+		}
+		if groupFlagIdentify && (flagCount > 0 || hasState || groupFlagName != "" || groupFlagDelete) {
+			return fmt.Errorf("--identify cannot be combined with other flags")
+		}
+		if cmd.Flags().Changed("transition") && flagCount == 0 && !hasState {
+			return fmt.Errorf("--transition needs a state change such as --on or --brightness")
+		}
 
-		client, err := authenticatedClient()
+		state, err := groupStateFlags.lightState(cmd.Flags())
 		if err != nil {
 			return err
 		}
 
-		var targetOn bool
-		if groupFlagToggle {
-			groups, err := client.GetGroups()
+		client, err := authenticatedClient(cmd)
+		if err != nil {
+			return err
+		}
+
+		if !groupFlagDelete && !groupFlagIdentify && groupFlagName == "" && flagCount == 0 && !hasState {
+			// Showing needs the group and its lights; get both in one request.
+			bridgeState, err := client.GetFullState(cmd.Context())
 			if err != nil {
-				return fmt.Errorf("get groups: %w", err)
+				return fmt.Errorf("get bridge state: %w", err)
+			}
+			var group hue.Group
+			if args[0] == allLightsGroup {
+				group, err = resolveGroup(cmd.Context(), client, args[0])
+			} else {
+				group, err = ResolveGroup(bridgeState.Groups, args[0])
 			}
+			if err != nil {
+				return err
+			}
+			return showGroup(cmd, group, bridgeState.Lights)
+		}
 
-			found := false
-			for _, g := range groups {
-				if g.ID == groupID {
-					targetOn = !g.AnyOn
-					found = true
-					break
-				}
+		group, err := resolveGroup(cmd.Context(), client, args[0])
+		if err != nil {
+			return err
+		}
+
+		if groupFlagDelete {
+			if err := exactMatch("group", args[0], group.ID, group.Name); err != nil {
+				return err
 			}
-			if !found {
-				return fmt.Errorf("group %s not found", groupID)
+			return deleteGroup(cmd, client, group)
+		}
+
+		if groupFlagName != "" {
+			return renameGroup(cmd, client, group, groupFlagName)
+		}
+
+		if groupFlagIdentify {
+			return identifyGroup(cmd, client, group)
+		}
+
+		var targetOn bool
+		if groupFlagToggle {
+			targetOn = !group.AnyOn
+			if !targetOn && hasState {
+				return fmt.Errorf("--toggle turns group %s off, which cannot be combined with brightness, color or effect flags", group.ID)
 			}
 		} else {
-			targetOn = groupFlagOn
+			// Brightness and color changes imply turning the lights on.
+			targetOn = groupFlagOn || hasState
+		}
+		// Stepping the brightness only changes lights that are already on.
+		if flagCount > 0 || !brightnessStepOnly(state) {
+			state.On = &targetOn
 		}
 
-		action := hue.GroupAction{On: &targetOn}
-		if err := client.SetGroupState(groupID, action); err != nil {
+		action := groupActionFromState(state)
+		if err := client.SetGroupState(cmd.Context(), group.ID, action); err != nil {
 			return fmt.Errorf("set group state: %w", err)
 		}
 
-		status := "off"
-		if targetOn {
-			status = "on"
+		message := fmt.Sprintf("Group %s set to %s", group.ID, describeState(state))
+		if !hasState {
+			status := "off"
+			if targetOn {
+				status = "on"
+			}
+			message = fmt.Sprintf("Group %s turned %s", group.ID, status)
+		}
+
+		result := mutationResult{Action: "set", Resource: "group", ID: group.ID}
+		if structuredOutput() {
+			updated, err := resolveGroup(cmd.Context(), client, group.ID)
+			if err != nil {
+				return err
+			}
+			result.Old = newGroupRecord(group)
+			result.New = newGroupRecord(updated)
 		}
-		fmt.Printf("Group %s turned %s\n", groupID, status)
-		return nil
+		return renderResult(cmd, result, message)
 	},
 }
 
-func showGroup(groupID string) error {
-	client, err := authenticatedClient()
-	if err != nil {
-		return err
-	}
-
-	groups, err := client.GetGroups()
-	if err != nil {
-		return fmt.Errorf("get groups: %w", err)
-	}
+// allLightsGroup is the ID of the group the bridge keeps with all lights
+// in it. It is left out of the group list, but can be controlled like any
+// other group.
+const allLightsGroup = "0"
 
-	var group *hue.Group
-	for _, g := range groups {
-		if g.ID == groupID {
-			group = &g
-			break
+// resolveGroup looks up a group by ID or name.
+func resolveGroup(ctx context.Context, client *hue.Client, query string) (hue.Group, error) {
+	if query == allLightsGroup {
+		group, err := client.GetGroup(ctx, query)
+		if err != nil {
+			return hue.Group{}, fmt.Errorf("get group: %w", err)
 		}
+		return *group, nil
 	}
 
-	if group == nil {
-		return fmt.Errorf("group %s not found", groupID)
-	}
-
-	lights, err := client.GetLights()
+	groups, err := client.GetGroups(ctx)
 	if err != nil {
-		return fmt.Errorf("get lights: %w", err)
+		return hue.Group{}, fmt.Errorf("get groups: %w", err)
 	}
+	return ResolveGroup(groups, query)
+}
 
+func showGroup(cmd *cobra.Command, group hue.Group, lights []hue.Light) error {
+	// Light names are only shown in the table view.
 	lightByID := make(map[string]hue.Light)
 	for _, l := range lights {
 		lightByID[l.ID] = l
 	}
 
-	var status string
-	if group.AllOn {
-		status = "all on"
-	} else if group.AnyOn {
-		status = "some on"
-	} else {
-		status = "all off"
-	}
-
-	fmt.Printf("ID:     %s\n", group.ID)
-	fmt.Printf("Name:   %s\n", group.Name)
-	fmt.Printf("Type:   %s\n", group.Type)
-	fmt.Printf("State:  %s\n", status)
-	fmt.Printf("Lights:\n")
-	for _, lightID := range group.Lights {
-		light, ok := lightByID[lightID]
-		if ok {
-			state := "off"
-			if light.On {
-				state = "on"
-			}
-			fmt.Printf("  %s. %s (%s)\n", lightID, light.Name, state)
+	return render(cmd, newGroupRecord(group), func(w io.Writer) {
+		var status string
+		if group.AllOn {
+			status = "all on"
+		} else if group.AnyOn {
+			status = "some on"
 		} else {
-			fmt.Printf("  %s. (unknown)\n", lightID)
+			status = "all off"
 		}
-	}
 
-	return nil
+		_, _ = fmt.Fprintf(w, "ID:     %s\n", group.ID)
+		_, _ = fmt.Fprintf(w, "Name:   %s\n", group.Name)
+		_, _ = fmt.Fprintf(w, "Type:   %s\n", group.Type)
+		_, _ = fmt.Fprintf(w, "State:  %s\n", status)
+		_, _ = fmt.Fprintf(w, "Lights:\n")
+		for _, lightID := range group.Lights {
+			light, ok := lightByID[lightID]
+			if ok {
+				state := "off"
+				if light.On {
+					state = "on"
+				}
+				_, _ = fmt.Fprintf(w, "  %s. %s (%s)\n", lightID, light.Name, state)
+			} else {
+				_, _ = fmt.Fprintf(w, "  %s. (unknown)\n", lightID)
+			}
+		}
+	})
 }
 
-func renameGroup(groupID, name string) error {
-	client, err := authenticatedClient()
-	if err != nil {
-		return err
+func renameGroup(cmd *cobra.Command, client *hue.Client, group hue.Group, name string) error {
+	if err := client.RenameGroup(cmd.Context(), group.ID, name); err != nil {
+		return fmt.Errorf("rename group: %w", err)
 	}
 
-	if err := client.RenameGroup(groupID, name); err != nil {
-		return fmt.Errorf("rename group: %w", err)
+	result := mutationResult{Action: "rename", Resource: "group", ID: group.ID}
+	if structuredOutput() {
+		updated, err := resolveGroup(cmd.Context(), client, group.ID)
+		if err != nil {
+			return err
+		}
+		result.Old = newGroupRecord(group)
+		result.New = newGroupRecord(updated)
 	}
 
-	fmt.Printf("Group %s renamed to %q\n", groupID, name)
-	return nil
+	return renderResult(cmd, result, fmt.Sprintf("Group %s renamed to %q", group.ID, name))
 }
 
-func deleteGroup(groupID string) error {
-	client, err := authenticatedClient()
-	if err != nil {
-		return err
+// identifyGroup makes all lights in a group blink so they can be found.
+func identifyGroup(cmd *cobra.Command, client *hue.Client, group hue.Group) error {
+	if err := client.SetGroupState(cmd.Context(), group.ID, hue.GroupAction{Alert: hue.AlertLongSelect}); err != nil {
+		return fmt.Errorf("identify group: %w", err)
 	}
 
-	if err := client.DeleteGroup(groupID); err != nil {
+	result := mutationResult{Action: "identify", Resource: "group", ID: group.ID}
+	return renderResult(cmd, result, fmt.Sprintf("Group %s (%s) is blinking for 15 seconds", group.ID, group.Name))
+}
+
+func deleteGroup(cmd *cobra.Command, client *hue.Client, group hue.Group) error {
+	if err := client.DeleteGroup(cmd.Context(), group.ID); err != nil {
 		return fmt.Errorf("delete group: %w", err)
 	}
 
-	fmt.Printf("Group %s deleted\n", groupID)
-	return nil
+	result := mutationResult{Action: "delete", Resource: "group", ID: group.ID, Old: newGroupRecord(group)}
+	return renderResult(cmd, result, fmt.Sprintf("Group %s deleted", group.ID))
 }
 
 func init() {
@@ -187,5 +244,7 @@ func init() {
 	GroupCmd.Flags().BoolVar(&groupFlagOff, "off", false, "Turn all lights in group off")
 	GroupCmd.Flags().BoolVar(&groupFlagToggle, "toggle", false, "Toggle group state")
 	GroupCmd.Flags().StringVar(&groupFlagName, "name", "", "Rename the group")
-	GroupCmd.Flags().BoolVar(&groupFlagDelete, "delete", false, "Delete the group")
+	GroupCmd.Flags().BoolVar(&groupFlagDelete, "delete", false, "Delete the group, named by its exact ID or name")
+	GroupCmd.Flags().BoolVar(&groupFlagIdentify, "identify", false, "Blink all lights in the group for 15 seconds to find them")
+	groupStateFlags.register(GroupCmd.Flags())
 }
@@ -30,30 +30,45 @@ var GroupCreateCmd = &cobra.Command{
 			return fmt.Errorf("--type must be 'room' or 'zone'")
 		}
 
+		client, err := authenticatedClient(cmd)
+		if err != nil {
+			return err
+		}
+
 		var lightIDs []string
 		if createGroupLights != "" {
-			for lightID := range strings.SplitSeq(createGroupLights, ",") {
-				lightIDs = append(lightIDs, strings.TrimSpace(lightID))
+			lights, err := client.GetLights(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("get lights: %w", err)
+			}
+			for query := range strings.SplitSeq(createGroupLights, ",") {
+				light, err := ResolveLight(lights, strings.TrimSpace(query))
+				if err != nil {
+					return err
+				}
+				lightIDs = append(lightIDs, light.ID)
 			}
 		}
 
-		client, err := authenticatedClient()
-		if err != nil {
-			return err
-		}
-
-		id, err := client.CreateGroup(createGroupName, groupType, lightIDs)
+		id, err := client.CreateGroup(cmd.Context(), createGroupName, groupType, lightIDs)
 		if err != nil {
 			return fmt.Errorf("create group: %w", err)
 		}
 
-		fmt.Printf("Created %s %q (ID: %s)\n", groupType, createGroupName, id)
-		return nil
+		result := mutationResult{Action: "create", Resource: "group", ID: id}
+		if structuredOutput() {
+			group, err := resolveGroup(cmd.Context(), client, id)
+			if err != nil {
+				return err
+			}
+			result.New = newGroupRecord(group)
+		}
+		return renderResult(cmd, result, fmt.Sprintf("Created %s %q (ID: %s)", groupType, createGroupName, id))
 	},
 }
 
 func init() {
 	GroupCreateCmd.Flags().StringVar(&createGroupName, "name", "", "Name of the group (required)")
 	GroupCreateCmd.Flags().StringVar(&createGroupType, "type", "zone", "Type: 'room' or 'zone'")
-	GroupCreateCmd.Flags().StringVar(&createGroupLights, "lights", "", "Comma-separated light IDs (e.g., '1,2,3')")
+	GroupCreateCmd.Flags().StringVar(&createGroupLights, "lights", "", "Comma-separated light IDs or names (e.g., '1,2,3')")
 }
@@ -2,7 +2,9 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 
+	"github.com/LarsEckart/huey/hue"
 	"github.com/spf13/cobra"
 )
 
@@ -11,16 +13,38 @@ var GroupsCmd = &cobra.Command{
 	Use:   "groups",
 	Short: "List all groups",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		client, err := authenticatedClient()
+		v2, err := clipClient(cmd)
+		if err != nil {
+			return err
+		}
+		if v2 != nil {
+			groups, err := groupsFromV2(cmd.Context(), v2)
+			if err != nil {
+				return fmt.Errorf("get groups: %w", err)
+			}
+			return printGroups(cmd, groups)
+		}
+
+		client, err := authenticatedClient(cmd)
 		if err != nil {
 			return err
 		}
 
-		groups, err := client.GetGroups()
+		groups, err := client.GetGroups(cmd.Context())
 		if err != nil {
 			return fmt.Errorf("get groups: %w", err)
 		}
+		return printGroups(cmd, groups)
+	},
+}
+
+func printGroups(cmd *cobra.Command, groups []hue.Group) error {
+	records := make([]groupRecord, 0, len(groups))
+	for _, group := range groups {
+		records = append(records, newGroupRecord(group))
+	}
 
+	return render(cmd, records, func(w io.Writer) {
 		for _, group := range groups {
 			var status string
 			if group.AllOn {
@@ -30,9 +54,7 @@ var GroupsCmd = &cobra.Command{
 			} else {
 				status = "all off"
 			}
-			fmt.Printf("%s  %-20s  %-10s  %s\n", group.ID, group.Name, group.Type, status)
+			_, _ = fmt.Fprintf(w, "%s  %-20s  %-10s  %s\n", group.ID, group.Name, group.Type, status)
 		}
-
-		return nil
-	},
+	})
 }
@@ -1,17 +1,85 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"os"
 
 	"github.com/LarsEckart/huey/auth"
+	"github.com/LarsEckart/huey/config"
 	"github.com/LarsEckart/huey/hue"
+	"github.com/LarsEckart/huey/hue/clip"
+	"github.com/spf13/cobra"
 )
 
-func authenticatedClient() (*hue.Client, error) {
-	cfg, err := auth.EnsureAuthenticated()
+// authenticatedClient returns a client for the configured bridge, running
+// the pairing flow first if needed. If the bridge no longer accepts
+// huey's credentials, the command's requests fail with
+// hue.ErrUnauthorized and RepairOnUnauthorized offers to pair again.
+func authenticatedClient(cmd *cobra.Command) (*hue.Client, error) {
+	cfg, err := auth.EnsureAuthenticated(cmd.Context())
 	if err != nil {
 		return nil, fmt.Errorf("ensure authentication: %w", err)
 	}
+	return auth.NewClient(cfg, os.Stderr, ClientOptions(cmd)...), nil
+}
+
+// clipClient returns a CLIP v2 client if the config selects the v2 API,
+// and nil if commands should use the v1 client.
+func clipClient(cmd *cobra.Command) (*clip.Client, error) {
+	cfg, err := auth.EnsureAuthenticated(cmd.Context())
+	if err != nil {
+		return nil, fmt.Errorf("ensure authentication: %w", err)
+	}
+	if !cfg.UseV2() {
+		return nil, nil
+	}
+
+	var opts []clip.Option
+	if cmd.Flags().Changed("bridge-timeout") {
+		opts = append(opts, clip.WithTimeout(flagBridgeTimeout))
+	}
+	return auth.NewClipClient(cfg, os.Stderr, opts...), nil
+}
 
-	return hue.NewClient(cfg.BridgeIP, cfg.Username), nil
+// RepairOnUnauthorized makes the subcommands of root offer to pair with
+// the bridge again when it rejects huey's credentials, and then run once
+// more. The rejected request changed nothing, so running again is safe.
+func RepairOnUnauthorized(root *cobra.Command) {
+	for _, c := range root.Commands() {
+		RepairOnUnauthorized(c)
+		run := c.RunE
+		if run == nil {
+			continue
+		}
+		c.RunE = func(cmd *cobra.Command, args []string) error {
+			err := run(cmd, args)
+			if !errors.Is(err, hue.ErrUnauthorized) {
+				return err
+			}
+			cfg, loadErr := config.Load()
+			if loadErr != nil {
+				return err
+			}
+			if err := auth.Repair(cmd.Context(), cfg, cmd.ErrOrStderr()); err != nil {
+				return err
+			}
+			return run(cmd, args)
+		}
+	}
+}
+
+// ClientOptions returns the hue.Client options set by the global
+// --bridge-timeout and --retries flags. They override the config file.
+func ClientOptions(cmd *cobra.Command) []hue.Option {
+	var opts []hue.Option
+	if cmd.Flags().Changed("bridge-timeout") {
+		opts = append(opts, hue.WithTimeout(flagBridgeTimeout))
+	}
+	if cmd.Flags().Changed("retries") {
+		policy := hue.DefaultRetryPolicy
+		policy.MaxRetries = flagRetries
+		opts = append(opts, hue.WithRetryPolicy(policy))
+	}
+	return opts
 }
@@ -1,31 +1,32 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"io"
 
 	"github.com/LarsEckart/huey/hue"
+	"github.com/LarsEckart/huey/hue/color"
 	"github.com/spf13/cobra"
 )
 
 var (
-	flagOn     bool
-	flagOff    bool
-	flagToggle bool
-	flagName   string
+	flagOn       bool
+	flagOff      bool
+	flagToggle   bool
+	flagName     string
+	flagIdentify bool
+
+	lightStateFlags stateFlags
 )
 
 // LightCmd controls a single light.
 var LightCmd = &cobra.Command{
-	Use:   "light <id>",
+	Use:   "light <id|name>",
 	Short: "Control a single light",
+	Long:  "Control a single light, identified by ID, name, unique name prefix, or a close match of its name.",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		lightID := args[0]
-
-		if flagName != "" {
-			return renameLight(lightID, flagName)
-		}
-
 		flagCount := 0
 		if flagOn {
 			flagCount++
@@ -37,80 +38,157 @@ var LightCmd = &cobra.Command{
 			flagCount++
 		}
 
-		if flagCount == 0 {
-			return showLight(lightID)
-		}
-
+		hasState := lightStateFlags.changed(cmd.Flags())
 		if flagCount > 1 {
 			return fmt.Errorf("use only one of --on, --off, or --toggle")
 		}
+		if flagOff && hasState {
+			return fmt.Errorf("--off cannot be combined with brightness, color or effect flags")
+		}
+		if flagIdentify && (flagCount > 0 || hasState || flagName != "") {
+			return fmt.Errorf("--identify cannot be combined with other flags")
+		}
+		if cmd.Flags().Changed("transition") && flagCount == 0 && !hasState {
+			return fmt.Errorf("--transition needs a state change such as --on or --brightness")
+		}
 
-		client, err := authenticatedClient()
+		state, err := lightStateFlags.lightState(cmd.Flags())
 		if err != nil {
 			return err
 		}
 
-		var targetOn bool
-		if flagToggle {
-			light, err := client.GetLight(lightID)
+		client, err := authenticatedClient(cmd)
+		if err != nil {
+			return err
+		}
+
+		light, err := resolveLight(cmd.Context(), client, args[0])
+		if err != nil {
+			return err
+		}
+
+		if flagName != "" {
+			return renameLight(cmd, client, light, flagName)
+		}
+
+		if flagIdentify {
+			return identifyLight(cmd, client, light)
+		}
+
+		if flagCount == 0 && !hasState {
+			return showLight(cmd, light)
+		}
+
+		if cmd.Flags().Changed("color") {
+			// Convert against the bulb's own gamut so the color is reproducible.
+			xy, err := lightStateFlags.colorXY(color.GamutFor(light.GamutType))
 			if err != nil {
-				return fmt.Errorf("get light: %w", err)
+				return err
 			}
+			state.XY = &xy
+		}
+
+		var targetOn bool
+		if flagToggle {
 			targetOn = !light.On
+			if !targetOn && hasState {
+				return fmt.Errorf("--toggle turns light %s off, which cannot be combined with brightness, color or effect flags", light.ID)
+			}
 		} else {
-			targetOn = flagOn
+			// Brightness and color changes imply turning the light on.
+			targetOn = flagOn || hasState
+		}
+		// Stepping the brightness doesn't turn the light on.
+		if flagCount > 0 || !brightnessStepOnly(state) {
+			state.On = &targetOn
+		} else if !light.On {
+			// The bridge rejects brightness changes for lights that are off.
+			return fmt.Errorf("light %s is off; add --on to turn it on and step its brightness", light.ID)
 		}
 
-		state := hue.LightState{On: &targetOn}
-		if err := client.SetLightState(lightID, state); err != nil {
+		if err := client.SetLightState(cmd.Context(), light.ID, state); err != nil {
 			return fmt.Errorf("set light state: %w", err)
 		}
 
-		status := "off"
-		if targetOn {
-			status = "on"
+		message := fmt.Sprintf("Light %s set to %s", light.ID, describeState(state))
+		if !hasState {
+			status := "off"
+			if targetOn {
+				status = "on"
+			}
+			message = fmt.Sprintf("Light %s turned %s", light.ID, status)
+		}
+
+		result := mutationResult{Action: "set", Resource: "light", ID: light.ID}
+		if structuredOutput() {
+			updated, err := client.GetLight(cmd.Context(), light.ID)
+			if err != nil {
+				return fmt.Errorf("get light: %w", err)
+			}
+			result.Old = newLightRecord(light)
+			result.New = newLightRecord(*updated)
 		}
-		fmt.Printf("Light %s turned %s\n", lightID, status)
-		return nil
+		return renderResult(cmd, result, message)
 	},
 }
 
-func showLight(lightID string) error {
-	client, err := authenticatedClient()
+// resolveLight looks up a light by ID or name.
+func resolveLight(ctx context.Context, client *hue.Client, query string) (hue.Light, error) {
+	lights, err := client.GetLights(ctx)
 	if err != nil {
-		return err
+		return hue.Light{}, fmt.Errorf("get lights: %w", err)
 	}
+	return ResolveLight(lights, query)
+}
 
-	light, err := client.GetLight(lightID)
-	if err != nil {
-		return fmt.Errorf("get light: %w", err)
-	}
+func showLight(cmd *cobra.Command, light hue.Light) error {
+	return render(cmd, newLightRecord(light), func(w io.Writer) {
+		status := "off"
+		if light.On {
+			status = "on"
+		}
+
+		_, _ = fmt.Fprintf(w, "ID:         %s\n", light.ID)
+		_, _ = fmt.Fprintf(w, "Name:       %s\n", light.Name)
+		_, _ = fmt.Fprintf(w, "Type:       %s\n", light.Type)
+		_, _ = fmt.Fprintf(w, "State:      %s\n", status)
+		_, _ = fmt.Fprintf(w, "Brightness: %d\n", light.Brightness)
+		switch light.ColorMode {
+		case "ct":
+			_, _ = fmt.Fprintf(w, "Color:      %d mired (%dK)\n", light.ColorTemp, color.MiredToKelvin(light.ColorTemp))
+		case "xy", "hs":
+			xy := color.XY{X: light.XY[0], Y: light.XY[1]}
+			_, _ = fmt.Fprintf(w, "Color:      %s (xy %.4f, %.4f)\n", xy.RGB().Hex(), xy.X, xy.Y)
+		}
+	})
+}
 
-	status := "off"
-	if light.On {
-		status = "on"
+// identifyLight makes a light blink so it can be found.
+func identifyLight(cmd *cobra.Command, client *hue.Client, light hue.Light) error {
+	if err := client.SetLightState(cmd.Context(), light.ID, hue.LightState{Alert: hue.AlertLongSelect}); err != nil {
+		return fmt.Errorf("identify light: %w", err)
 	}
 
-	fmt.Printf("ID:         %s\n", light.ID)
-	fmt.Printf("Name:       %s\n", light.Name)
-	fmt.Printf("Type:       %s\n", light.Type)
-	fmt.Printf("State:      %s\n", status)
-	fmt.Printf("Brightness: %d\n", light.Brightness)
-	return nil
+	result := mutationResult{Action: "identify", Resource: "light", ID: light.ID}
+	return renderResult(cmd, result, fmt.Sprintf("Light %s (%s) is blinking for 15 seconds", light.ID, light.Name))
 }
 
-func renameLight(lightID, name string) error {
-	client, err := authenticatedClient()
-	if err != nil {
-		return err
+func renameLight(cmd *cobra.Command, client *hue.Client, light hue.Light, name string) error {
+	if err := client.RenameLight(cmd.Context(), light.ID, name); err != nil {
+		return fmt.Errorf("rename light: %w", err)
 	}
 
-	if err := client.RenameLight(lightID, name); err != nil {
-		return fmt.Errorf("rename light: %w", err)
+	result := mutationResult{Action: "rename", Resource: "light", ID: light.ID}
+	if structuredOutput() {
+		updated, err := client.GetLight(cmd.Context(), light.ID)
+		if err != nil {
+			return fmt.Errorf("get light: %w", err)
+		}
+		result.Old = newLightRecord(light)
+		result.New = newLightRecord(*updated)
 	}
 
-	fmt.Printf("Light %s renamed to %q\n", lightID, name)
-	return nil
+	return renderResult(cmd, result, fmt.Sprintf("Light %s renamed to %q", light.ID, name))
 }
 
 func init() {
@@ -118,4 +196,6 @@ func init() {
 	LightCmd.Flags().BoolVar(&flagOff, "off", false, "Turn light off")
 	LightCmd.Flags().BoolVar(&flagToggle, "toggle", false, "Toggle light state")
 	LightCmd.Flags().StringVar(&flagName, "name", "", "Rename the light")
+	LightCmd.Flags().BoolVar(&flagIdentify, "identify", false, "Blink the light for 15 seconds to find it")
+	lightStateFlags.register(LightCmd.Flags())
 }
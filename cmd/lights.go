@@ -2,7 +2,9 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 
+	"github.com/LarsEckart/huey/hue"
 	"github.com/spf13/cobra"
 )
 
@@ -11,24 +13,44 @@ var LightsCmd = &cobra.Command{
 	Use:   "lights",
 	Short: "List all lights",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		client, err := authenticatedClient()
+		v2, err := clipClient(cmd)
+		if err != nil {
+			return err
+		}
+		if v2 != nil {
+			lights, err := v2.GetLights(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("get lights: %w", err)
+			}
+			return printLights(cmd, lightsFromV2(lights))
+		}
+
+		client, err := authenticatedClient(cmd)
 		if err != nil {
 			return err
 		}
 
-		lights, err := client.GetLights()
+		lights, err := client.GetLights(cmd.Context())
 		if err != nil {
 			return fmt.Errorf("get lights: %w", err)
 		}
+		return printLights(cmd, lights)
+	},
+}
+
+func printLights(cmd *cobra.Command, lights []hue.Light) error {
+	records := make([]lightRecord, 0, len(lights))
+	for _, light := range lights {
+		records = append(records, newLightRecord(light))
+	}
 
+	return render(cmd, records, func(w io.Writer) {
 		for _, light := range lights {
 			status := "off"
 			if light.On {
 				status = "on"
 			}
-			fmt.Printf("%s  %-20s  %s\n", light.ID, light.Name, status)
+			_, _ = fmt.Fprintf(w, "%s  %-20s  %s\n", light.ID, light.Name, status)
 		}
-
-		return nil
-	},
+	})
 }
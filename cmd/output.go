@@ -0,0 +1,400 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/LarsEckart/huey/hue"
+	"github.com/spf13/cobra"
+)
+
+// Output formats accepted by --output.
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+	outputCSV   = "csv"
+)
+
+var (
+	outputFormat   string
+	outputJSONFlag bool
+
+	flagBridgeTimeout time.Duration
+	flagRetries       int
+)
+
+// BindGlobalFlags registers the flags shared by every command on root.
+func BindGlobalFlags(root *cobra.Command) {
+	root.PersistentFlags().StringVarP(&outputFormat, "output", "o", outputTable, "Output format: table, json, yaml, or csv")
+	root.PersistentFlags().BoolVar(&outputJSONFlag, "json", false, "Shorthand for --output json")
+	root.PersistentFlags().DurationVar(&flagBridgeTimeout, "bridge-timeout", hue.DefaultTimeout, "Timeout for each bridge request (overrides timeout_ms in the config)")
+	root.PersistentFlags().IntVar(&flagRetries, "retries", hue.DefaultRetryPolicy.MaxRetries, "Retries for failed bridge requests, 0 to disable (overrides retries in the config)")
+	root.PersistentPreRunE = validateGlobalFlags
+}
+
+func validateGlobalFlags(cmd *cobra.Command, args []string) error {
+	if outputJSONFlag {
+		if cmd.Flags().Changed("output") && outputFormat != outputJSON {
+			return fmt.Errorf("--json conflicts with --output %s", outputFormat)
+		}
+		outputFormat = outputJSON
+	}
+
+	if !slices.Contains([]string{outputTable, outputJSON, outputYAML, outputCSV}, outputFormat) {
+		return fmt.Errorf("--output must be one of table, json, yaml, or csv, got %q", outputFormat)
+	}
+	if flagBridgeTimeout <= 0 {
+		return fmt.Errorf("--bridge-timeout must be positive, got %s", flagBridgeTimeout)
+	}
+	if flagRetries < 0 {
+		return fmt.Errorf("--retries cannot be negative, got %d", flagRetries)
+	}
+	return nil
+}
+
+// structuredOutput reports whether a machine-readable format was requested.
+func structuredOutput() bool {
+	return outputFormat != "" && outputFormat != outputTable
+}
+
+// render writes v in the selected output format.
+// For table output, table is called to print the human-readable form instead.
+func render(cmd *cobra.Command, v any, table func(w io.Writer)) error {
+	w := cmd.OutOrStdout()
+
+	switch outputFormat {
+	case outputJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	case outputYAML:
+		return writeYAML(w, v)
+	case outputCSV:
+		return writeCSV(w, v)
+	default:
+		table(w)
+		return nil
+	}
+}
+
+// renderResult writes the outcome of a command that changed bridge state.
+// Table output prints message as a single line.
+func renderResult(cmd *cobra.Command, result mutationResult, message string) error {
+	return render(cmd, result, func(w io.Writer) {
+		_, _ = fmt.Fprintln(w, message)
+	})
+}
+
+// recordStream writes records one at a time as they happen, for commands
+// that run until interrupted. JSON is written one object per line, YAML
+// as separate documents and CSV under a single header.
+type recordStream struct {
+	cmd         *cobra.Command
+	wroteHeader bool
+}
+
+// write writes v in the selected output format. For table output, table
+// is called to print the human-readable form instead.
+func (s *recordStream) write(v any, table func(w io.Writer)) error {
+	w := s.cmd.OutOrStdout()
+
+	switch outputFormat {
+	case outputJSON:
+		return json.NewEncoder(w).Encode(v)
+	case outputYAML:
+		if _, err := io.WriteString(w, "---\n"); err != nil {
+			return err
+		}
+		return writeYAML(w, v)
+	case outputCSV:
+		var buf bytes.Buffer
+		if err := writeCSV(&buf, v); err != nil {
+			return err
+		}
+		out := buf.String()
+		if s.wroteHeader {
+			_, out, _ = strings.Cut(out, "\n")
+		}
+		s.wroteHeader = true
+		_, err := io.WriteString(w, out)
+		return err
+	default:
+		table(w)
+		return nil
+	}
+}
+
+// field is a named struct field in output order.
+type field struct {
+	name  string
+	value reflect.Value
+}
+
+// outputFields returns the exported fields of a struct value using their
+// JSON names. Fields tagged omitempty are skipped when zero if omitEmpty is set.
+func outputFields(v reflect.Value, omitEmpty bool) []field {
+	var fields []field
+	t := v.Type()
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+
+		value := v.Field(i)
+		if omitEmpty && strings.Contains(opts, "omitempty") && value.IsZero() {
+			continue
+		}
+		fields = append(fields, field{name: name, value: value})
+	}
+	return fields
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+func isScalar(v reflect.Value) bool {
+	v = indirect(v)
+	if !v.IsValid() {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		return false
+	default:
+		return true
+	}
+}
+
+func formatScalar(v reflect.Value) string {
+	v = indirect(v)
+	if !v.IsValid() {
+		return ""
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// writeYAML writes v as a YAML document.
+func writeYAML(w io.Writer, v any) error {
+	lines := yamlLines(reflect.ValueOf(v))
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
+
+// yamlLines renders v in block style, one entry per line.
+func yamlLines(v reflect.Value) []string {
+	v = indirect(v)
+	if !v.IsValid() {
+		return []string{"null"}
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		var lines []string
+		for _, f := range outputFields(v, true) {
+			lines = append(lines, yamlEntry(f.name, f.value)...)
+		}
+		if len(lines) == 0 {
+			return []string{"{}"}
+		}
+		return lines
+
+	case reflect.Map:
+		keys := v.MapKeys()
+		slices.SortFunc(keys, func(a, b reflect.Value) int {
+			return strings.Compare(fmt.Sprint(a.Interface()), fmt.Sprint(b.Interface()))
+		})
+		var lines []string
+		for _, key := range keys {
+			lines = append(lines, yamlEntry(fmt.Sprint(key.Interface()), v.MapIndex(key))...)
+		}
+		if len(lines) == 0 {
+			return []string{"{}"}
+		}
+		return lines
+
+	case reflect.Slice, reflect.Array:
+		if v.Len() == 0 {
+			return []string{"[]"}
+		}
+		if isScalarList(v) {
+			return []string{yamlFlowList(v)}
+		}
+		var lines []string
+		for i := range v.Len() {
+			item := yamlLines(v.Index(i))
+			lines = append(lines, "- "+item[0])
+			for _, line := range item[1:] {
+				lines = append(lines, "  "+line)
+			}
+		}
+		return lines
+
+	case reflect.String:
+		return []string{yamlString(v.String())}
+
+	default:
+		return []string{formatScalar(v)}
+	}
+}
+
+func yamlEntry(key string, value reflect.Value) []string {
+	lines := yamlLines(value)
+	inner := indirect(value)
+	block := inner.IsValid() && len(lines) > 0 && lines[0] != "[]" && lines[0] != "{}" &&
+		(inner.Kind() == reflect.Struct || inner.Kind() == reflect.Map ||
+			((inner.Kind() == reflect.Slice || inner.Kind() == reflect.Array) && !isScalarList(inner)))
+	if !block {
+		return []string{yamlString(key) + ": " + lines[0]}
+	}
+
+	entry := []string{yamlString(key) + ":"}
+	for _, line := range lines {
+		entry = append(entry, "  "+line)
+	}
+	return entry
+}
+
+func isScalarList(v reflect.Value) bool {
+	for i := range v.Len() {
+		if !isScalar(v.Index(i)) {
+			return false
+		}
+	}
+	return true
+}
+
+func yamlFlowList(v reflect.Value) string {
+	items := make([]string, v.Len())
+	for i := range v.Len() {
+		items[i] = yamlLines(v.Index(i))[0]
+	}
+	return "[" + strings.Join(items, ", ") + "]"
+}
+
+// yamlString quotes s unless it is a plain word or phrase: it starts
+// with a letter, holds no control characters or characters with special
+// meaning in YAML, and would not be read as a boolean or null. Go's
+// escapes are valid in YAML's double-quoted strings.
+func yamlString(s string) string {
+	first, _ := utf8.DecodeRuneInString(s)
+	if !unicode.IsLetter(first) || strings.TrimSpace(s) != s ||
+		strings.ContainsAny(s, ":#{}[],&*!|>'\"%@`") ||
+		strings.ContainsFunc(s, func(r rune) bool { return !unicode.IsPrint(r) }) {
+		return strconv.Quote(s)
+	}
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "y", "n", "null":
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// writeCSV writes v, a struct or a slice of structs, as a header row
+// followed by one row per record. Lists are joined with ";" and nested
+// objects are encoded as JSON.
+func writeCSV(w io.Writer, v any) error {
+	value := indirect(reflect.ValueOf(v))
+
+	var rows []reflect.Value
+	var recordType reflect.Type
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		recordType = value.Type().Elem()
+		for i := range value.Len() {
+			rows = append(rows, indirect(value.Index(i)))
+		}
+	case reflect.Struct:
+		recordType = value.Type()
+		rows = append(rows, value)
+	default:
+		return fmt.Errorf("csv output needs a record or list of records, got %s", value.Kind())
+	}
+	for recordType.Kind() == reflect.Pointer {
+		recordType = recordType.Elem()
+	}
+
+	writer := csv.NewWriter(w)
+
+	var header []string
+	for _, f := range outputFields(reflect.New(recordType).Elem(), false) {
+		header = append(header, f.name)
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		var record []string
+		for _, f := range outputFields(row, false) {
+			cell, err := csvCell(f.value)
+			if err != nil {
+				return err
+			}
+			record = append(record, cell)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func csvCell(v reflect.Value) (string, error) {
+	inner := indirect(v)
+	if isScalar(inner) {
+		return formatScalar(inner), nil
+	}
+	if (inner.Kind() == reflect.Slice || inner.Kind() == reflect.Array) && isScalarList(inner) {
+		items := make([]string, inner.Len())
+		for i := range inner.Len() {
+			items[i] = formatScalar(inner.Index(i))
+		}
+		return strings.Join(items, ";"), nil
+	}
+
+	data, err := json.Marshal(inner.Interface())
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
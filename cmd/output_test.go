@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strconv"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestWriteYAML(t *testing.T) {
+	result := mutationResult{
+		Action:   "set",
+		Resource: "light",
+		ID:       "1",
+		Old:      lightRecord{ID: "1", Name: "Desk: left", Type: "Dimmable light", XY: [2]float64{0.3, 0.4}},
+	}
+
+	var buf bytes.Buffer
+	if err := writeYAML(&buf, result); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `action: set
+resource: light
+id: "1"
+old:
+  id: "1"
+  name: "Desk: left"
+  type: Dimmable light
+  "on": false
+  brightness: 0
+  hue: 0
+  saturation: 0
+  xy: [0.3, 0.4]
+  color_temp: 0
+  color_mode: ""
+`
+	if buf.String() != want {
+		t.Errorf("unexpected YAML:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestWriteYAML_ListOfRecords(t *testing.T) {
+	groups := []groupRecord{
+		{ID: "1", Name: "Office", Type: "Room", Lights: []string{"1", "2"}, AnyOn: true},
+		{ID: "2", Name: "Empty", Type: "Zone", Lights: []string{}},
+	}
+
+	var buf bytes.Buffer
+	if err := writeYAML(&buf, groups); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `- id: "1"
+  name: Office
+  type: Room
+  lights: ["1", "2"]
+  all_on: false
+  any_on: true
+- id: "2"
+  name: Empty
+  type: Zone
+  lights: []
+  all_on: false
+  any_on: false
+`
+	if buf.String() != want {
+		t.Errorf("unexpected YAML:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	groups := []groupRecord{
+		{ID: "1", Name: "Office, upstairs", Type: "Room", Lights: []string{"1", "2"}, AllOn: true, AnyOn: true},
+	}
+
+	var buf bytes.Buffer
+	if err := writeCSV(&buf, groups); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "id,name,type,lights,all_on,any_on\n1,\"Office, upstairs\",Room,1;2,true,true\n"
+	if buf.String() != want {
+		t.Errorf("unexpected CSV:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestWriteCSV_EmptyListWritesHeader(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeCSV(&buf, []lightRecord{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "id,name,type,on,brightness,hue,saturation,xy,color_temp,color_mode\n"
+	if buf.String() != want {
+		t.Errorf("unexpected CSV:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+// trickyNames are light names that need quoting or escaping in YAML or CSV.
+var trickyNames = []string{
+	"Desk",
+	"Desk: left",
+	`He said "hi"`,
+	"it's",
+	"line one\nline two",
+	"tab\there",
+	"# not a comment",
+	"- not a list",
+	"[1, 2]",
+	"{a: b}",
+	"&anchor",
+	"*alias",
+	"!tag",
+	"|",
+	"> folded",
+	"%directive",
+	"@at",
+	"`tick`",
+	" padded ",
+	"back\\slash",
+	"bell\a",
+	"yes", "No", "ON", "off", "null", "~", "true",
+	"42", "-1", "3.5", "1e3", "0x10", "0o17", ".inf", "-.Inf", ".nan",
+	"2026-10-17",
+	"Küche 💡",
+	"",
+}
+
+func TestWriteYAML_RoundTrip(t *testing.T) {
+	records := make([]lightRecord, 0, len(trickyNames))
+	for i, name := range trickyNames {
+		records = append(records, lightRecord{ID: strconv.Itoa(i + 1), Name: name})
+	}
+
+	var buf bytes.Buffer
+	if err := writeYAML(&buf, records); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var parsed []map[string]any
+	if err := yaml.Unmarshal(buf.Bytes(), &parsed); err != nil {
+		t.Fatalf("output is not valid YAML: %v\n%s", err, buf.String())
+	}
+	if len(parsed) != len(records) {
+		t.Fatalf("got %d records back, want %d", len(parsed), len(records))
+	}
+	for i, record := range records {
+		if got := parsed[i]["id"]; got != record.ID {
+			t.Errorf("id = %#v, want %q", got, record.ID)
+		}
+		if got := parsed[i]["name"]; got != record.Name {
+			t.Errorf("name = %#v, want %q", got, record.Name)
+		}
+	}
+}
+
+func TestWriteCSV_RoundTrip(t *testing.T) {
+	records := make([]groupRecord, 0, len(trickyNames))
+	for i, name := range trickyNames {
+		records = append(records, groupRecord{ID: strconv.Itoa(i + 1), Name: name, Lights: []string{"1", "2"}})
+	}
+
+	var buf bytes.Buffer
+	if err := writeCSV(&buf, records); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not valid CSV: %v", err)
+	}
+	if len(rows) != len(records)+1 {
+		t.Fatalf("got %d rows, want a header and %d records", len(rows), len(records))
+	}
+	for i, record := range records {
+		row := rows[i+1]
+		if row[0] != record.ID || row[1] != record.Name || row[3] != "1;2" {
+			t.Errorf("row = %q, want id %q, name %q and lights 1;2", row, record.ID, record.Name)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package cmd
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/LarsEckart/huey/hue"
+	"github.com/LarsEckart/huey/hue/discovery"
+)
+
+// The record types below are the documented output schemas for
+// --output json, yaml and csv. Add fields rather than renaming or
+// removing them so scripts keep working.
+
+// lightRecord describes a light.
+type lightRecord struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Type       string     `json:"type"`
+	On         bool       `json:"on"`
+	Brightness int        `json:"brightness"` // 0-254
+	Hue        int        `json:"hue"`        // 0-65535
+	Saturation int        `json:"saturation"` // 0-254
+	XY         [2]float64 `json:"xy"`
+	ColorTemp  int        `json:"color_temp"` // mired
+	ColorMode  string     `json:"color_mode"`
+}
+
+func newLightRecord(light hue.Light) lightRecord {
+	return lightRecord{
+		ID:         light.ID,
+		Name:       light.Name,
+		Type:       light.Type,
+		On:         light.On,
+		Brightness: light.Brightness,
+		Hue:        light.Hue,
+		Saturation: light.Saturation,
+		XY:         light.XY,
+		ColorTemp:  light.ColorTemp,
+		ColorMode:  light.ColorMode,
+	}
+}
+
+// groupRecord describes a group (room, zone, etc.).
+type groupRecord struct {
+	ID     string   `json:"id"`
+	Name   string   `json:"name"`
+	Type   string   `json:"type"`
+	Lights []string `json:"lights"`
+	AllOn  bool     `json:"all_on"`
+	AnyOn  bool     `json:"any_on"`
+}
+
+func newGroupRecord(group hue.Group) groupRecord {
+	return groupRecord{
+		ID:     group.ID,
+		Name:   group.Name,
+		Type:   group.Type,
+		Lights: group.Lights,
+		AllOn:  group.AllOn,
+		AnyOn:  group.AnyOn,
+	}
+}
+
+// sceneRecord describes a scene. GroupName is empty when the group is unknown.
+type sceneRecord struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Type      string   `json:"type"`
+	Group     string   `json:"group"`
+	GroupName string   `json:"group_name"`
+	Lights    []string `json:"lights"`
+}
+
+func newSceneRecord(scene hue.Scene, groupName string) sceneRecord {
+	return sceneRecord{
+		ID:        scene.ID,
+		Name:      scene.Name,
+		Type:      scene.Type,
+		Group:     scene.Group,
+		GroupName: groupName,
+		Lights:    scene.Lights,
+	}
+}
+
+// sceneLightRecord describes the state a scene sets one light to.
+// Attributes the scene leaves alone are null.
+type sceneLightRecord struct {
+	Light        string      `json:"light"`
+	Name         string      `json:"name"`
+	On           *bool       `json:"on"`
+	Brightness   *int        `json:"brightness"` // 1-254
+	Hue          *int        `json:"hue"`        // 0-65535
+	Saturation   *int        `json:"saturation"` // 0-254
+	XY           *[2]float64 `json:"xy"`
+	ColorTemp    *int        `json:"color_temp"` // mired
+	Effect       string      `json:"effect"`
+	TransitionMS *int        `json:"transition_ms"`
+}
+
+func newSceneLightRecord(lightID, name string, state hue.LightState) sceneLightRecord {
+	record := sceneLightRecord{
+		Light:      lightID,
+		Name:       name,
+		On:         state.On,
+		Brightness: state.Brightness,
+		Hue:        state.Hue,
+		Saturation: state.Saturation,
+		XY:         state.XY,
+		ColorTemp:  state.ColorTemp,
+		Effect:     state.Effect,
+	}
+	if state.TransitionTime != nil {
+		ms := *state.TransitionTime * 100
+		record.TransitionMS = &ms
+	}
+	return record
+}
+
+// sensorRecord describes a sensor. Reachable and Battery are null for
+// sensors that don't report them; LastUpdated is empty if the sensor never
+// reported.
+type sensorRecord struct {
+	ID          string         `json:"id"`
+	Name        string         `json:"name"`
+	Type        string         `json:"type"`
+	ModelID     string         `json:"model_id"`
+	On          bool           `json:"on"`
+	Reachable   *bool          `json:"reachable"`
+	Battery     *int           `json:"battery"`      // percent
+	LastUpdated string         `json:"last_updated"` // RFC 3339
+	State       map[string]any `json:"state"`        // as reported by the bridge
+}
+
+func newSensorRecord(sensor hue.Sensor) sensorRecord {
+	record := sensorRecord{
+		ID:        sensor.ID,
+		Name:      sensor.Name,
+		Type:      sensor.Type,
+		ModelID:   sensor.ModelID,
+		On:        sensor.Config.On,
+		Reachable: sensor.Config.Reachable,
+		Battery:   sensor.Config.Battery,
+		State:     sensor.RawState,
+	}
+	if !sensor.State.LastUpdated.IsZero() {
+		record.LastUpdated = sensor.State.LastUpdated.Format(time.RFC3339)
+	}
+	return record
+}
+
+// scheduleRecord describes a schedule. Time is the bridge's time pattern
+// and When the same time in the syntax of huey schedule add --at.
+type scheduleRecord struct {
+	ID          string                `json:"id"`
+	Name        string                `json:"name"`
+	Description string                `json:"description"`
+	Time        string                `json:"time"` // e.g. "W124/T07:00:00"
+	When        string                `json:"when"` // e.g. "weekdays 07:00"
+	Status      string                `json:"status"`
+	AutoDelete  bool                  `json:"auto_delete"`
+	Command     scheduleCommandRecord `json:"command"`
+}
+
+// scheduleCommandRecord is the request a schedule sends to the bridge.
+type scheduleCommandRecord struct {
+	Address string         `json:"address"`
+	Method  string         `json:"method"`
+	Body    map[string]any `json:"body"`
+}
+
+// newScheduleRecord describes schedule, whose times are in loc, the
+// bridge's time zone.
+func newScheduleRecord(schedule hue.Schedule, loc *time.Location) scheduleRecord {
+	record := scheduleRecord{
+		ID:          schedule.ID,
+		Name:        schedule.Name,
+		Description: schedule.Description,
+		Time:        schedule.LocalTime,
+		Status:      schedule.Status,
+		AutoDelete:  schedule.AutoDelete,
+		Command: scheduleCommandRecord{
+			Address: schedule.Command.Address,
+			Method:  schedule.Command.Method,
+		},
+	}
+	if p, err := hue.ParseTimePattern(schedule.LocalTime, loc); err == nil {
+		record.When = describeTimePattern(p)
+	}
+	_ = json.Unmarshal(schedule.Command.Body, &record.Command.Body)
+	return record
+}
+
+// ruleRecord describes a rule. Rule is its conditions and actions in the
+// DSL of huey rule add; LastTriggered is empty if it never triggered.
+type ruleRecord struct {
+	ID             string             `json:"id"`
+	Name           string             `json:"name"`
+	Status         string             `json:"status"`
+	Rule           string             `json:"rule"` // e.g. "when sensor 5 presence == true then group 3 on"
+	Conditions     []hue.Condition    `json:"conditions"`
+	Actions        []ruleActionRecord `json:"actions"`
+	TimesTriggered int                `json:"times_triggered"`
+	LastTriggered  string             `json:"last_triggered"` // RFC 3339
+}
+
+// ruleActionRecord is a request a rule sends to the bridge.
+type ruleActionRecord struct {
+	Address string         `json:"address"`
+	Method  string         `json:"method"`
+	Body    map[string]any `json:"body"`
+}
+
+func newRuleRecord(rule hue.Rule) ruleRecord {
+	record := ruleRecord{
+		ID:             rule.ID,
+		Name:           rule.Name,
+		Status:         rule.Status,
+		Rule:           formatRule(rule.Conditions, rule.Actions, false),
+		Conditions:     rule.Conditions,
+		Actions:        make([]ruleActionRecord, 0, len(rule.Actions)),
+		TimesTriggered: rule.TimesTriggered,
+	}
+	for _, action := range rule.Actions {
+		actionRecord := ruleActionRecord{Address: action.Address, Method: action.Method}
+		_ = json.Unmarshal(action.Body, &actionRecord.Body)
+		record.Actions = append(record.Actions, actionRecord)
+	}
+	if !rule.LastTriggered.IsZero() {
+		record.LastTriggered = rule.LastTriggered.Format(time.RFC3339)
+	}
+	return record
+}
+
+// mutationResult is returned by commands that change bridge state.
+// Old is omitted for created resources and New for deleted ones.
+type mutationResult struct {
+	Action   string `json:"action"`   // "set", "rename", "delete", "create", "activate", "identify"
+	Resource string `json:"resource"` // "light", "group", "scene", "sensor", "schedule", "rule"
+	ID       string `json:"id"`
+	Old      any    `json:"old,omitempty"`
+	New      any    `json:"new,omitempty"`
+}
+
+// bridgeRecord describes a bridge found by discovery.
+type bridgeRecord struct {
+	IP      string `json:"ip"`
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	ModelID string `json:"model_id"`
+	Source  string `json:"source"` // "mdns", "ssdp", "probe"
+}
+
+func newBridgeRecord(bridge discovery.Bridge) bridgeRecord {
+	return bridgeRecord{
+		IP:      bridge.IP,
+		ID:      bridge.ID,
+		Name:    bridge.Name,
+		ModelID: bridge.ModelID,
+		Source:  bridge.Source,
+	}
+}
+
+// authRecord describes the credentials saved by huey auth.
+type authRecord struct {
+	BridgeIP string `json:"bridge_ip"`
+	BridgeID string `json:"bridge_id"`
+	Username string `json:"username"`
+}
+
+// pinRecord describes the certificate pinned by huey auth --repin.
+// Fingerprint is empty when the certificate needs no pin.
+type pinRecord struct {
+	BridgeIP    string `json:"bridge_ip"`
+	BridgeID    string `json:"bridge_id"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// changeRecord describes a state change seen by huey watch. Field is
+// "added" or "removed", with Old and New empty, when a resource appears
+// or disappears.
+type changeRecord struct {
+	Time     string `json:"time"`     // RFC 3339 with nanoseconds
+	Resource string `json:"resource"` // "light", "group", "sensor"
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Field    string `json:"field"`
+	Old      any    `json:"old"`
+	New      any    `json:"new"`
+}
@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/LarsEckart/huey/hue"
+)
+
+// AmbiguousError is returned when a query matches more than one resource.
+type AmbiguousError struct {
+	Kind       string   // "light", "group", "scene", "sensor", "schedule", "rule"
+	Query      string   // what the user typed
+	Candidates []string // matching resources as "Name (ID)"
+}
+
+func (e *AmbiguousError) Error() string {
+	return fmt.Sprintf("%s %q is ambiguous, did you mean one of: %s", e.Kind, e.Query, strings.Join(e.Candidates, ", "))
+}
+
+// NotFoundError is returned when a query matches no resource.
+type NotFoundError struct {
+	Kind  string
+	Query string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s %q not found", e.Kind, e.Query)
+}
+
+// ResolveLight finds the light identified by query. See Resolve for the
+// matching rules.
+func ResolveLight(lights []hue.Light, query string) (hue.Light, error) {
+	return Resolve("light", query, lights,
+		func(l hue.Light) string { return l.ID },
+		func(l hue.Light) string { return l.Name })
+}
+
+// ResolveGroup finds the group identified by query. See Resolve for the
+// matching rules.
+func ResolveGroup(groups []hue.Group, query string) (hue.Group, error) {
+	return Resolve("group", query, groups,
+		func(g hue.Group) string { return g.ID },
+		func(g hue.Group) string { return g.Name })
+}
+
+// ResolveScene finds the scene identified by query. See Resolve for the
+// matching rules.
+func ResolveScene(scenes []hue.Scene, query string) (hue.Scene, error) {
+	return Resolve("scene", query, scenes,
+		func(s hue.Scene) string { return s.ID },
+		func(s hue.Scene) string { return s.Name })
+}
+
+// ResolveSensor finds the sensor identified by query. See Resolve for the
+// matching rules.
+func ResolveSensor(sensors []hue.Sensor, query string) (hue.Sensor, error) {
+	return Resolve("sensor", query, sensors,
+		func(s hue.Sensor) string { return s.ID },
+		func(s hue.Sensor) string { return s.Name })
+}
+
+// ResolveSchedule finds the schedule identified by query. See Resolve for
+// the matching rules.
+func ResolveSchedule(schedules []hue.Schedule, query string) (hue.Schedule, error) {
+	return Resolve("schedule", query, schedules,
+		func(s hue.Schedule) string { return s.ID },
+		func(s hue.Schedule) string { return s.Name })
+}
+
+// ResolveRule finds the rule identified by query. See Resolve for the
+// matching rules.
+func ResolveRule(rules []hue.Rule, query string) (hue.Rule, error) {
+	return Resolve("rule", query, rules,
+		func(r hue.Rule) string { return r.ID },
+		func(r hue.Rule) string { return r.Name })
+}
+
+// Resolve finds the item identified by query, trying in order:
+// exact ID, exact name, case-insensitive name, unique name prefix,
+// unique name substring, and finally the closest fuzzy match by edit
+// distance. The first rule that matches anything decides: one match is
+// returned, several produce an *AmbiguousError listing them.
+func Resolve[T any](kind, query string, items []T, id, name func(T) string) (T, error) {
+	var zero T
+
+	lowerQuery := strings.ToLower(strings.TrimSpace(query))
+	if lowerQuery == "" {
+		return zero, fmt.Errorf("%s name or ID cannot be empty", kind)
+	}
+
+	rules := []func(T) bool{
+		func(item T) bool { return id(item) == query },
+		func(item T) bool { return name(item) == query },
+		func(item T) bool { return strings.ToLower(name(item)) == lowerQuery },
+		func(item T) bool { return strings.HasPrefix(strings.ToLower(name(item)), lowerQuery) },
+		func(item T) bool { return strings.Contains(strings.ToLower(name(item)), lowerQuery) },
+	}
+
+	for _, matches := range rules {
+		var found []T
+		for _, item := range items {
+			if matches(item) {
+				found = append(found, item)
+			}
+		}
+		if len(found) == 1 {
+			return found[0], nil
+		}
+		if len(found) > 1 {
+			return zero, ambiguous(kind, query, found, id, name)
+		}
+	}
+
+	// Fuzzy match: allow roughly one typo per three characters.
+	maxDistance := max(1, len(lowerQuery)/3)
+	bestDistance := maxDistance + 1
+	var best []T
+	for _, item := range items {
+		distance := levenshtein(lowerQuery, strings.ToLower(name(item)))
+		switch {
+		case distance < bestDistance:
+			bestDistance = distance
+			best = []T{item}
+		case distance == bestDistance:
+			best = append(best, item)
+		}
+	}
+	if len(best) == 1 {
+		return best[0], nil
+	}
+	if len(best) > 1 {
+		return zero, ambiguous(kind, query, best, id, name)
+	}
+
+	return zero, &NotFoundError{Kind: kind, Query: query}
+}
+
+// exactMatch returns an error unless query is exactly the ID or name of
+// the resource Resolve found for it. Destructive commands require this,
+// so that a typo can't pick another resource.
+func exactMatch(kind, query, id, name string) error {
+	if query == id || query == name {
+		return nil
+	}
+	return fmt.Errorf("%s %q matches %q (%s), but deleting needs its exact ID or name", kind, query, name, id)
+}
+
+func ambiguous[T any](kind, query string, found []T, id, name func(T) string) error {
+	candidates := make([]string, 0, len(found))
+	for _, item := range found {
+		candidates = append(candidates, fmt.Sprintf("%s (%s)", name(item), id(item)))
+	}
+	return &AmbiguousError{Kind: kind, Query: query, Candidates: candidates}
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	previous := make([]int, len(br)+1)
+	current := make([]int, len(br)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(ar); i++ {
+		current[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(br)]
+}
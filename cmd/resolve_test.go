@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/LarsEckart/huey/hue"
+)
+
+func TestResolveLight(t *testing.T) {
+	lights := []hue.Light{
+		{ID: "1", Name: "Desk Lamp"},
+		{ID: "2", Name: "Ceiling"},
+		{ID: "3", Name: "Ceiling Spot"},
+		{ID: "4", Name: "Kitchen Pendant"},
+		{ID: "12", Name: "1"},
+	}
+
+	tests := []struct {
+		name   string
+		query  string
+		wantID string
+	}{
+		{name: "exact ID", query: "1", wantID: "1"},
+		{name: "exact name beats prefix", query: "Ceiling", wantID: "2"},
+		{name: "case-insensitive name", query: "desk lamp", wantID: "1"},
+		{name: "unique prefix", query: "kit", wantID: "4"},
+		{name: "unique substring", query: "spot", wantID: "3"},
+		{name: "fuzzy", query: "Desk Lmap", wantID: "1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			light, err := ResolveLight(lights, tt.query)
+			if err != nil {
+				t.Fatalf("ResolveLight(%q) unexpected error: %v", tt.query, err)
+			}
+			if light.ID != tt.wantID {
+				t.Errorf("ResolveLight(%q) = %s, want %s", tt.query, light.ID, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestResolveLight_Ambiguous(t *testing.T) {
+	lights := []hue.Light{
+		{ID: "1", Name: "Hue color lamp 1"},
+		{ID: "2", Name: "Hue color lamp 2"},
+	}
+
+	_, err := ResolveLight(lights, "hue color")
+
+	var ambiguous *AmbiguousError
+	if !errors.As(err, &ambiguous) {
+		t.Fatalf("expected AmbiguousError, got %v", err)
+	}
+	if len(ambiguous.Candidates) != 2 {
+		t.Errorf("expected 2 candidates, got %v", ambiguous.Candidates)
+	}
+}
+
+func TestResolveScene_NotFound(t *testing.T) {
+	scenes := []hue.Scene{{ID: "abc", Name: "Relax"}}
+
+	_, err := ResolveScene(scenes, "energize")
+
+	var notFound *NotFoundError
+	if !errors.As(err, &notFound) {
+		t.Fatalf("expected NotFoundError, got %v", err)
+	}
+}
+
+func TestExactMatch(t *testing.T) {
+	if err := exactMatch("scene", "abc", "abc", "Relax"); err != nil {
+		t.Errorf("exact ID: unexpected error: %v", err)
+	}
+	if err := exactMatch("scene", "Relax", "abc", "Relax"); err != nil {
+		t.Errorf("exact name: unexpected error: %v", err)
+	}
+	for _, query := range []string{"relax", "Rel", "Relxa"} {
+		if err := exactMatch("scene", query, "abc", "Relax"); err == nil {
+			t.Errorf("%q: expected an error for an inexact match", query)
+		}
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "", b: "abc", want: 3},
+		{a: "kitten", b: "sitting", want: 3},
+		{a: "relax", b: "relax", want: 0},
+		{a: "relax", b: "relxa", want: 2},
+	}
+
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
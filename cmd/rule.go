@@ -0,0 +1,247 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/LarsEckart/huey/hue"
+	"github.com/spf13/cobra"
+)
+
+var (
+	ruleFlagName    string
+	ruleFlagEnable  bool
+	ruleFlagDisable bool
+)
+
+// RuleCmd groups the commands that manage rules on the bridge.
+var RuleCmd = &cobra.Command{
+	Use:   "rule",
+	Short: "Manage rules that drive switches and motion sensors",
+	Long: "List, show, add, edit and delete the bridge's rules, written in a small language:\n\n" +
+		"  when sensor 5 buttonevent == 1002 and sensor 5 lastupdated changed then group 3 scene abc\n\n" +
+		"Conditions test \"sensor|light|group <id> <attribute>\" (an attribute in state, or a path like config/on),\n" +
+		"\"time\" or an /address with == <value>, > <value>, < <value>, changed, changed 30s ago,\n" +
+		"stable for 30s, not stable for 30s, in 08:00-20:00 or not in 08:00-20:00.\n" +
+		"Actions set \"group|light <id>\" or \"sensor <id> [config]\" with on, off, scene <id> and key=value\n" +
+		"pairs of the bridge's API, e.g. \"light 4 on bri=254 alert=select\", or send METHOD /address {json}.\n" +
+		"Join conditions and actions with \"and\". Quote the rule so the shell leaves > and < alone.",
+}
+
+var ruleListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List all rules",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := authenticatedClient(cmd)
+		if err != nil {
+			return err
+		}
+
+		rules, err := client.GetRules(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("get rules: %w", err)
+		}
+
+		records := make([]ruleRecord, 0, len(rules))
+		for _, rule := range rules {
+			records = append(records, newRuleRecord(rule))
+		}
+
+		return render(cmd, records, func(w io.Writer) {
+			for _, record := range records {
+				_, _ = fmt.Fprintf(w, "%-3s %-24s %-8s %s\n", record.ID, record.Name, record.Status, record.Rule)
+			}
+		})
+	},
+}
+
+var ruleShowCmd = &cobra.Command{
+	Use:   "show <id|name>",
+	Short: "Show a rule",
+	Long:  "Show a rule, identified by ID, name, unique name prefix, or a close match of its name, one clause per line.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := authenticatedClient(cmd)
+		if err != nil {
+			return err
+		}
+
+		rules, err := client.GetRules(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("get rules: %w", err)
+		}
+		rule, err := ResolveRule(rules, args[0])
+		if err != nil {
+			return err
+		}
+
+		return render(cmd, newRuleRecord(rule), func(w io.Writer) {
+			_, _ = fmt.Fprintf(w, "ID:       %s\n", rule.ID)
+			_, _ = fmt.Fprintf(w, "Name:     %s\n", rule.Name)
+			_, _ = fmt.Fprintf(w, "Status:   %s, %s\n", rule.Status, ruleAge(rule, time.Now()))
+			_, _ = fmt.Fprintf(w, "\n%s\n", formatRule(rule.Conditions, rule.Actions, true))
+		})
+	},
+}
+
+var ruleAddCmd = &cobra.Command{
+	Use:   "add --name <name> <rule>",
+	Short: "Add a rule",
+	Long: "Add a rule written in the language described in huey rule --help, e.g.\n\n" +
+		"  huey rule add --name \"Dimmer on\" \"when sensor 5 buttonevent == 1002 and sensor 5 lastupdated changed then group 3 scene abc\"\n\n" +
+		"The lights, groups, sensors and scenes it refers to must exist.",
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if strings.TrimSpace(ruleFlagName) == "" {
+			return fmt.Errorf("--name is required")
+		}
+		conditions, actions, err := parseRule(strings.Join(args, " "))
+		if err != nil {
+			return err
+		}
+
+		client, err := authenticatedClient(cmd)
+		if err != nil {
+			return err
+		}
+
+		state, err := client.GetFullState(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("get bridge state: %w", err)
+		}
+		if err := validateRule(conditions, actions, state); err != nil {
+			return err
+		}
+
+		rule := hue.Rule{Name: ruleFlagName, Status: hue.RuleEnabled, Conditions: conditions, Actions: actions}
+		id, err := client.CreateRule(cmd.Context(), rule)
+		if err != nil {
+			return fmt.Errorf("create rule: %w", err)
+		}
+		rule.ID = id
+
+		result := mutationResult{Action: "create", Resource: "rule", ID: id, New: newRuleRecord(rule)}
+		return renderResult(cmd, result, fmt.Sprintf("Created rule %q (%s): %s", rule.Name, id, formatRule(conditions, actions, false)))
+	},
+}
+
+var ruleEditCmd = &cobra.Command{
+	Use:   "edit <id|name> [rule]",
+	Short: "Change a rule's name, status, or conditions and actions",
+	Long: "Change a rule, identified by ID, name, unique name prefix, or a close match of its name.\n" +
+		"A new rule after the name replaces all its conditions and actions.",
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if ruleFlagEnable && ruleFlagDisable {
+			return fmt.Errorf("use only one of --enable or --disable")
+		}
+
+		var update hue.RuleUpdate
+		var changes []string
+		if cmd.Flags().Changed("name") {
+			if strings.TrimSpace(ruleFlagName) == "" {
+				return fmt.Errorf("--name cannot be empty")
+			}
+			update.Name = &ruleFlagName
+			changes = append(changes, fmt.Sprintf("name %q", ruleFlagName))
+		}
+		if ruleFlagEnable || ruleFlagDisable {
+			status := hue.RuleEnabled
+			if ruleFlagDisable {
+				status = hue.RuleDisabled
+			}
+			update.Status = &status
+			changes = append(changes, status)
+		}
+		if len(args) > 1 {
+			conditions, actions, err := parseRule(strings.Join(args[1:], " "))
+			if err != nil {
+				return err
+			}
+			update.Conditions, update.Actions = conditions, actions
+			changes = append(changes, formatRule(conditions, actions, false))
+		}
+		if len(changes) == 0 {
+			return fmt.Errorf("nothing to change, use --name, --enable, --disable or give a new rule")
+		}
+
+		client, err := authenticatedClient(cmd)
+		if err != nil {
+			return err
+		}
+
+		state, err := client.GetFullState(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("get bridge state: %w", err)
+		}
+		rule, err := ResolveRule(state.Rules, args[0])
+		if err != nil {
+			return err
+		}
+		if update.Conditions != nil {
+			if err := validateRule(update.Conditions, update.Actions, state); err != nil {
+				return err
+			}
+		}
+
+		if err := client.UpdateRule(cmd.Context(), rule.ID, update); err != nil {
+			return fmt.Errorf("update rule: %w", err)
+		}
+
+		result := mutationResult{Action: "set", Resource: "rule", ID: rule.ID}
+		if structuredOutput() {
+			updated, err := client.GetRule(cmd.Context(), rule.ID)
+			if err != nil {
+				return fmt.Errorf("get rule: %w", err)
+			}
+			result.Old = newRuleRecord(rule)
+			result.New = newRuleRecord(*updated)
+		}
+		return renderResult(cmd, result, fmt.Sprintf("Rule %q set to %s", rule.Name, strings.Join(changes, ", ")))
+	},
+}
+
+var ruleDeleteCmd = &cobra.Command{
+	Use:   "delete <id|name>",
+	Short: "Delete a rule",
+	Long:  "Delete a rule, identified by its exact ID or name.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := authenticatedClient(cmd)
+		if err != nil {
+			return err
+		}
+
+		rules, err := client.GetRules(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("get rules: %w", err)
+		}
+		rule, err := ResolveRule(rules, args[0])
+		if err != nil {
+			return err
+		}
+		if err := exactMatch("rule", args[0], rule.ID, rule.Name); err != nil {
+			return err
+		}
+
+		if err := client.DeleteRule(cmd.Context(), rule.ID); err != nil {
+			return fmt.Errorf("delete rule: %w", err)
+		}
+
+		result := mutationResult{Action: "delete", Resource: "rule", ID: rule.ID, Old: newRuleRecord(rule)}
+		return renderResult(cmd, result, fmt.Sprintf("Deleted rule %q", rule.Name))
+	},
+}
+
+func init() {
+	ruleAddCmd.Flags().StringVar(&ruleFlagName, "name", "", "Rule name")
+	ruleEditCmd.Flags().StringVar(&ruleFlagName, "name", "", "Rename the rule")
+	ruleEditCmd.Flags().BoolVar(&ruleFlagEnable, "enable", false, "Enable the rule")
+	ruleEditCmd.Flags().BoolVar(&ruleFlagDisable, "disable", false, "Disable the rule without deleting it")
+
+	RuleCmd.AddCommand(ruleListCmd, ruleShowCmd, ruleAddCmd, ruleEditCmd, ruleDeleteCmd)
+}
@@ -0,0 +1,577 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/LarsEckart/huey/hue"
+)
+
+// The rule DSL describes a rule's conditions and actions in one line:
+//
+//	when sensor 5 buttonevent == 1002 and sensor 5 lastupdated changed
+//	then group 3 scene abc
+//
+// Conditions test an attribute, "sensor|light|group <id> <attribute>" for
+// the attribute in state (or a path like config/on), "time" for the
+// bridge's local time, or a raw address like /config/localtime. They
+// compare it with == , > or <, or use changed, changed 30s ago,
+// stable for 30s, not stable for 30s, in or not in:
+//
+//	time in 08:00-20:00
+//
+// Actions set a light, group or sensor with on, off, scene <id> and
+// key=value pairs of the bridge's API, where value is JSON or a bare word:
+//
+//	group 0 off and light 4 on bri=254 alert=select and sensor 9 status=1
+//
+// Other requests are written as METHOD /address {json body}.
+
+// parseRule parses a rule in the DSL into its conditions and actions.
+func parseRule(text string) ([]hue.Condition, []hue.Action, error) {
+	tokens, err := ruleTokens(text)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(tokens) == 0 || !strings.EqualFold(tokens[0], "when") {
+		return nil, nil, fmt.Errorf(`rule must start with "when", e.g. "when sensor 5 buttonevent == 1002 then group 3 scene abc"`)
+	}
+	then := slices.IndexFunc(tokens, func(t string) bool { return strings.EqualFold(t, "then") })
+	if then < 0 {
+		return nil, nil, fmt.Errorf(`rule needs "then" followed by its actions`)
+	}
+
+	var conditions []hue.Condition
+	for i, clause := range splitClauses(tokens[1:then]) {
+		condition, err := parseCondition(clause)
+		if err != nil {
+			return nil, nil, fmt.Errorf("condition %d: %w", i+1, err)
+		}
+		conditions = append(conditions, condition)
+	}
+	var actions []hue.Action
+	for i, clause := range splitClauses(tokens[then+1:]) {
+		action, err := parseAction(clause)
+		if err != nil {
+			return nil, nil, fmt.Errorf("action %d: %w", i+1, err)
+		}
+		actions = append(actions, action)
+	}
+
+	switch {
+	case len(conditions) == 0:
+		return nil, nil, fmt.Errorf(`rule needs at least one condition after "when"`)
+	case len(actions) == 0:
+		return nil, nil, fmt.Errorf(`rule needs at least one action after "then"`)
+	case len(conditions) > hue.MaxRuleConditions:
+		return nil, nil, fmt.Errorf("rule has %d conditions, the bridge allows at most %d", len(conditions), hue.MaxRuleConditions)
+	case len(actions) > hue.MaxRuleActions:
+		return nil, nil, fmt.Errorf("rule has %d actions, the bridge allows at most %d", len(actions), hue.MaxRuleActions)
+	}
+	return conditions, actions, nil
+}
+
+// ruleTokens splits text at spaces, keeping "quoted strings" and JSON
+// objects and arrays together. Quotes are kept in the tokens.
+func ruleTokens(text string) ([]string, error) {
+	var tokens []string
+	var token strings.Builder
+	inQuotes, escaped, depth := false, false, 0
+
+	for _, r := range text {
+		switch {
+		case inQuotes:
+			switch {
+			case escaped:
+				escaped = false
+			case r == '\\':
+				escaped = true
+			case r == '"':
+				inQuotes = false
+			}
+		case r == '"':
+			inQuotes = true
+		case r == '{' || r == '[':
+			depth++
+		case r == '}' || r == ']':
+			depth--
+		case depth == 0 && (r == ' ' || r == '\t' || r == '\n'):
+			if token.Len() > 0 {
+				tokens = append(tokens, token.String())
+				token.Reset()
+			}
+			continue
+		}
+		token.WriteRune(r)
+	}
+	if inQuotes {
+		return nil, fmt.Errorf("unterminated quote in rule")
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced braces in rule")
+	}
+	if token.Len() > 0 {
+		tokens = append(tokens, token.String())
+	}
+	return tokens, nil
+}
+
+// splitClauses splits tokens at "and".
+func splitClauses(tokens []string) [][]string {
+	var clauses [][]string
+	start := 0
+	for i, token := range tokens {
+		if strings.EqualFold(token, "and") {
+			clauses = append(clauses, tokens[start:i])
+			start = i + 1
+		}
+	}
+	if start < len(tokens) || start > 0 {
+		clauses = append(clauses, tokens[start:])
+	}
+	return clauses
+}
+
+// ruleResources maps the resource words of the DSL to address segments.
+var ruleResources = map[string]string{"sensor": "sensors", "light": "lights", "group": "groups"}
+
+// clockRange matches the DSL's short form of a time range, e.g. 08:00-20:00.
+var clockRange = regexp.MustCompile(`^(\d{2}:\d{2})-(\d{2}:\d{2})$`)
+
+// timeRange matches the bridge's form of such a range, T08:00:00/T20:00:00.
+var timeRange = regexp.MustCompile(`^T(\d{2}:\d{2}):00/T(\d{2}:\d{2}):00$`)
+
+func parseCondition(tokens []string) (hue.Condition, error) {
+	var condition hue.Condition
+	if len(tokens) == 0 {
+		return condition, fmt.Errorf(`empty condition, e.g. "sensor 5 presence == true"`)
+	}
+
+	subject := strings.ToLower(tokens[0])
+	switch {
+	case ruleResources[subject] != "":
+		if len(tokens) < 3 {
+			return condition, fmt.Errorf("expected %s <id> <attribute>, e.g. %q", subject, "sensor 5 presence")
+		}
+		attribute := unquote(tokens[2])
+		if !strings.Contains(attribute, "/") {
+			attribute = "state/" + attribute
+		}
+		condition.Address = fmt.Sprintf("/%s/%s/%s", ruleResources[subject], unquote(tokens[1]), attribute)
+		tokens = tokens[3:]
+	case subject == "time":
+		condition.Address = "/config/localtime"
+		tokens = tokens[1:]
+	case strings.HasPrefix(subject, "/"):
+		condition.Address = tokens[0]
+		tokens = tokens[1:]
+	default:
+		return condition, fmt.Errorf("unknown condition %q, expected sensor, light, group, time or an /address", tokens[0])
+	}
+
+	test := strings.ToLower(strings.Join(tokens, " "))
+	var err error
+	switch {
+	case len(tokens) == 2 && tokens[0] == "==":
+		condition.Operator, condition.Value = hue.OpEqual, unquote(tokens[1])
+	case len(tokens) == 2 && tokens[0] == ">":
+		condition.Operator, condition.Value = hue.OpGreater, unquote(tokens[1])
+	case len(tokens) == 2 && tokens[0] == "<":
+		condition.Operator, condition.Value = hue.OpLess, unquote(tokens[1])
+	case test == "changed":
+		condition.Operator = hue.OpChanged
+	case len(tokens) == 3 && strings.EqualFold(tokens[0], "changed") && strings.EqualFold(tokens[2], "ago"):
+		condition.Operator = hue.OpChangedDelayed
+		condition.Value, err = ruleDuration(tokens[1])
+	case len(tokens) == 3 && strings.HasPrefix(test, "stable for "):
+		condition.Operator = hue.OpStable
+		condition.Value, err = ruleDuration(tokens[2])
+	case len(tokens) == 4 && strings.HasPrefix(test, "not stable for "):
+		condition.Operator = hue.OpNotStable
+		condition.Value, err = ruleDuration(tokens[3])
+	case len(tokens) == 2 && strings.EqualFold(tokens[0], "in"):
+		condition.Operator, condition.Value = hue.OpIn, ruleTimeRange(unquote(tokens[1]))
+	case len(tokens) == 3 && strings.HasPrefix(test, "not in "):
+		condition.Operator, condition.Value = hue.OpNotIn, ruleTimeRange(unquote(tokens[2]))
+	default:
+		err = fmt.Errorf("unknown test %q, expected == <value>, > <value>, < <value>, changed, changed <duration> ago, stable for <duration>, not stable for <duration>, in <range> or not in <range>", strings.Join(tokens, " "))
+	}
+	return condition, err
+}
+
+// ruleDuration converts a Go duration to the bridge's "PT00:00:30".
+func ruleDuration(value string) (string, error) {
+	d, err := parseScheduleDuration(value)
+	if err != nil {
+		return "", err
+	}
+	return hue.TimePattern{Kind: hue.TimeTimer, Timer: d}.String(), nil
+}
+
+// ruleTimeRange expands "08:00-20:00" to "T08:00:00/T20:00:00". Other
+// values are passed through.
+func ruleTimeRange(value string) string {
+	if m := clockRange.FindStringSubmatch(value); m != nil {
+		return fmt.Sprintf("T%s:00/T%s:00", m[1], m[2])
+	}
+	return value
+}
+
+func parseAction(tokens []string) (hue.Action, error) {
+	var action hue.Action
+	if len(tokens) == 0 {
+		return action, fmt.Errorf(`empty action, e.g. "group 3 scene abc"`)
+	}
+
+	subject := strings.ToLower(tokens[0])
+	switch {
+	case ruleResources[subject] != "":
+		if len(tokens) < 3 {
+			return action, fmt.Errorf("expected %s <id> followed by what to set, e.g. %q", subject, "group 3 on bri=254")
+		}
+		id, settings := unquote(tokens[1]), tokens[2:]
+		switch subject {
+		case "group":
+			action.Address = fmt.Sprintf("/groups/%s/action", id)
+		case "light":
+			action.Address = fmt.Sprintf("/lights/%s/state", id)
+		case "sensor":
+			target := "state"
+			if strings.EqualFold(settings[0], "config") {
+				target, settings = "config", settings[1:]
+			}
+			action.Address = fmt.Sprintf("/sensors/%s/%s", id, target)
+		}
+		action.Method = http.MethodPut
+		body, err := actionBody(settings)
+		if err != nil {
+			return action, err
+		}
+		action.Body = body
+
+	case slices.Contains([]string{http.MethodPut, http.MethodPost, http.MethodDelete}, strings.ToUpper(subject)):
+		if len(tokens) < 2 || len(tokens) > 3 || !strings.HasPrefix(tokens[1], "/") {
+			return action, fmt.Errorf("expected %s /address {json body}", strings.ToUpper(subject))
+		}
+		action.Method, action.Address = strings.ToUpper(subject), tokens[1]
+		action.Body = json.RawMessage("{}")
+		if len(tokens) == 3 {
+			if !json.Valid([]byte(tokens[2])) {
+				return action, fmt.Errorf("invalid JSON body %s", tokens[2])
+			}
+			action.Body = json.RawMessage(tokens[2])
+		}
+
+	default:
+		return action, fmt.Errorf("unknown action %q, expected group, light, sensor or a method like PUT", tokens[0])
+	}
+	return action, nil
+}
+
+// actionBody builds a JSON object from on, off, scene <id> and key=value
+// settings, keeping their order.
+func actionBody(settings []string) (json.RawMessage, error) {
+	var keys []string
+	values := map[string]json.RawMessage{}
+	set := func(key string, value json.RawMessage) error {
+		if _, ok := values[key]; ok {
+			return fmt.Errorf("%s is set twice", key)
+		}
+		keys = append(keys, key)
+		values[key] = value
+		return nil
+	}
+
+	for i := 0; i < len(settings); i++ {
+		setting := settings[i]
+		var err error
+		switch key, value, ok := strings.Cut(setting, "="); {
+		case strings.EqualFold(setting, "on"):
+			err = set("on", json.RawMessage("true"))
+		case strings.EqualFold(setting, "off"):
+			err = set("on", json.RawMessage("false"))
+		case strings.EqualFold(setting, "scene"):
+			if i+1 == len(settings) {
+				return nil, fmt.Errorf("scene needs a scene ID")
+			}
+			i++
+			err = set("scene", jsonString(unquote(settings[i])))
+		case ok && key != "":
+			if json.Valid([]byte(value)) {
+				err = set(key, json.RawMessage(value))
+			} else {
+				err = set(key, jsonString(value))
+			}
+		default:
+			return nil, fmt.Errorf("unknown setting %q, expected on, off, scene <id> or key=value", setting)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var body bytes.Buffer
+	body.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			body.WriteByte(',')
+		}
+		body.Write(jsonString(key))
+		body.WriteByte(':')
+		body.Write(values[key])
+	}
+	body.WriteByte('}')
+	return body.Bytes(), nil
+}
+
+func jsonString(s string) json.RawMessage {
+	data, _ := json.Marshal(s)
+	return data
+}
+
+// unquote removes the quotes around a "quoted string" token.
+func unquote(token string) string {
+	if s, err := strconv.Unquote(token); err == nil && strings.HasPrefix(token, `"`) {
+		return s
+	}
+	return token
+}
+
+// quoteWord quotes s if it wouldn't survive ruleTokens as one bare word.
+func quoteWord(s string) string {
+	if s == "" || strings.ContainsAny(s, " \t\n\"{}[]") || strings.EqualFold(s, "and") || strings.EqualFold(s, "then") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// formatRule writes a rule's conditions and actions in the DSL, one
+// clause per line when multiline is set.
+func formatRule(conditions []hue.Condition, actions []hue.Action, multiline bool) string {
+	var clauses []string
+	for i, condition := range conditions {
+		word := "when "
+		if i > 0 {
+			word = "and "
+		}
+		clauses = append(clauses, word+formatCondition(condition))
+	}
+	for i, action := range actions {
+		word := "then "
+		if i > 0 {
+			word = "and "
+		}
+		clauses = append(clauses, word+formatAction(action))
+	}
+	if multiline {
+		return strings.Join(clauses, "\n")
+	}
+	return strings.Join(clauses, " ")
+}
+
+func formatCondition(condition hue.Condition) string {
+	subject := condition.Address
+	parts := strings.Split(strings.TrimPrefix(condition.Address, "/"), "/")
+	if condition.Address == "/config/localtime" {
+		subject = "time"
+	} else if len(parts) >= 3 {
+		for word, resource := range ruleResources {
+			if parts[0] == resource {
+				attribute := strings.Join(parts[2:], "/")
+				if rest, ok := strings.CutPrefix(attribute, "state/"); ok && !strings.Contains(rest, "/") {
+					attribute = rest
+				}
+				subject = fmt.Sprintf("%s %s %s", word, quoteWord(parts[1]), quoteWord(attribute))
+			}
+		}
+	}
+
+	value := condition.Value
+	switch condition.Operator {
+	case hue.OpEqual:
+		return subject + " == " + quoteWord(value)
+	case hue.OpGreater:
+		return subject + " > " + quoteWord(value)
+	case hue.OpLess:
+		return subject + " < " + quoteWord(value)
+	case hue.OpChanged:
+		return subject + " changed"
+	case hue.OpChangedDelayed:
+		return subject + " changed " + formatRuleDuration(value) + " ago"
+	case hue.OpStable:
+		return subject + " stable for " + formatRuleDuration(value)
+	case hue.OpNotStable:
+		return subject + " not stable for " + formatRuleDuration(value)
+	case hue.OpIn:
+		return subject + " in " + formatTimeRange(value)
+	case hue.OpNotIn:
+		return subject + " not in " + formatTimeRange(value)
+	}
+	return strings.TrimSpace(subject + " " + condition.Operator + " " + value)
+}
+
+// formatRuleDuration converts the bridge's "PT00:00:30" to "30s".
+func formatRuleDuration(value string) string {
+	p, err := hue.ParseTimePattern(value, time.UTC) // a timer, without a zone
+	if err != nil || p.Kind != hue.TimeTimer || p.Repeat != 0 || p.Random != 0 {
+		return quoteWord(value)
+	}
+	return shortDuration(p.Timer)
+}
+
+func formatTimeRange(value string) string {
+	if m := timeRange.FindStringSubmatch(value); m != nil {
+		return m[1] + "-" + m[2]
+	}
+	return quoteWord(value)
+}
+
+func formatAction(action hue.Action) string {
+	raw := func() string {
+		var body bytes.Buffer
+		if err := json.Compact(&body, action.Body); err != nil || body.String() == "{}" {
+			return action.Method + " " + action.Address
+		}
+		return action.Method + " " + action.Address + " " + body.String()
+	}
+
+	var body map[string]json.RawMessage
+	if action.Method != http.MethodPut || json.Unmarshal(action.Body, &body) != nil || len(body) == 0 {
+		return raw()
+	}
+
+	var target string
+	switch parts := strings.Split(strings.TrimPrefix(action.Address, "/"), "/"); {
+	case len(parts) != 3:
+		return raw()
+	case parts[0] == "groups" && parts[2] == "action":
+		target = "group " + quoteWord(parts[1])
+	case parts[0] == "lights" && parts[2] == "state":
+		target = "light " + quoteWord(parts[1])
+	case parts[0] == "sensors" && parts[2] == "state":
+		target = "sensor " + quoteWord(parts[1])
+	case parts[0] == "sensors" && parts[2] == "config":
+		target = "sensor " + quoteWord(parts[1]) + " config"
+	default:
+		return raw()
+	}
+
+	// on and scene first, the rest by key.
+	keys := make([]string, 0, len(body))
+	for key := range body {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b string) int {
+		rank := func(key string) int { return slices.Index([]string{"scene", "on"}, key) }
+		if ra, rb := rank(a), rank(b); ra != rb {
+			return rb - ra
+		}
+		return strings.Compare(a, b)
+	})
+
+	settings := []string{target}
+	for _, key := range keys {
+		value := body[key]
+		var s string
+		var on bool
+		switch {
+		case key == "on" && json.Unmarshal(value, &on) == nil:
+			if on {
+				settings = append(settings, "on")
+			} else {
+				settings = append(settings, "off")
+			}
+			continue
+		case key == "scene" && json.Unmarshal(value, &s) == nil:
+			settings = append(settings, "scene "+quoteWord(s))
+			continue
+		case json.Unmarshal(value, &s) == nil && s != "" && !json.Valid([]byte(s)) && quoteWord(s) == s && !strings.Contains(s, "="):
+			// A bare word reads back as the same string.
+			settings = append(settings, key+"="+s)
+			continue
+		}
+		var compact bytes.Buffer
+		if json.Compact(&compact, value) != nil {
+			return raw()
+		}
+		settings = append(settings, key+"="+compact.String())
+	}
+	return strings.Join(settings, " ")
+}
+
+// validateRule checks that the lights, groups, sensors and scenes a rule
+// refers to exist, and that sensor state conditions test an attribute
+// the sensor reports.
+func validateRule(conditions []hue.Condition, actions []hue.Action, state *hue.BridgeState) error {
+	for i, condition := range conditions {
+		if err := validateRuleAddress(condition.Address, state, true); err != nil {
+			return fmt.Errorf("condition %d: %w", i+1, err)
+		}
+	}
+	for i, action := range actions {
+		if err := validateRuleAddress(action.Address, state, false); err != nil {
+			return fmt.Errorf("action %d: %w", i+1, err)
+		}
+		var body struct {
+			Scene string `json:"scene"`
+		}
+		if json.Unmarshal(action.Body, &body) == nil && body.Scene != "" &&
+			!slices.ContainsFunc(state.Scenes, func(s hue.Scene) bool { return s.ID == body.Scene }) {
+			return fmt.Errorf("action %d: %w", i+1, &NotFoundError{Kind: "scene", Query: body.Scene})
+		}
+	}
+	return nil
+}
+
+func validateRuleAddress(address string, state *hue.BridgeState, isCondition bool) error {
+	parts := strings.Split(strings.TrimPrefix(address, "/"), "/")
+	if len(parts) < 2 {
+		return nil
+	}
+	id := parts[1]
+	switch parts[0] {
+	case "lights":
+		if !slices.ContainsFunc(state.Lights, func(l hue.Light) bool { return l.ID == id }) {
+			return &NotFoundError{Kind: "light", Query: id}
+		}
+	case "groups":
+		// Group 0 is all lights and isn't listed.
+		if id != allLightsGroup && !slices.ContainsFunc(state.Groups, func(g hue.Group) bool { return g.ID == id }) {
+			return &NotFoundError{Kind: "group", Query: id}
+		}
+	case "sensors":
+		i := slices.IndexFunc(state.Sensors, func(s hue.Sensor) bool { return s.ID == id })
+		if i < 0 {
+			return &NotFoundError{Kind: "sensor", Query: id}
+		}
+		if sensor := state.Sensors[i]; isCondition && len(parts) == 4 && parts[2] == "state" {
+			if _, ok := sensor.RawState[parts[3]]; !ok {
+				attributes := make([]string, 0, len(sensor.RawState))
+				for attribute := range sensor.RawState {
+					attributes = append(attributes, attribute)
+				}
+				slices.Sort(attributes)
+				return fmt.Errorf("sensor %s (%s) has no state attribute %q, it has: %s", id, sensor.Name, parts[3], strings.Join(attributes, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// ruleAge describes when a rule last triggered.
+func ruleAge(rule hue.Rule, now time.Time) string {
+	switch rule.TimesTriggered {
+	case 0:
+		return "never triggered"
+	case 1:
+		return "triggered once, " + lastUpdated(rule.LastTriggered, now)
+	}
+	return fmt.Sprintf("triggered %d times, last %s", rule.TimesTriggered, lastUpdated(rule.LastTriggered, now))
+}
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/LarsEckart/huey/hue"
+)
+
+func TestParseRule(t *testing.T) {
+	conditions, actions, err := parseRule(`when sensor 5 buttonevent == 1002 and sensor 5 lastupdated changed and time in 08:00-20:00
+		then group 3 scene abc and light 4 on bri=254 alert=select transitiontime=20`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	wantConditions := []hue.Condition{
+		{Address: "/sensors/5/state/buttonevent", Operator: hue.OpEqual, Value: "1002"},
+		{Address: "/sensors/5/state/lastupdated", Operator: hue.OpChanged},
+		{Address: "/config/localtime", Operator: hue.OpIn, Value: "T08:00:00/T20:00:00"},
+	}
+	if len(conditions) != len(wantConditions) {
+		t.Fatalf("got %d conditions, want %d", len(conditions), len(wantConditions))
+	}
+	for i, want := range wantConditions {
+		if conditions[i] != want {
+			t.Errorf("condition %d = %+v, want %+v", i+1, conditions[i], want)
+		}
+	}
+
+	wantActions := []struct{ address, body string }{
+		{"/groups/3/action", `{"scene":"abc"}`},
+		{"/lights/4/state", `{"on":true,"bri":254,"alert":"select","transitiontime":20}`},
+	}
+	if len(actions) != len(wantActions) {
+		t.Fatalf("got %d actions, want %d", len(actions), len(wantActions))
+	}
+	for i, want := range wantActions {
+		if actions[i].Address != want.address || actions[i].Method != "PUT" || string(actions[i].Body) != want.body {
+			t.Errorf("action %d = %s %s %s, want PUT %s %s", i+1, actions[i].Method, actions[i].Address, actions[i].Body, want.address, want.body)
+		}
+	}
+}
+
+func TestFormatRule_RoundTrip(t *testing.T) {
+	tests := []string{
+		"when sensor 5 buttonevent == 1002 and sensor 5 lastupdated changed then group 3 scene abc",
+		"when sensor 7 presence == true and sensor 8 dark == true then group 1 on scene def bri_inc=30",
+		"when sensor 7 presence == false and sensor 7 presence stable for 5m then group 1 off transitiontime=50",
+		"when sensor 7 presence changed 30s ago and group 1 any_on == true then light 2 alert=lselect",
+		"when sensor 7 lightlevel < 12000 and sensor 7 config/on == true then sensor 9 status=1",
+		"when time not in 22:00-06:00 and sensor 9 status > 0 then sensor 7 config off",
+		"when sensor 9 flag == true then light 2 on name=\"Desk lamp\" xy=[0.45,0.41]",
+		"when time in W124/T07:00:00/T08:00:00 and /config/zigbeechannel == 15 then PUT /schedules/3 {\"status\":\"enabled\"}",
+		"when sensor 9 status not stable for 1m30s then DELETE /rules/4",
+	}
+
+	for _, rule := range tests {
+		t.Run(rule, func(t *testing.T) {
+			conditions, actions, err := parseRule(rule)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := formatRule(conditions, actions, false); got != rule {
+				t.Errorf("formatRule =\n %s\nwant\n %s", got, rule)
+			}
+		})
+	}
+}
+
+func TestFormatRule_Multiline(t *testing.T) {
+	conditions, actions, err := parseRule("when sensor 5 buttonevent == 4002 and sensor 5 lastupdated changed then group 0 off and light 1 on")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "when sensor 5 buttonevent == 4002\nand sensor 5 lastupdated changed\nthen group 0 off\nand light 1 on"
+	if got := formatRule(conditions, actions, true); got != want {
+		t.Errorf("formatRule =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestParseRule_Invalid(t *testing.T) {
+	tests := []struct {
+		rule string
+		want string
+	}{
+		{"sensor 5 presence == true then group 1 on", `must start with "when"`},
+		{"when sensor 5 presence == true", `needs "then"`},
+		{"when then group 1 on", "at least one condition"},
+		{"when sensor 5 presence == true then", "at least one action"},
+		{"when sensor 5 presence is true then group 1 on", "condition 1: unknown test"},
+		{"when sensor 5 then group 1 on", "condition 1: expected sensor <id> <attribute>"},
+		{"when lamp 5 on == true then group 1 on", `condition 1: unknown condition "lamp"`},
+		{"when sensor 5 presence stable for soon then group 1 on", "condition 1: invalid duration"},
+		{"when sensor 5 presence stable for 25h then group 1 on", "condition 1: duration \"25h\" must be less than 24h"},
+		{"when sensor 5 presence == true and then group 1 on", "condition 2: empty condition"},
+		{"when sensor 5 presence == true then group 1", "action 1: expected group <id>"},
+		{"when sensor 5 presence == true then group 1 on off", "action 1: on is set twice"},
+		{"when sensor 5 presence == true then group 1 scene", "action 1: scene needs a scene ID"},
+		{"when sensor 5 presence == true then group 1 dim", `action 1: unknown setting "dim"`},
+		{"when sensor 5 presence == true then PUT /groups/1/action {\"on\":", "unbalanced braces"},
+		{`when sensor 5 presence == "true then group 1 on`, "unterminated quote"},
+		{"when sensor 5 presence == true then blink 1", `action 1: unknown action "blink"`},
+		{"when " + strings.Repeat("sensor 5 presence == true and ", 8) + "sensor 5 dark == true then group 1 on", "at most 8"},
+	}
+
+	for _, tt := range tests {
+		_, _, err := parseRule(tt.rule)
+		if err == nil {
+			t.Errorf("parseRule(%q): expected an error", tt.rule)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("parseRule(%q) error = %q, want it to contain %q", tt.rule, err, tt.want)
+		}
+	}
+}
+
+func TestValidateRule(t *testing.T) {
+	state := &hue.BridgeState{
+		Lights:  []hue.Light{{ID: "4", Name: "Desk"}},
+		Groups:  []hue.Group{{ID: "3", Name: "Hall"}},
+		Scenes:  []hue.Scene{{ID: "abc", Name: "Bright", Group: "3"}},
+		Sensors: []hue.Sensor{{ID: "5", Name: "Hall switch", RawState: map[string]any{"buttonevent": 1002.0, "lastupdated": "none"}}},
+	}
+
+	tests := []struct {
+		rule string
+		want string // empty if valid
+	}{
+		{"when sensor 5 buttonevent == 1002 then group 3 scene abc and group 0 off and light 4 on", ""},
+		{"when sensor 5 config/on == true then PUT /schedules/1 {\"status\":\"enabled\"}", ""},
+		{"when sensor 6 buttonevent == 1002 then group 3 on", `condition 1: sensor "6" not found`},
+		{"when sensor 5 presence == true then group 3 on", `condition 1: sensor 5 (Hall switch) has no state attribute "presence", it has: buttonevent, lastupdated`},
+		{"when sensor 5 buttonevent == 1002 then group 9 on", `action 1: group "9" not found`},
+		{"when sensor 5 buttonevent == 1002 then light 4 on and light 5 on", `action 2: light "5" not found`},
+		{"when sensor 5 buttonevent == 1002 then group 3 scene xyz", `action 1: scene "xyz" not found`},
+	}
+
+	for _, tt := range tests {
+		conditions, actions, err := parseRule(tt.rule)
+		if err != nil {
+			t.Fatalf("parseRule(%q): unexpected error: %v", tt.rule, err)
+		}
+		err = validateRule(conditions, actions, state)
+		switch {
+		case tt.want == "" && err != nil:
+			t.Errorf("validateRule(%q): unexpected error: %v", tt.rule, err)
+		case tt.want != "" && (err == nil || err.Error() != tt.want):
+			t.Errorf("validateRule(%q) error = %v, want %q", tt.rule, err, tt.want)
+		}
+	}
+}
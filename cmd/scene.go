@@ -2,47 +2,222 @@ package cmd
 
 import (
 	"fmt"
+	"io"
+	"slices"
 
+	"github.com/LarsEckart/huey/hue"
+	"github.com/LarsEckart/huey/hue/color"
 	"github.com/spf13/cobra"
 )
 
-var sceneFlagDelete bool
+var (
+	sceneFlagDelete   bool
+	sceneFlagGroup    string
+	sceneFlagShow     bool
+	sceneFlagSetLight string
+	sceneFlagOn       bool
+	sceneFlagOff      bool
+
+	sceneStateFlags stateFlags
+)
 
 // SceneCmd activates a single scene.
 var SceneCmd = &cobra.Command{
-	Use:   "scene <id>",
-	Short: "Activate or delete a scene",
-	Args:  cobra.ExactArgs(1),
+	Use:   "scene <id|name>",
+	Short: "Activate, inspect, edit or delete a scene",
+	Long: "Activate or delete a scene, identified by ID, name, unique name prefix, or a close match of its name.\n" +
+		"Deleting needs the scene's exact ID or name.\n" +
+		"Scene names repeat across rooms, so use --group to pick the room.\n" +
+		"With --show, list the state the scene sets each light to; with --set-light and --on, --off or\n" +
+		"brightness, color and effect flags, change what it stores for one light.",
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		sceneID := args[0]
+		flags := cmd.Flags()
+		hasState := sceneStateFlags.changed(flags)
+		setLight := sceneFlagSetLight != ""
+
+		switch {
+		case sceneFlagShow && (sceneFlagDelete || setLight || flags.Changed("transition")):
+			return fmt.Errorf("--show cannot be combined with --delete, --set-light or --transition")
+		case sceneFlagDelete && (setLight || flags.Changed("transition")):
+			return fmt.Errorf("--delete cannot be combined with --set-light or --transition")
+		case !setLight && (sceneFlagOn || sceneFlagOff || hasState):
+			return fmt.Errorf("--on, --off, brightness, color and effect flags need --set-light to pick the light to change")
+		case setLight && !sceneFlagOn && !sceneFlagOff && !hasState && !flags.Changed("transition"):
+			return fmt.Errorf("--set-light needs --on, --off, or brightness, color, effect or transition flags")
+		case sceneFlagOn && sceneFlagOff:
+			return fmt.Errorf("use only one of --on or --off")
+		case sceneFlagOff && hasState:
+			return fmt.Errorf("--off cannot be combined with brightness, color or effect flags")
+		}
 
-		client, err := authenticatedClient()
+		state, err := sceneStateFlags.lightState(flags)
 		if err != nil {
 			return err
 		}
+		if state.BrightnessInc != nil {
+			return fmt.Errorf("--brightness steps can't be stored in a scene, use a value like 40%%")
+		}
 
-		scene, err := client.GetScene(sceneID)
+		client, err := authenticatedClient(cmd)
 		if err != nil {
-			return fmt.Errorf("get scene: %w", err)
+			return err
+		}
+
+		// Scenes and their groups in one request.
+		bridgeState, err := client.GetFullState(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("get bridge state: %w", err)
+		}
+		scenes, groups := bridgeState.Scenes, bridgeState.Groups
+
+		if sceneFlagGroup != "" {
+			group, err := ResolveGroup(groups, sceneFlagGroup)
+			if err != nil {
+				return err
+			}
+			var inGroup []hue.Scene
+			for _, s := range scenes {
+				if s.Group == group.ID {
+					inGroup = append(inGroup, s)
+				}
+			}
+			scenes = inGroup
+		}
+
+		scene, err := ResolveScene(scenes, args[0])
+		if err != nil {
+			return err
+		}
+
+		var groupName string
+		for _, g := range groups {
+			if g.ID == scene.Group {
+				groupName = g.Name
+				break
+			}
+		}
+		record := newSceneRecord(scene, groupName)
+
+		if sceneFlagShow {
+			return showSceneLights(cmd, client, scene, groupName, bridgeState.Lights)
+		}
+
+		if setLight {
+			if sceneFlagOn || sceneFlagOff || hasState {
+				on := !sceneFlagOff
+				state.On = &on
+			}
+			return setSceneLight(cmd, client, scene, bridgeState.Lights, state)
 		}
 
 		if sceneFlagDelete {
-			if err := client.DeleteScene(sceneID); err != nil {
+			if err := exactMatch("scene", args[0], scene.ID, scene.Name); err != nil {
+				return err
+			}
+			if err := client.DeleteScene(cmd.Context(), scene.ID); err != nil {
 				return fmt.Errorf("delete scene: %w", err)
 			}
-			fmt.Printf("Deleted scene %q\n", scene.Name)
-			return nil
+			result := mutationResult{Action: "delete", Resource: "scene", ID: scene.ID, Old: record}
+			return renderResult(cmd, result, fmt.Sprintf("Deleted scene %q", scene.Name))
 		}
 
-		if err := client.ActivateScene(sceneID); err != nil {
+		if err := client.ActivateScene(cmd.Context(), scene.ID, state.TransitionTime); err != nil {
 			return fmt.Errorf("activate scene: %w", err)
 		}
 
-		fmt.Printf("Activated scene %q\n", scene.Name)
-		return nil
+		result := mutationResult{Action: "activate", Resource: "scene", ID: scene.ID, New: record}
+		return renderResult(cmd, result, fmt.Sprintf("Activated scene %q", scene.Name))
 	},
 }
 
+// showSceneLights lists the state a scene sets each of its lights to.
+func showSceneLights(cmd *cobra.Command, client *hue.Client, scene hue.Scene, groupName string, lights []hue.Light) error {
+	full, err := client.GetScene(cmd.Context(), scene.ID)
+	if err != nil {
+		return fmt.Errorf("get scene: %w", err)
+	}
+
+	records := make([]sceneLightRecord, 0, len(full.Lights))
+	for _, id := range full.Lights {
+		state, ok := full.LightStates[id]
+		if !ok {
+			continue
+		}
+		records = append(records, newSceneLightRecord(id, lightName(lights, id), state))
+	}
+
+	return render(cmd, records, func(w io.Writer) {
+		title := fmt.Sprintf("Scene %q (%s)", scene.Name, scene.ID)
+		if groupName != "" {
+			title += " in " + groupName
+		}
+		_, _ = fmt.Fprintln(w, title+":")
+		for _, id := range full.Lights {
+			description := "not stored"
+			if state, ok := full.LightStates[id]; ok {
+				description = describeState(state)
+			}
+			_, _ = fmt.Fprintf(w, "  %-3s %-24s %s\n", id, lightName(lights, id), description)
+		}
+	})
+}
+
+// setSceneLight changes the state scene stores for the light named by
+// --set-light.
+func setSceneLight(cmd *cobra.Command, client *hue.Client, scene hue.Scene, lights []hue.Light, state hue.LightState) error {
+	inScene := slices.DeleteFunc(slices.Clone(lights), func(l hue.Light) bool {
+		return !slices.Contains(scene.Lights, l.ID)
+	})
+	light, err := ResolveLight(inScene, sceneFlagSetLight)
+	if err != nil {
+		return fmt.Errorf("scene %q: %w", scene.Name, err)
+	}
+
+	if cmd.Flags().Changed("color") {
+		// Convert against the bulb's own gamut so the color is reproducible.
+		xy, err := sceneStateFlags.colorXY(color.GamutFor(light.GamutType))
+		if err != nil {
+			return err
+		}
+		state.XY = &xy
+	}
+
+	var before *hue.Scene
+	if structuredOutput() {
+		if before, err = client.GetScene(cmd.Context(), scene.ID); err != nil {
+			return fmt.Errorf("get scene: %w", err)
+		}
+	}
+
+	if err := client.UpdateSceneLightState(cmd.Context(), scene.ID, light.ID, state); err != nil {
+		return fmt.Errorf("update scene light state: %w", err)
+	}
+
+	result := mutationResult{Action: "set", Resource: "scene", ID: scene.ID}
+	if structuredOutput() {
+		after, err := client.GetScene(cmd.Context(), scene.ID)
+		if err != nil {
+			return fmt.Errorf("get scene: %w", err)
+		}
+		result.Old = newSceneLightRecord(light.ID, light.Name, before.LightStates[light.ID])
+		result.New = newSceneLightRecord(light.ID, light.Name, after.LightStates[light.ID])
+	}
+	return renderResult(cmd, result, fmt.Sprintf("Scene %q now sets light %q to %s", scene.Name, light.Name, describeState(state)))
+}
+
+// lightName returns the name of the light with the given ID, or the ID
+// if the light is unknown.
+func lightName(lights []hue.Light, id string) string {
+	return nameOf(lights, id, func(l hue.Light) (string, string) { return l.ID, l.Name })
+}
+
 func init() {
-	SceneCmd.Flags().BoolVar(&sceneFlagDelete, "delete", false, "Delete the scene")
+	SceneCmd.Flags().BoolVar(&sceneFlagDelete, "delete", false, "Delete the scene, named by its exact ID or name")
+	SceneCmd.Flags().StringVar(&sceneFlagGroup, "group", "", "Only match scenes in this group (ID or name)")
+	SceneCmd.Flags().BoolVar(&sceneFlagShow, "show", false, "Show the state the scene sets each light to")
+	SceneCmd.Flags().StringVar(&sceneFlagSetLight, "set-light", "", "Change the state the scene stores for this light (ID or name)")
+	SceneCmd.Flags().BoolVar(&sceneFlagOn, "on", false, "With --set-light, store the light as on")
+	SceneCmd.Flags().BoolVar(&sceneFlagOff, "off", false, "With --set-light, store the light as off")
+	sceneStateFlags.register(SceneCmd.Flags())
 }
@@ -24,22 +24,34 @@ var SceneCreateCmd = &cobra.Command{
 			return fmt.Errorf("--group is required")
 		}
 
-		client, err := authenticatedClient()
+		client, err := authenticatedClient(cmd)
 		if err != nil {
 			return err
 		}
 
-		id, err := client.CreateScene(sceneCreateName, sceneCreateGroup)
+		group, err := resolveGroup(cmd.Context(), client, sceneCreateGroup)
+		if err != nil {
+			return err
+		}
+
+		id, err := client.CreateScene(cmd.Context(), sceneCreateName, group.ID)
 		if err != nil {
 			return fmt.Errorf("create scene: %w", err)
 		}
 
-		fmt.Printf("Created scene %q (ID: %s)\n", sceneCreateName, id)
-		return nil
+		result := mutationResult{Action: "create", Resource: "scene", ID: id}
+		if structuredOutput() {
+			scene, err := client.GetScene(cmd.Context(), id)
+			if err != nil {
+				return fmt.Errorf("get scene: %w", err)
+			}
+			result.New = newSceneRecord(*scene, group.Name)
+		}
+		return renderResult(cmd, result, fmt.Sprintf("Created scene %q (ID: %s)", sceneCreateName, id))
 	},
 }
 
 func init() {
 	SceneCreateCmd.Flags().StringVar(&sceneCreateName, "name", "", "Scene name (required)")
-	SceneCreateCmd.Flags().StringVar(&sceneCreateGroup, "group", "", "Group ID to capture (required)")
+	SceneCreateCmd.Flags().StringVar(&sceneCreateGroup, "group", "", "Group ID or name to capture (required)")
 }
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 
 	"github.com/LarsEckart/huey/hue"
 	"github.com/spf13/cobra"
@@ -12,34 +13,36 @@ var ScenesCmd = &cobra.Command{
 	Use:   "scenes",
 	Short: "List all scenes",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		client, err := authenticatedClient()
+		client, err := authenticatedClient(cmd)
 		if err != nil {
 			return err
 		}
 
-		scenes, err := client.GetScenes()
+		// Scenes and their group names in one request.
+		state, err := client.GetFullState(cmd.Context())
 		if err != nil {
-			return fmt.Errorf("get scenes: %w", err)
-		}
-
-		groups, err := client.GetGroups()
-		if err != nil {
-			return fmt.Errorf("get groups: %w", err)
+			return fmt.Errorf("get bridge state: %w", err)
 		}
+		scenes := state.Scenes
 
 		groupByID := make(map[string]hue.Group)
-		for _, g := range groups {
+		for _, g := range state.Groups {
 			groupByID[g.ID] = g
 		}
 
+		records := make([]sceneRecord, 0, len(scenes))
 		for _, scene := range scenes {
-			groupName := "(no group)"
-			if g, ok := groupByID[scene.Group]; ok {
-				groupName = g.Name
-			}
-			fmt.Printf("%-20s %-24s [%s]\n", scene.ID, scene.Name, groupName)
+			records = append(records, newSceneRecord(scene, groupByID[scene.Group].Name))
 		}
 
-		return nil
+		return render(cmd, records, func(w io.Writer) {
+			for _, scene := range scenes {
+				groupName := "(no group)"
+				if g, ok := groupByID[scene.Group]; ok {
+					groupName = g.Name
+				}
+				_, _ = fmt.Fprintf(w, "%-20s %-24s [%s]\n", scene.ID, scene.Name, groupName)
+			}
+		})
 	},
 }
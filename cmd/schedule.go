@@ -0,0 +1,415 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/LarsEckart/huey/hue"
+	"github.com/LarsEckart/huey/hue/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var (
+	scheduleFlagAt          string
+	scheduleFlagName        string
+	scheduleFlagDescription string
+	scheduleFlagEnable      bool
+	scheduleFlagDisable     bool
+
+	scheduleAddTarget  scheduleTarget
+	scheduleEditTarget scheduleTarget
+)
+
+// ScheduleCmd groups the commands that manage schedules on the bridge.
+var ScheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "Manage schedules that run on the bridge",
+	Long: "List, add, edit and delete schedules. The bridge runs them on its own, so they fire even when huey isn't running.\n" +
+		"Times are in the bridge's time zone.",
+}
+
+var scheduleListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List all schedules",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := authenticatedClient(cmd)
+		if err != nil {
+			return err
+		}
+
+		// Schedules and the lights, groups and scenes they refer to.
+		state, err := client.GetFullState(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("get bridge state: %w", err)
+		}
+
+		loc := state.Config.Location()
+		records := make([]scheduleRecord, 0, len(state.Schedules))
+		for _, schedule := range state.Schedules {
+			records = append(records, newScheduleRecord(schedule, loc))
+		}
+
+		return render(cmd, records, func(w io.Writer) {
+			for i, schedule := range state.Schedules {
+				when := records[i].When
+				if when == "" {
+					when = schedule.LocalTime
+				}
+				if schedule.Status == hue.ScheduleDisabled {
+					when += " (disabled)"
+				}
+				_, _ = fmt.Fprintf(w, "%-3s %-24s %-28s %s\n",
+					schedule.ID, schedule.Name, when, describeScheduleCommand(schedule.Command, state))
+			}
+		})
+	},
+}
+
+var scheduleAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add a schedule",
+	Long: "Add a schedule that activates a scene, or sets a light or group, at a given time:\n\n" +
+		"  huey schedule add --at \"weekdays 07:00\" --scene Wake --transition 10m\n" +
+		"  huey schedule add --at \"in 30m\" --group Kitchen --off\n" +
+		"  huey schedule add --at \"daily 22:30 random 15m\" --light Porch --on\n\n" +
+		"--at takes a time of day (its next occurrence), \"today\", \"tomorrow\" or a date like 2026-10-18 before it;\n" +
+		"daily, weekdays, weekends or days like \"mon,wed-fri\" before it to repeat every week;\n" +
+		"\"in 10m\" for a one-off timer, or \"every 2h\" for a repeating one.\n" +
+		"Any of them may end in \"random 15m\" to run up to 15 minutes later, e.g. to look like someone is home.\n" +
+		"One-off schedules delete themselves once they've run.",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if scheduleFlagAt == "" {
+			return fmt.Errorf("--at is required, e.g. --at \"weekdays 07:00\"")
+		}
+
+		client, err := authenticatedClient(cmd)
+		if err != nil {
+			return err
+		}
+
+		state, err := client.GetFullState(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("get bridge state: %w", err)
+		}
+		pattern, err := parseScheduleTime(scheduleFlagAt, time.Now().In(state.Config.Location()))
+		if err != nil {
+			return err
+		}
+
+		command, target, err := scheduleAddTarget.command(client, state, cmd.Flags())
+		if err != nil {
+			return err
+		}
+
+		schedule := hue.Schedule{
+			Name:        scheduleFlagName,
+			Description: scheduleFlagDescription,
+			Command:     command,
+			LocalTime:   pattern.String(),
+			Status:      hue.ScheduleEnabled,
+			AutoDelete:  runsOnce(pattern),
+		}
+		if schedule.Name == "" {
+			schedule.Name = target
+		}
+
+		id, err := client.CreateSchedule(cmd.Context(), schedule)
+		if err != nil {
+			return fmt.Errorf("create schedule: %w", err)
+		}
+		schedule.ID = id
+
+		result := mutationResult{Action: "create", Resource: "schedule", ID: id, New: newScheduleRecord(schedule, state.Config.Location())}
+		return renderResult(cmd, result, fmt.Sprintf("Created schedule %q (%s), %s: %s",
+			schedule.Name, id, describeTimePattern(pattern), describeScheduleCommand(command, state)))
+	},
+}
+
+var scheduleEditCmd = &cobra.Command{
+	Use:   "edit <id|name>",
+	Short: "Change a schedule's time, name, action or status",
+	Long: "Change a schedule, identified by ID, name, unique name prefix, or a close match of its name.\n" +
+		"--at takes the same times as huey schedule add; --scene, --light or --group replace what it does.",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if scheduleFlagEnable && scheduleFlagDisable {
+			return fmt.Errorf("use only one of --enable or --disable")
+		}
+		flags := cmd.Flags()
+
+		var update hue.ScheduleUpdate
+		var changes []string
+		if flags.Changed("name") {
+			if strings.TrimSpace(scheduleFlagName) == "" {
+				return fmt.Errorf("--name cannot be empty")
+			}
+			update.Name = &scheduleFlagName
+			changes = append(changes, fmt.Sprintf("name %q", scheduleFlagName))
+		}
+		if flags.Changed("description") {
+			update.Description = &scheduleFlagDescription
+			changes = append(changes, fmt.Sprintf("description %q", scheduleFlagDescription))
+		}
+		if scheduleFlagEnable || scheduleFlagDisable {
+			status := hue.ScheduleEnabled
+			if scheduleFlagDisable {
+				status = hue.ScheduleDisabled
+			}
+			update.Status = &status
+			changes = append(changes, status)
+		}
+
+		client, err := authenticatedClient(cmd)
+		if err != nil {
+			return err
+		}
+
+		state, err := client.GetFullState(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("get bridge state: %w", err)
+		}
+		schedule, err := ResolveSchedule(state.Schedules, args[0])
+		if err != nil {
+			return err
+		}
+
+		if flags.Changed("at") {
+			pattern, err := parseScheduleTime(scheduleFlagAt, time.Now().In(state.Config.Location()))
+			if err != nil {
+				return err
+			}
+			localTime, autoDelete := pattern.String(), runsOnce(pattern)
+			update.LocalTime, update.AutoDelete = &localTime, &autoDelete
+			changes = append([]string{describeTimePattern(pattern)}, changes...)
+		}
+		if scheduleEditTarget.given(flags) {
+			command, _, err := scheduleEditTarget.command(client, state, flags)
+			if err != nil {
+				return err
+			}
+			update.Command = &command
+			changes = append(changes, describeScheduleCommand(command, state))
+		}
+
+		if len(changes) == 0 {
+			return fmt.Errorf("nothing to change, use --at, --name, --description, --enable, --disable, --scene, --light or --group")
+		}
+
+		if err := client.UpdateSchedule(cmd.Context(), schedule.ID, update); err != nil {
+			return fmt.Errorf("update schedule: %w", err)
+		}
+
+		result := mutationResult{Action: "set", Resource: "schedule", ID: schedule.ID}
+		if structuredOutput() {
+			updated, err := client.GetSchedule(cmd.Context(), schedule.ID)
+			if err != nil {
+				return fmt.Errorf("get schedule: %w", err)
+			}
+			result.Old = newScheduleRecord(schedule, state.Config.Location())
+			result.New = newScheduleRecord(*updated, state.Config.Location())
+		}
+		return renderResult(cmd, result, fmt.Sprintf("Schedule %q set to %s", schedule.Name, strings.Join(changes, ", ")))
+	},
+}
+
+var scheduleDeleteCmd = &cobra.Command{
+	Use:   "delete <id|name>",
+	Short: "Delete a schedule",
+	Long:  "Delete a schedule, identified by its exact ID or name.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := authenticatedClient(cmd)
+		if err != nil {
+			return err
+		}
+
+		// Schedules and the bridge's time zone in one request.
+		state, err := client.GetFullState(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("get bridge state: %w", err)
+		}
+		schedule, err := ResolveSchedule(state.Schedules, args[0])
+		if err != nil {
+			return err
+		}
+		if err := exactMatch("schedule", args[0], schedule.ID, schedule.Name); err != nil {
+			return err
+		}
+
+		if err := client.DeleteSchedule(cmd.Context(), schedule.ID); err != nil {
+			return fmt.Errorf("delete schedule: %w", err)
+		}
+
+		result := mutationResult{Action: "delete", Resource: "schedule", ID: schedule.ID, Old: newScheduleRecord(schedule, state.Config.Location())}
+		return renderResult(cmd, result, fmt.Sprintf("Deleted schedule %q", schedule.Name))
+	},
+}
+
+// scheduleTarget holds the flags that say what a schedule does: activate
+// a scene, or set a light or group.
+type scheduleTarget struct {
+	scene string
+	group string
+	light string
+	on    bool
+	off   bool
+	state stateFlags
+}
+
+func (t *scheduleTarget) register(flags *pflag.FlagSet) {
+	flags.StringVar(&t.scene, "scene", "", "Activate this scene (with --group to pick the room)")
+	flags.StringVar(&t.group, "group", "", "Set this group, or with --scene, the scene's room (ID or name)")
+	flags.StringVar(&t.light, "light", "", "Set this light (ID or name)")
+	flags.BoolVar(&t.on, "on", false, "Turn the light or group on")
+	flags.BoolVar(&t.off, "off", false, "Turn the light or group off")
+	t.state.register(flags)
+}
+
+// given reports whether any flag that changes what the schedule does
+// was given.
+func (t *scheduleTarget) given(flags *pflag.FlagSet) bool {
+	return flags.Changed("scene") || flags.Changed("group") || flags.Changed("light") ||
+		t.on || t.off || t.state.changed(flags) || flags.Changed("transition")
+}
+
+// command builds the schedule command from the flags, and returns it with
+// the name of the scene, light or group it acts on.
+func (t *scheduleTarget) command(client *hue.Client, state *hue.BridgeState, flags *pflag.FlagSet) (hue.ScheduleCommand, string, error) {
+	var none hue.ScheduleCommand
+
+	lightState, err := t.state.lightState(flags)
+	if err != nil {
+		return none, "", err
+	}
+	hasState := t.state.changed(flags)
+
+	if t.scene != "" {
+		if t.light != "" || t.on || t.off || hasState {
+			return none, "", fmt.Errorf("--scene cannot be combined with --light, --on, --off, or brightness, color or effect flags")
+		}
+		scenes := state.Scenes
+		if t.group != "" {
+			group, err := ResolveGroup(state.Groups, t.group)
+			if err != nil {
+				return none, "", err
+			}
+			scenes = slices.DeleteFunc(slices.Clone(scenes), func(s hue.Scene) bool { return s.Group != group.ID })
+		}
+		scene, err := ResolveScene(scenes, t.scene)
+		if err != nil {
+			return none, "", err
+		}
+		command, err := client.GroupActionCommand(scene.Group, hue.GroupAction{Scene: scene.ID, TransitionTime: lightState.TransitionTime})
+		return command, scene.Name, err
+	}
+
+	if t.light == "" && t.group == "" {
+		return none, "", fmt.Errorf("choose what the schedule does with --scene, --light or --group")
+	}
+	if t.light != "" && t.group != "" {
+		return none, "", fmt.Errorf("use only one of --light or --group")
+	}
+	if t.on && t.off {
+		return none, "", fmt.Errorf("use only one of --on or --off")
+	}
+	if t.off && hasState {
+		return none, "", fmt.Errorf("--off cannot be combined with brightness, color or effect flags")
+	}
+	if !t.on && !t.off && !hasState {
+		return none, "", fmt.Errorf("use --on, --off, or brightness, color or effect flags to say what to set")
+	}
+
+	if t.off || t.on || !brightnessStepOnly(lightState) {
+		on := !t.off
+		lightState.On = &on
+	}
+
+	if t.light != "" {
+		light, err := ResolveLight(state.Lights, t.light)
+		if err != nil {
+			return none, "", err
+		}
+		if flags.Changed("color") {
+			// Convert against the bulb's own gamut so the color is reproducible.
+			xy, err := t.state.colorXY(color.GamutFor(light.GamutType))
+			if err != nil {
+				return none, "", err
+			}
+			lightState.XY = &xy
+		}
+		command, err := client.LightStateCommand(light.ID, lightState)
+		return command, light.Name, err
+	}
+	group, err := ResolveGroup(state.Groups, t.group)
+	if err != nil {
+		return none, "", err
+	}
+	command, err := client.GroupActionCommand(group.ID, groupActionFromState(lightState))
+	return command, group.Name, err
+}
+
+// describeScheduleCommand summarizes what a schedule does, e.g.
+// "scene Wake" or "group Kitchen: off". Commands huey doesn't know are
+// shown as the raw request.
+func describeScheduleCommand(command hue.ScheduleCommand, state *hue.BridgeState) string {
+	// /api/<username>/lights/<id>/state or /api/<username>/groups/<id>/action
+	parts := strings.Split(strings.Trim(command.Address, "/"), "/")
+	if len(parts) == 5 && parts[0] == "api" {
+		var lightState hue.LightState
+		var action struct {
+			Scene string `json:"scene"`
+		}
+		if json.Unmarshal(command.Body, &lightState) == nil && json.Unmarshal(command.Body, &action) == nil {
+			id := parts[3]
+			switch {
+			case parts[2] == "lights" && parts[4] == "state":
+				return "light " + lightName(state.Lights, id) + ": " + describeState(lightState)
+			case parts[2] == "groups" && parts[4] == "action" && action.Scene != "":
+				description := "scene " + nameOf(state.Scenes, action.Scene, func(s hue.Scene) (string, string) { return s.ID, s.Name })
+				if rest := describeState(lightState); rest != "" {
+					description += ", " + rest
+				}
+				return description
+			case parts[2] == "groups" && parts[4] == "action":
+				return "group " + nameOf(state.Groups, id, func(g hue.Group) (string, string) { return g.ID, g.Name }) +
+					": " + describeState(lightState)
+			}
+		}
+	}
+	return command.Method + " " + command.Address
+}
+
+// nameOf returns the name of the item with the given ID, or the ID if
+// there is none, e.g. because the item was deleted.
+func nameOf[T any](items []T, id string, idName func(T) (string, string)) string {
+	for _, item := range items {
+		if itemID, name := idName(item); itemID == id {
+			return name
+		}
+	}
+	return id
+}
+
+func init() {
+	scheduleAddCmd.Flags().StringVar(&scheduleFlagAt, "at", "", "When to run, e.g. \"07:00\", \"weekdays 07:00\", \"in 10m\" or \"every 2h\"")
+	scheduleAddCmd.Flags().StringVar(&scheduleFlagName, "name", "", "Schedule name (default: the scene, light or group name)")
+	scheduleAddCmd.Flags().StringVar(&scheduleFlagDescription, "description", "", "Schedule description")
+	scheduleAddTarget.register(scheduleAddCmd.Flags())
+
+	scheduleEditCmd.Flags().StringVar(&scheduleFlagAt, "at", "", "New time, e.g. \"weekends 09:00\"")
+	scheduleEditCmd.Flags().StringVar(&scheduleFlagName, "name", "", "Rename the schedule")
+	scheduleEditCmd.Flags().StringVar(&scheduleFlagDescription, "description", "", "New description")
+	scheduleEditCmd.Flags().BoolVar(&scheduleFlagEnable, "enable", false, "Enable the schedule")
+	scheduleEditCmd.Flags().BoolVar(&scheduleFlagDisable, "disable", false, "Disable the schedule without deleting it")
+	scheduleEditTarget.register(scheduleEditCmd.Flags())
+
+	ScheduleCmd.AddCommand(scheduleListCmd, scheduleAddCmd, scheduleEditCmd, scheduleDeleteCmd)
+}
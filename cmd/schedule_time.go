@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/LarsEckart/huey/hue"
+)
+
+// dayNames maps day names and abbreviations to weekdays.
+var dayNames = map[string]time.Weekday{
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+	"sun": time.Sunday, "sunday": time.Sunday,
+}
+
+// weekOrder lists the weekdays starting with Monday, for day ranges.
+var weekOrder = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday}
+
+// parseScheduleTime parses the --at syntax of huey schedule:
+//
+//	07:00, tomorrow 07:00, 2026-10-18 07:00   once
+//	daily 07:00, weekdays 07:00, weekends 09:30,
+//	mon,wed,fri 18:00, mon-thu 18:00          every week
+//	in 10m                                    once, after a countdown
+//	every 2h                                  repeatedly, after a countdown
+//
+// Any of them may end in "random 15m" to delay by a random time up to
+// that long. A time of day alone means its next occurrence after now.
+func parseScheduleTime(value string, now time.Time) (hue.TimePattern, error) {
+	var p hue.TimePattern
+
+	words := strings.Fields(strings.ToLower(value))
+	if n := len(words); n >= 2 && words[n-2] == "random" {
+		d, err := parseScheduleDuration(words[n-1])
+		if err != nil {
+			return p, fmt.Errorf("--at %q: random: %w", value, err)
+		}
+		p.Random = d
+		words = words[:n-2]
+	}
+
+	var err error
+	switch {
+	case len(words) == 2 && words[0] == "in":
+		p.Kind = hue.TimeTimer
+		p.Timer, err = parseScheduleDuration(words[1])
+
+	case len(words) == 2 && words[0] == "every" && words[1] != "day":
+		p.Kind, p.Repeat = hue.TimeTimer, hue.RepeatForever
+		p.Timer, err = parseScheduleDuration(words[1])
+
+	case len(words) == 1:
+		p.Kind = hue.TimeAbsolute
+		var clock time.Duration
+		if clock, err = parseTimeOfDay(words[0]); err == nil {
+			p.At = atTimeOfDay(now, clock)
+			if !p.At.After(now) {
+				p.At = atTimeOfDay(now.AddDate(0, 0, 1), clock)
+			}
+		}
+
+	case len(words) == 2 || (len(words) == 3 && words[0] == "every" && words[1] == "day"):
+		day, clockText := strings.Join(words[:len(words)-1], " "), words[len(words)-1]
+		var clock time.Duration
+		if clock, err = parseTimeOfDay(clockText); err != nil {
+			break
+		}
+		if date, ok := parseDate(day, now); ok {
+			p.Kind, p.At = hue.TimeAbsolute, atTimeOfDay(date, clock)
+			if !p.At.After(now) {
+				err = fmt.Errorf("%s is in the past", p.At.Format("2006-01-02 15:04"))
+			}
+			break
+		}
+		p.Kind, p.TimeOfDay = hue.TimeRecurring, clock
+		p.Weekdays, err = parseWeekdays(day)
+
+	default:
+		err = fmt.Errorf(`expected a time like "07:00", "weekdays 07:00", "in 10m" or "every 2h"`)
+	}
+	if err != nil {
+		return p, fmt.Errorf("--at %q: %w", value, err)
+	}
+	return p, nil
+}
+
+// parseScheduleDuration parses a Go duration of at least a second, as
+// timers and random delays count in whole seconds, and under a day, the
+// longest the bridge's hh:mm:ss durations can hold.
+func parseScheduleDuration(value string) (time.Duration, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q, expected e.g. 10m or 1h30m", value)
+	}
+	if d < time.Second {
+		return 0, fmt.Errorf("duration %q must be at least 1s", value)
+	}
+	if d >= 24*time.Hour {
+		return 0, fmt.Errorf("duration %q must be less than 24h", value)
+	}
+	return d.Truncate(time.Second), nil
+}
+
+// parseTimeOfDay parses "hh:mm" or "hh:mm:ss" as time since midnight.
+func parseTimeOfDay(value string) (time.Duration, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid time of day %q, expected hh:mm", value)
+	}
+	limits := []int{23, 59, 59}
+	var d time.Duration
+	for i, unit := range []time.Duration{time.Hour, time.Minute, time.Second}[:len(parts)] {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil || n < 0 || n > limits[i] {
+			return 0, fmt.Errorf("invalid time of day %q, expected hh:mm", value)
+		}
+		d += time.Duration(n) * unit
+	}
+	return d, nil
+}
+
+// parseDate parses "today", "tomorrow" or "2026-10-18".
+func parseDate(value string, now time.Time) (time.Time, bool) {
+	switch value {
+	case "today":
+		return now, true
+	case "tomorrow":
+		return now.AddDate(0, 0, 1), true
+	}
+	date, err := time.ParseInLocation("2006-01-02", value, now.Location())
+	return date, err == nil
+}
+
+// atTimeOfDay returns the given time of day on date's day.
+func atTimeOfDay(date time.Time, clock time.Duration) time.Time {
+	year, month, day := date.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, date.Location()).Add(clock)
+}
+
+// parseWeekdays parses "daily", "every day", "weekdays", "weekends", or a
+// comma-separated list of days and day ranges like "mon,wed-fri".
+func parseWeekdays(value string) (hue.Weekdays, error) {
+	switch value {
+	case "daily", "every day":
+		return hue.EveryDay, nil
+	case "weekdays":
+		return hue.WorkDays, nil
+	case "weekends":
+		return hue.Weekend, nil
+	}
+
+	var days []time.Weekday
+	for part := range strings.SplitSeq(value, ",") {
+		first, last, isRange := strings.Cut(part, "-")
+		from, ok := dayNames[first]
+		if !ok {
+			return 0, fmt.Errorf("unknown day %q, expected e.g. daily, weekdays, weekends or mon,wed-fri", first)
+		}
+		if !isRange {
+			days = append(days, from)
+			continue
+		}
+		to, ok := dayNames[last]
+		if !ok {
+			return 0, fmt.Errorf("unknown day %q, expected e.g. daily, weekdays, weekends or mon,wed-fri", last)
+		}
+		i, j := weekdayIndex(from), weekdayIndex(to)
+		if j < i {
+			return 0, fmt.Errorf("day range %q must run from Monday towards Sunday", part)
+		}
+		days = append(days, weekOrder[i:j+1]...)
+	}
+	return hue.WeekdaysOf(days...), nil
+}
+
+func weekdayIndex(day time.Weekday) int {
+	return (int(day) + 6) % 7
+}
+
+// runsOnce reports whether a schedule at p fires only once, so it should
+// delete itself afterwards.
+func runsOnce(p hue.TimePattern) bool {
+	return p.Kind == hue.TimeAbsolute || (p.Kind == hue.TimeTimer && p.Repeat == 0)
+}
+
+// describeTimePattern formats a schedule time in the --at syntax.
+func describeTimePattern(p hue.TimePattern) string {
+	var s string
+	switch p.Kind {
+	case hue.TimeAbsolute:
+		s = p.At.Format("2006-01-02 ") + formatTimeOfDay(p.At.Sub(atTimeOfDay(p.At, 0)))
+	case hue.TimeRecurring:
+		s = describeWeekdays(p.Weekdays) + " " + formatTimeOfDay(p.TimeOfDay)
+	case hue.TimeTimer:
+		switch {
+		case p.Repeat == hue.RepeatForever:
+			s = "every " + shortDuration(p.Timer)
+		case p.Repeat > 1:
+			s = fmt.Sprintf("%d times every %s", p.Repeat, shortDuration(p.Timer))
+		default:
+			s = "in " + shortDuration(p.Timer)
+		}
+	}
+	if p.Random > 0 {
+		s += " random " + shortDuration(p.Random)
+	}
+	return s
+}
+
+func describeWeekdays(w hue.Weekdays) string {
+	switch w {
+	case hue.EveryDay:
+		return "daily"
+	case hue.WorkDays:
+		return "weekdays"
+	case hue.Weekend:
+		return "weekends"
+	}
+	var names []string
+	for _, day := range w.Days() {
+		names = append(names, strings.ToLower(day.String()[:3]))
+	}
+	return strings.Join(names, ",")
+}
+
+// formatTimeOfDay formats time since midnight as "hh:mm", with seconds
+// only if there are any.
+func formatTimeOfDay(d time.Duration) string {
+	s := fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+	if seconds := int(d.Seconds()) % 60; seconds != 0 {
+		s += fmt.Sprintf(":%02d", seconds)
+	}
+	return s
+}
+
+// shortDuration formats d without trailing zero units: "10m", not "10m0s".
+func shortDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
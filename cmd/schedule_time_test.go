@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/LarsEckart/huey/hue"
+)
+
+func TestParseScheduleTime(t *testing.T) {
+	// A Saturday afternoon.
+	now := time.Date(2026, 10, 17, 14, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		at      string
+		pattern string // bridge time pattern
+		when    string // as described back
+	}{
+		{"15:30", "2026-10-17T15:30:00", "2026-10-17 15:30"},
+		{"07:00", "2026-10-18T07:00:00", "2026-10-18 07:00"},
+		{"tomorrow 7:00", "2026-10-18T07:00:00", "2026-10-18 07:00"},
+		{"2026-12-24 18:00:30", "2026-12-24T18:00:30", "2026-12-24 18:00:30"},
+		{"daily 22:30", "W127/T22:30:00", "daily 22:30"},
+		{"Every Day 22:30", "W127/T22:30:00", "daily 22:30"},
+		{"weekdays 07:00", "W124/T07:00:00", "weekdays 07:00"},
+		{"weekends 09:00", "W003/T09:00:00", "weekends 09:00"},
+		{"mon,wed,fri 18:00", "W084/T18:00:00", "mon,wed,fri 18:00"},
+		{"mon-fri 07:00", "W124/T07:00:00", "weekdays 07:00"},
+		{"tuesday,sat-sun 08:00", "W035/T08:00:00", "tue,sat,sun 08:00"},
+		{"in 10m", "PT00:10:00", "in 10m"},
+		{"in 1h30m", "PT01:30:00", "in 1h30m"},
+		{"every 2h", "R/PT02:00:00", "every 2h"},
+		{"daily 22:30 random 15m", "W127/T22:30:00A00:15:00", "daily 22:30 random 15m"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.at, func(t *testing.T) {
+			p, err := parseScheduleTime(tt.at, now)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := p.String(); got != tt.pattern {
+				t.Errorf("pattern = %q, want %q", got, tt.pattern)
+			}
+
+			parsed, err := hue.ParseTimePattern(tt.pattern, now.Location())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := describeTimePattern(parsed); got != tt.when {
+				t.Errorf("describeTimePattern = %q, want %q", got, tt.when)
+			}
+		})
+	}
+}
+
+func TestParseScheduleTime_BridgeZone(t *testing.T) {
+	// 20:00 in UTC is already 01:00 the next day on a bridge at UTC+5.
+	bridge := hue.BridgeConfig{TimeZone: "none", LocalTime: "2026-10-18T01:00:00", UTC: "2026-10-17T20:00:00"}
+	now := time.Date(2026, 10, 17, 20, 0, 0, 0, time.UTC).In(bridge.Location())
+
+	p, err := parseScheduleTime("23:00", now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := p.String(); got != "2026-10-18T23:00:00" {
+		t.Errorf("pattern = %q, want the bridge's next 23:00", got)
+	}
+}
+
+func TestRunsOnce(t *testing.T) {
+	tests := map[string]bool{
+		"2026-10-18T07:00:00": true,
+		"PT00:10:00":          true,
+		"R05/PT00:10:00":      false,
+		"R/PT00:10:00":        false,
+		"W124/T07:00:00":      false,
+	}
+	for pattern, want := range tests {
+		p, err := hue.ParseTimePattern(pattern, time.UTC)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := runsOnce(p); got != want {
+			t.Errorf("runsOnce(%s) = %t, want %t", pattern, got, want)
+		}
+	}
+}
+
+func TestParseScheduleTime_Invalid(t *testing.T) {
+	now := time.Date(2026, 10, 17, 14, 0, 0, 0, time.Local)
+
+	for _, at := range []string{
+		"",
+		"7am",
+		"25:00",
+		"today 09:00",
+		"2026-01-01 09:00",
+		"someday 09:00",
+		"fri-mon 09:00",
+		"in 500ms",
+		"in 25h",
+		"every 24h",
+		"every soon",
+		"daily 22:30 random",
+		"daily 22:30 random 1x",
+		"daily 22:30 random 48h",
+	} {
+		if _, err := parseScheduleTime(at, now); err == nil {
+			t.Errorf("parseScheduleTime(%q): expected an error", at)
+		}
+	}
+}
+
+func TestDescribeScheduleCommand(t *testing.T) {
+	state := &hue.BridgeState{
+		Lights: []hue.Light{{ID: "3", Name: "Porch"}},
+		Groups: []hue.Group{{ID: "1", Name: "Kitchen"}},
+		Scenes: []hue.Scene{{ID: "abc", Name: "Wake", Group: "1"}},
+	}
+	client := hue.NewClient("bridge", "user")
+	on, off := true, false
+
+	sceneCommand, _ := client.GroupActionCommand("1", hue.GroupAction{Scene: "abc"})
+	groupCommand, _ := client.GroupActionCommand("1", hue.GroupAction{On: &off})
+	lightCommand, _ := client.LightStateCommand("3", hue.LightState{On: &on})
+
+	tests := []struct {
+		command hue.ScheduleCommand
+		want    string
+	}{
+		{sceneCommand, "scene Wake"},
+		{groupCommand, "group Kitchen: off"},
+		{lightCommand, "light Porch: on"},
+		{hue.ScheduleCommand{Address: "/api/user/sensors/5/state", Method: "PUT"}, "PUT /api/user/sensors/5/state"},
+	}
+
+	for _, tt := range tests {
+		if got := describeScheduleCommand(tt.command, state); got != tt.want {
+			t.Errorf("describeScheduleCommand(%s) = %q, want %q", tt.command.Address, got, tt.want)
+		}
+	}
+}
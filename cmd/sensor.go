@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/LarsEckart/huey/hue"
+	"github.com/spf13/cobra"
+)
+
+var (
+	sensorFlagEnable      bool
+	sensorFlagDisable     bool
+	sensorFlagSensitivity int
+	sensorFlagLED         bool
+)
+
+// SensorCmd shows or configures a single sensor.
+var SensorCmd = &cobra.Command{
+	Use:   "sensor <id|name>",
+	Short: "Show or configure a sensor",
+	Long: "Show a sensor's reading, battery and reachability, identified by ID, name, unique name prefix, or a close match of its name.\n" +
+		"With --enable, --disable, --sensitivity or --led, change its settings instead.",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if sensorFlagEnable && sensorFlagDisable {
+			return fmt.Errorf("use only one of --enable or --disable")
+		}
+		flags := cmd.Flags()
+		if flags.Changed("sensitivity") && sensorFlagSensitivity < 0 {
+			return fmt.Errorf("--sensitivity cannot be negative, got %d", sensorFlagSensitivity)
+		}
+
+		client, err := authenticatedClient(cmd)
+		if err != nil {
+			return err
+		}
+
+		sensors, err := client.GetSensors(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("get sensors: %w", err)
+		}
+		sensor, err := ResolveSensor(sensors, args[0])
+		if err != nil {
+			return err
+		}
+
+		var update hue.SensorConfigUpdate
+		var changes []string
+		if sensorFlagEnable || sensorFlagDisable {
+			on := sensorFlagEnable
+			update.On = &on
+			if on {
+				changes = append(changes, "enabled")
+			} else {
+				changes = append(changes, "disabled")
+			}
+		}
+		if flags.Changed("sensitivity") {
+			if sensor.Config.SensitivityMax == nil {
+				return fmt.Errorf("sensor %s has no sensitivity setting", sensor.ID)
+			}
+			if sensorFlagSensitivity > *sensor.Config.SensitivityMax {
+				return fmt.Errorf("--sensitivity must be between 0 and %d for sensor %s, got %d", *sensor.Config.SensitivityMax, sensor.ID, sensorFlagSensitivity)
+			}
+			update.Sensitivity = &sensorFlagSensitivity
+			changes = append(changes, fmt.Sprintf("sensitivity %d", sensorFlagSensitivity))
+		}
+		if flags.Changed("led") {
+			if sensor.Config.LEDIndication == nil {
+				return fmt.Errorf("sensor %s has no LED setting", sensor.ID)
+			}
+			update.LEDIndication = &sensorFlagLED
+			changes = append(changes, fmt.Sprintf("LED indication %t", sensorFlagLED))
+		}
+
+		if len(changes) == 0 {
+			return showSensor(cmd, sensor)
+		}
+
+		if err := client.SetSensorConfig(cmd.Context(), sensor.ID, update); err != nil {
+			return fmt.Errorf("set sensor config: %w", err)
+		}
+
+		result := mutationResult{Action: "set", Resource: "sensor", ID: sensor.ID}
+		if structuredOutput() {
+			updated, err := client.GetSensor(cmd.Context(), sensor.ID)
+			if err != nil {
+				return fmt.Errorf("get sensor: %w", err)
+			}
+			result.Old = newSensorRecord(sensor)
+			result.New = newSensorRecord(*updated)
+		}
+		return renderResult(cmd, result, fmt.Sprintf("Sensor %s set to %s", sensor.ID, strings.Join(changes, ", ")))
+	},
+}
+
+func showSensor(cmd *cobra.Command, sensor hue.Sensor) error {
+	return render(cmd, newSensorRecord(sensor), func(w io.Writer) {
+		config := sensor.Config
+
+		_, _ = fmt.Fprintf(w, "ID:           %s\n", sensor.ID)
+		_, _ = fmt.Fprintf(w, "Name:         %s\n", sensor.Name)
+		_, _ = fmt.Fprintf(w, "Type:         %s\n", sensor.Type)
+		_, _ = fmt.Fprintf(w, "Model:        %s\n", sensor.ModelID)
+		_, _ = fmt.Fprintf(w, "Enabled:      %t\n", config.On)
+		if config.Reachable != nil {
+			_, _ = fmt.Fprintf(w, "Reachable:    %t\n", *config.Reachable)
+		}
+		if config.Battery != nil {
+			_, _ = fmt.Fprintf(w, "Battery:      %d%%\n", *config.Battery)
+		}
+		if reading := sensorReading(sensor); reading != "" {
+			_, _ = fmt.Fprintf(w, "Reading:      %s\n", reading)
+		}
+		updated := lastUpdated(sensor.State.LastUpdated, time.Now())
+		if !sensor.State.LastUpdated.IsZero() {
+			updated = sensor.State.LastUpdated.Local().Format("2006-01-02 15:04:05") + " (" + updated + ")"
+		}
+		_, _ = fmt.Fprintf(w, "Updated:      %s\n", updated)
+		if config.Sensitivity != nil && config.SensitivityMax != nil {
+			_, _ = fmt.Fprintf(w, "Sensitivity:  %d of %d\n", *config.Sensitivity, *config.SensitivityMax)
+		}
+		if config.LEDIndication != nil {
+			_, _ = fmt.Fprintf(w, "LED:          %t\n", *config.LEDIndication)
+		}
+	})
+}
+
+func init() {
+	SensorCmd.Flags().BoolVar(&sensorFlagEnable, "enable", false, "Enable the sensor")
+	SensorCmd.Flags().BoolVar(&sensorFlagDisable, "disable", false, "Disable the sensor, e.g. to pause a motion sensor's automations")
+	SensorCmd.Flags().IntVar(&sensorFlagSensitivity, "sensitivity", 0, "Motion sensitivity, 0 up to the sensor's maximum")
+	SensorCmd.Flags().BoolVar(&sensorFlagLED, "led", false, "Light the LED on motion (--led=false to turn it off)")
+}
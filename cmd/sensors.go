@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/LarsEckart/huey/hue"
+	"github.com/spf13/cobra"
+)
+
+// SensorsCmd lists all sensors.
+var SensorsCmd = &cobra.Command{
+	Use:   "sensors",
+	Short: "List all sensors",
+	Long:  "List motion sensors, switches and the bridge's virtual sensors with their last reading, battery level and when they last reported.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := authenticatedClient(cmd)
+		if err != nil {
+			return err
+		}
+
+		sensors, err := client.GetSensors(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("get sensors: %w", err)
+		}
+
+		records := make([]sensorRecord, 0, len(sensors))
+		for _, sensor := range sensors {
+			records = append(records, newSensorRecord(sensor))
+		}
+
+		now := time.Now()
+		return render(cmd, records, func(w io.Writer) {
+			for _, sensor := range sensors {
+				_, _ = fmt.Fprintf(w, "%-3s %-24s %-18s %-22s %s\n",
+					sensor.ID, sensor.Name, sensor.Type, sensorReading(sensor), sensorHealth(sensor, now))
+			}
+		})
+	},
+}
+
+// sensorReading describes a sensor's last reading, e.g. "motion" or
+// "21.5°C". It is empty for sensor types without a known reading.
+func sensorReading(sensor hue.Sensor) string {
+	state := sensor.State
+	switch {
+	case state.Presence != nil:
+		if *state.Presence {
+			return "motion"
+		}
+		return "no motion"
+	case state.Temperature != nil:
+		return fmt.Sprintf("%.1f°C", *state.Temperature)
+	case state.LightLevel != nil:
+		reading := fmt.Sprintf("%.0f lux", state.Lux())
+		if state.Dark != nil && *state.Dark {
+			reading += ", dark"
+		}
+		return reading
+	case state.ButtonEvent != nil:
+		return describeButtonEvent(sensor.Type, *state.ButtonEvent)
+	case state.Daylight != nil:
+		if *state.Daylight {
+			return "daylight"
+		}
+		return "dark"
+	case state.Flag != nil:
+		return fmt.Sprintf("flag %t", *state.Flag)
+	case state.Status != nil:
+		return fmt.Sprintf("status %d", *state.Status)
+	}
+	return ""
+}
+
+// describeButtonEvent names the button and action of a switch event.
+// Only ZLLSwitch events (dimmer switches) follow the button*1000 + action
+// scheme; others, like the Tap switch's, are shown as is.
+func describeButtonEvent(sensorType string, event int) string {
+	if sensorType != "ZLLSwitch" {
+		return fmt.Sprintf("button event %d", event)
+	}
+	actions := map[int]string{
+		hue.ButtonInitialPress: "pressed",
+		hue.ButtonHold:         "held",
+		hue.ButtonShortRelease: "short release",
+		hue.ButtonLongRelease:  "long release",
+	}
+	action, ok := actions[event%1000]
+	if !ok {
+		return fmt.Sprintf("button event %d", event)
+	}
+	return fmt.Sprintf("button %d %s", event/1000, action)
+}
+
+// sensorHealth summarizes whether a sensor works: disabled, unreachable,
+// battery level and when it last reported.
+func sensorHealth(sensor hue.Sensor, now time.Time) string {
+	var health string
+	switch {
+	case !sensor.Config.On:
+		health = "disabled, "
+	case sensor.Config.Reachable != nil && !*sensor.Config.Reachable:
+		health = "unreachable, "
+	}
+	if sensor.Config.Battery != nil {
+		health += fmt.Sprintf("battery %d%%, ", *sensor.Config.Battery)
+	}
+	return health + "updated " + lastUpdated(sensor.State.LastUpdated, now)
+}
+
+// lastUpdated describes how long ago t was, or "never" for a zero t.
+func lastUpdated(t, now time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	age := now.Sub(t)
+	switch {
+	case age < time.Minute:
+		return "just now"
+	case age < 24*time.Hour:
+		return shortDuration(age.Truncate(time.Minute)) + " ago"
+	default:
+		return t.Local().Format("2006-01-02 15:04")
+	}
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/LarsEckart/huey/hue"
+)
+
+func TestSensorReading(t *testing.T) {
+	yes, no := true, false
+	temperature := 21.46
+	lightLevel := 20001
+	event := 4003
+
+	tests := []struct {
+		sensor hue.Sensor
+		want   string
+	}{
+		{hue.Sensor{Type: "ZLLPresence", State: hue.SensorState{Presence: &yes}}, "motion"},
+		{hue.Sensor{Type: "ZLLPresence", State: hue.SensorState{Presence: &no}}, "no motion"},
+		{hue.Sensor{Type: "ZLLTemperature", State: hue.SensorState{Temperature: &temperature}}, "21.5°C"},
+		{hue.Sensor{Type: "ZLLLightLevel", State: hue.SensorState{LightLevel: &lightLevel, Dark: &yes, Daylight: &no}}, "100 lux, dark"},
+		{hue.Sensor{Type: "ZLLSwitch", State: hue.SensorState{ButtonEvent: &event}}, "button 4 long release"},
+		{hue.Sensor{Type: "ZGPSwitch", State: hue.SensorState{ButtonEvent: &event}}, "button event 4003"},
+		{hue.Sensor{Type: "Daylight", State: hue.SensorState{Daylight: &no}}, "dark"},
+		{hue.Sensor{Type: "CLIPGenericFlag", State: hue.SensorState{Flag: &yes}}, "flag true"},
+		{hue.Sensor{Type: "Unknown"}, ""},
+	}
+
+	for _, tt := range tests {
+		if got := sensorReading(tt.sensor); got != tt.want {
+			t.Errorf("sensorReading(%s) = %q, want %q", tt.sensor.Type, got, tt.want)
+		}
+	}
+}
+
+func TestSensorHealth(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	unreachable := false
+	battery := 15
+
+	sensor := hue.Sensor{
+		State:  hue.SensorState{LastUpdated: now.Add(-90 * time.Minute)},
+		Config: hue.SensorConfig{On: true, Reachable: &unreachable, Battery: &battery},
+	}
+	if got, want := sensorHealth(sensor, now), "unreachable, battery 15%, updated 1h30m ago"; got != want {
+		t.Errorf("sensorHealth = %q, want %q", got, want)
+	}
+
+	if got := sensorHealth(hue.Sensor{}, now); got != "disabled, updated never" {
+		t.Errorf("sensorHealth = %q, want %q", got, "disabled, updated never")
+	}
+}
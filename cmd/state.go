@@ -0,0 +1,328 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/LarsEckart/huey/hue"
+	"github.com/LarsEckart/huey/hue/color"
+	"github.com/spf13/pflag"
+)
+
+// stateFlags holds the brightness and color flags shared by light and group.
+type stateFlags struct {
+	brightness string
+	hue        int
+	sat        int
+	ct         int
+	kelvin     int
+	xy         string
+	color      string
+	effect     string
+	transition time.Duration
+}
+
+var stateFlagNames = []string{"brightness", "hue", "sat", "ct", "kelvin", "xy", "color", "effect"}
+
+func (f *stateFlags) register(flags *pflag.FlagSet) {
+	flags.StringVar(&f.brightness, "brightness", "", "Brightness as percent (e.g. '50%') or 1-254, or a step like '+10%' or '-20'")
+	flags.IntVar(&f.hue, "hue", 0, "Hue (0-65535)")
+	flags.IntVar(&f.sat, "sat", 0, "Saturation (0-254)")
+	flags.IntVar(&f.ct, "ct", 0, "Color temperature in mired (153-500)")
+	flags.IntVar(&f.kelvin, "kelvin", 0, "Color temperature in Kelvin (e.g. 2700)")
+	flags.StringVar(&f.xy, "xy", "", "CIE xy color coordinates (e.g. '0.45,0.41')")
+	flags.StringVar(&f.color, "color", "", "Color as hex, rgb(), hsv(), Kelvin ('2700K') or name ('warm white')")
+	flags.StringVar(&f.effect, "effect", "", "Effect: 'colorloop' to cycle through colors, 'none' to stop")
+	registerTransition(flags, &f.transition)
+}
+
+// registerTransition adds the --transition flag to flags.
+func registerTransition(flags *pflag.FlagSet, transition *time.Duration) {
+	flags.DurationVar(transition, "transition", 0, "Fade to the new state over this long (e.g. '2s', 0 for instant; default from the config or 400ms)")
+}
+
+// changed reports whether any brightness, color or effect flag was given.
+// --transition alone is not a change.
+func (f *stateFlags) changed(flags *pflag.FlagSet) bool {
+	for _, name := range stateFlagNames {
+		if flags.Changed(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// lightState builds a LightState from the flags that were given.
+// Converted values (percent, Kelvin) are clamped to the bridge's ranges;
+// raw values outside those ranges are rejected.
+func (f *stateFlags) lightState(flags *pflag.FlagSet) (hue.LightState, error) {
+	var state hue.LightState
+
+	if flags.Changed("ct") && flags.Changed("kelvin") {
+		return state, fmt.Errorf("use only one of --ct or --kelvin")
+	}
+
+	colorModes := 0
+	if flags.Changed("xy") {
+		colorModes++
+	}
+	if flags.Changed("color") {
+		colorModes++
+	}
+	if flags.Changed("ct") || flags.Changed("kelvin") {
+		colorModes++
+	}
+	if flags.Changed("hue") || flags.Changed("sat") {
+		colorModes++
+	}
+	if colorModes > 1 {
+		return state, fmt.Errorf("use only one color mode: --color, --xy, --ct/--kelvin, or --hue/--sat")
+	}
+
+	if flags.Changed("brightness") {
+		if isBrightnessStep(f.brightness) {
+			step, err := parseBrightnessStep(f.brightness)
+			if err != nil {
+				return state, err
+			}
+			state.BrightnessInc = &step
+		} else {
+			bri, err := parseBrightness(f.brightness)
+			if err != nil {
+				return state, err
+			}
+			state.Brightness = &bri
+		}
+	}
+
+	if flags.Changed("hue") {
+		if f.hue < 0 || f.hue > hue.MaxHue {
+			return state, fmt.Errorf("--hue must be between 0 and %d, got %d", hue.MaxHue, f.hue)
+		}
+		state.Hue = &f.hue
+	}
+
+	if flags.Changed("sat") {
+		if f.sat < 0 || f.sat > hue.MaxSaturation {
+			return state, fmt.Errorf("--sat must be between 0 and %d, got %d", hue.MaxSaturation, f.sat)
+		}
+		state.Saturation = &f.sat
+	}
+
+	if flags.Changed("ct") {
+		if f.ct < hue.MinColorTemp || f.ct > hue.MaxColorTemp {
+			return state, fmt.Errorf("--ct must be between %d and %d mired, got %d", hue.MinColorTemp, hue.MaxColorTemp, f.ct)
+		}
+		state.ColorTemp = &f.ct
+	}
+
+	if flags.Changed("kelvin") {
+		ct, err := kelvinToMired(f.kelvin)
+		if err != nil {
+			return state, err
+		}
+		state.ColorTemp = &ct
+	}
+
+	if flags.Changed("xy") {
+		xy, err := parseXY(f.xy)
+		if err != nil {
+			return state, err
+		}
+		state.XY = &xy
+	}
+
+	if flags.Changed("color") {
+		xy, err := f.colorXY(color.GamutC)
+		if err != nil {
+			return state, err
+		}
+		state.XY = &xy
+	}
+
+	if flags.Changed("effect") {
+		switch f.effect {
+		case hue.EffectColorLoop, hue.EffectNone:
+			state.Effect = f.effect
+		default:
+			return state, fmt.Errorf("--effect must be %q or %q, got %q", hue.EffectColorLoop, hue.EffectNone, f.effect)
+		}
+	}
+
+	if flags.Changed("transition") {
+		transitionTime, err := parseTransition(f.transition)
+		if err != nil {
+			return state, err
+		}
+		state.TransitionTime = transitionTime
+	}
+
+	return state, nil
+}
+
+// colorXY converts the --color value to xy within the given gamut.
+func (f *stateFlags) colorXY(gamut color.Gamut) ([2]float64, error) {
+	xy, err := color.Parse(f.color, gamut)
+	if err != nil {
+		return [2]float64{}, fmt.Errorf("--color: %w", err)
+	}
+	return [2]float64{xy.X, xy.Y}, nil
+}
+
+// parseBrightness accepts "50%" (0-100 percent) or a raw value (0-254).
+// The result is clamped to the bridge's 1-254 range.
+func parseBrightness(value string) (int, error) {
+	value = strings.TrimSpace(value)
+
+	if percentText, ok := strings.CutSuffix(value, "%"); ok {
+		percent, err := strconv.ParseFloat(percentText, 64)
+		if err != nil || percent < 0 || percent > 100 {
+			return 0, fmt.Errorf("--brightness must be a percentage between 0%% and 100%%, got %q", value)
+		}
+		bri := int(math.Round(percent / 100 * hue.MaxBrightness))
+		return min(max(bri, hue.MinBrightness), hue.MaxBrightness), nil
+	}
+
+	bri, err := strconv.Atoi(value)
+	if err != nil || bri < 0 || bri > hue.MaxBrightness {
+		return 0, fmt.Errorf("--brightness must be between 0 and %d or a percentage like 50%%, got %q", hue.MaxBrightness, value)
+	}
+	return max(bri, hue.MinBrightness), nil
+}
+
+// isBrightnessStep reports whether a --brightness value is relative.
+func isBrightnessStep(value string) bool {
+	value = strings.TrimSpace(value)
+	return strings.HasPrefix(value, "+") || strings.HasPrefix(value, "-")
+}
+
+// parseBrightnessStep accepts a signed step, "+10%" (up to 100 percent) or
+// "-20" (up to 254), and returns it as a brightness increment.
+func parseBrightnessStep(value string) (int, error) {
+	value = strings.TrimSpace(value)
+
+	if percentText, ok := strings.CutSuffix(value, "%"); ok {
+		percent, err := strconv.ParseFloat(percentText, 64)
+		if err != nil || percent < -100 || percent > 100 {
+			return 0, fmt.Errorf("--brightness step must be a percentage between -100%% and +100%%, got %q", value)
+		}
+		return int(math.Round(percent / 100 * hue.MaxBrightness)), nil
+	}
+
+	step, err := strconv.Atoi(value)
+	if err != nil || step < -hue.MaxBrightnessInc || step > hue.MaxBrightnessInc {
+		return 0, fmt.Errorf("--brightness step must be between -%d and +%d or a percentage like +10%%, got %q", hue.MaxBrightnessInc, hue.MaxBrightnessInc, value)
+	}
+	return step, nil
+}
+
+// brightnessStepOnly reports whether state does nothing but step the
+// brightness. Stepping leaves lights that are off alone rather than
+// turning them on.
+func brightnessStepOnly(state hue.LightState) bool {
+	return state.BrightnessInc != nil && state.Brightness == nil && state.Effect == "" &&
+		state.Hue == nil && state.Saturation == nil && state.ColorTemp == nil && state.XY == nil
+}
+
+// kelvinToMired converts a color temperature in Kelvin to mired,
+// clamped to the range supported by the bridge.
+func kelvinToMired(kelvin int) (int, error) {
+	if kelvin <= 0 {
+		return 0, fmt.Errorf("--kelvin must be positive, got %d", kelvin)
+	}
+	mired := int(math.Round(1_000_000 / float64(kelvin)))
+	return min(max(mired, hue.MinColorTemp), hue.MaxColorTemp), nil
+}
+
+// parseTransition converts a --transition duration to deciseconds.
+func parseTransition(d time.Duration) (*int, error) {
+	if d < 0 || d > hue.MaxTransition {
+		return nil, fmt.Errorf("--transition must be between 0s and %s, got %s", hue.MaxTransition, d)
+	}
+	return hue.TransitionTime(d), nil
+}
+
+// parseXY parses "x,y" CIE coordinates, each between 0 and 1.
+func parseXY(value string) ([2]float64, error) {
+	var xy [2]float64
+
+	parts := strings.Split(value, ",")
+	if len(parts) != 2 {
+		return xy, fmt.Errorf("--xy must be two comma-separated numbers like '0.45,0.41', got %q", value)
+	}
+
+	for i, part := range parts {
+		coord, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || coord < 0 || coord > 1 {
+			return xy, fmt.Errorf("--xy coordinates must be between 0 and 1, got %q", value)
+		}
+		xy[i] = coord
+	}
+
+	return xy, nil
+}
+
+// groupActionFromState applies the same attributes to a whole group.
+func groupActionFromState(state hue.LightState) hue.GroupAction {
+	return hue.GroupAction{
+		On:         state.On,
+		Brightness: state.Brightness,
+		Hue:        state.Hue,
+		Saturation: state.Saturation,
+		ColorTemp:  state.ColorTemp,
+		XY:         state.XY,
+
+		BrightnessInc: state.BrightnessInc,
+		SaturationInc: state.SaturationInc,
+		HueInc:        state.HueInc,
+		ColorTempInc:  state.ColorTempInc,
+		XYInc:         state.XYInc,
+
+		Alert:  state.Alert,
+		Effect: state.Effect,
+
+		TransitionTime: state.TransitionTime,
+	}
+}
+
+// describeState summarizes the attributes being set, for confirmation output.
+func describeState(state hue.LightState) string {
+	var parts []string
+	if state.On != nil {
+		if *state.On {
+			parts = append(parts, "on")
+		} else {
+			parts = append(parts, "off")
+		}
+	}
+	if state.Brightness != nil {
+		percent := math.Round(float64(*state.Brightness) / hue.MaxBrightness * 100)
+		parts = append(parts, fmt.Sprintf("brightness %.0f%%", percent))
+	}
+	if state.BrightnessInc != nil {
+		percent := math.Round(float64(*state.BrightnessInc) / hue.MaxBrightness * 100)
+		parts = append(parts, fmt.Sprintf("brightness %+.0f%%", percent))
+	}
+	if state.Hue != nil {
+		parts = append(parts, fmt.Sprintf("hue %d", *state.Hue))
+	}
+	if state.Saturation != nil {
+		parts = append(parts, fmt.Sprintf("saturation %d", *state.Saturation))
+	}
+	if state.ColorTemp != nil {
+		parts = append(parts, fmt.Sprintf("color temperature %d mired", *state.ColorTemp))
+	}
+	if state.XY != nil {
+		parts = append(parts, fmt.Sprintf("xy %.4f,%.4f", state.XY[0], state.XY[1]))
+	}
+	if state.Effect != "" {
+		parts = append(parts, fmt.Sprintf("effect %s", state.Effect))
+	}
+	if state.TransitionTime != nil {
+		parts = append(parts, fmt.Sprintf("over %s", time.Duration(*state.TransitionTime)*100*time.Millisecond))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestParseBrightness(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int
+		wantErr bool
+	}{
+		{input: "100%", want: 254},
+		{input: "50%", want: 127},
+		{input: "0%", want: 1},
+		{input: "254", want: 254},
+		{input: "0", want: 1},
+		{input: "128", want: 128},
+		{input: "101%", wantErr: true},
+		{input: "255", wantErr: true},
+		{input: "-1", wantErr: true},
+		{input: "bright", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseBrightness(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseBrightness(%q) = %d, want error", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseBrightness(%q) unexpected error: %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("parseBrightness(%q) = %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKelvinToMired_Clamped(t *testing.T) {
+	tests := []struct {
+		kelvin int
+		want   int
+	}{
+		{kelvin: 2700, want: 370},
+		{kelvin: 10000, want: 153},
+		{kelvin: 1000, want: 500},
+	}
+
+	for _, tt := range tests {
+		got, err := kelvinToMired(tt.kelvin)
+		if err != nil {
+			t.Fatalf("kelvinToMired(%d) unexpected error: %v", tt.kelvin, err)
+		}
+		if got != tt.want {
+			t.Errorf("kelvinToMired(%d) = %d, want %d", tt.kelvin, got, tt.want)
+		}
+	}
+}
+
+func TestStateFlags_RejectsMixedColorModes(t *testing.T) {
+	var f stateFlags
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	f.register(flags)
+
+	if err := flags.Parse([]string{"--kelvin", "2700", "--xy", "0.3,0.3"}); err != nil {
+		t.Fatalf("parse flags: %v", err)
+	}
+
+	if _, err := f.lightState(flags); err == nil {
+		t.Fatal("expected error for mixed color modes, got nil")
+	}
+}
+
+func TestStateFlags_Transition(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{"2s", 20, false},
+		{"0", 0, false},
+		{"250ms", 3, false},
+		{"-1s", 0, true},
+		{"2h", 0, true},
+	}
+
+	for _, tt := range tests {
+		var f stateFlags
+		flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+		f.register(flags)
+		if err := flags.Parse([]string{"--transition", tt.value}); err != nil {
+			t.Fatalf("parse flags: %v", err)
+		}
+
+		state, err := f.lightState(flags)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("--transition %s: expected error, got nil", tt.value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("--transition %s: unexpected error: %v", tt.value, err)
+			continue
+		}
+		if state.TransitionTime == nil || *state.TransitionTime != tt.want {
+			t.Errorf("--transition %s: expected %d deciseconds, got %v", tt.value, tt.want, state.TransitionTime)
+		}
+		if f.changed(flags) {
+			t.Errorf("--transition %s: expected no state change", tt.value)
+		}
+	}
+}
+
+func TestParseBrightnessStep(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{"+10%", 25, false},
+		{"-20%", -51, false},
+		{"-20", -20, false},
+		{"+254", 254, false},
+		{"+255", 0, true},
+		{"-150%", 0, true},
+		{"+x", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseBrightnessStep(tt.value)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseBrightnessStep(%q): expected error, got nil", tt.value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseBrightnessStep(%q): unexpected error: %v", tt.value, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseBrightnessStep(%q) = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestStateFlags_BrightnessStep(t *testing.T) {
+	var f stateFlags
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	f.register(flags)
+
+	if err := flags.Parse([]string{"--brightness", "-10%"}); err != nil {
+		t.Fatalf("parse flags: %v", err)
+	}
+
+	state, err := f.lightState(flags)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if state.Brightness != nil || state.BrightnessInc == nil || *state.BrightnessInc != -25 {
+		t.Errorf("expected bri_inc -25 only, got bri %v, bri_inc %v", state.Brightness, state.BrightnessInc)
+	}
+	if !brightnessStepOnly(state) {
+		t.Error("expected a brightness step only")
+	}
+	if got := describeState(state); got != "brightness -10%" {
+		t.Errorf("describeState = %q, want %q", got, "brightness -10%")
+	}
+}
+
+func TestStateFlags_Effect(t *testing.T) {
+	for _, tt := range []struct {
+		value   string
+		wantErr bool
+	}{
+		{"colorloop", false},
+		{"none", false},
+		{"strobe", true},
+	} {
+		var f stateFlags
+		flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+		f.register(flags)
+		if err := flags.Parse([]string{"--effect", tt.value}); err != nil {
+			t.Fatalf("parse flags: %v", err)
+		}
+
+		state, err := f.lightState(flags)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("--effect %s: expected error, got nil", tt.value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("--effect %s: unexpected error: %v", tt.value, err)
+			continue
+		}
+		if state.Effect != tt.value {
+			t.Errorf("--effect %s: got effect %q", tt.value, state.Effect)
+		}
+		if !f.changed(flags) {
+			t.Errorf("--effect %s: expected a state change", tt.value)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/LarsEckart/huey/hue"
+	"github.com/LarsEckart/huey/hue/clip"
+)
+
+// v1ID returns the ID in a v2 resource's v1 address, e.g. "3" for
+// "/lights/3", or "" if the resource has no v1 counterpart.
+func v1ID(address string) string {
+	_, id, _ := strings.Cut(strings.TrimPrefix(address, "/"), "/")
+	return id
+}
+
+// compareV1IDs orders numeric v1 IDs like the v1 client does.
+func compareV1IDs(a, b string) int {
+	aID, _ := strconv.Atoi(a)
+	bID, _ := strconv.Atoi(b)
+	return cmp.Compare(aID, bID)
+}
+
+// v1LightType returns the v1 type name for a light with the features of l.
+func v1LightType(l clip.Light) string {
+	switch {
+	case l.Color != nil && l.ColorTemperature != nil:
+		return "Extended color light"
+	case l.Color != nil:
+		return "Color light"
+	case l.ColorTemperature != nil:
+		return "Color temperature light"
+	case l.Dimming != nil:
+		return "Dimmable light"
+	default:
+		return "On/Off plug-in unit"
+	}
+}
+
+// lightsFromV2 converts CLIP v2 lights to the v1 model, identified by
+// their v1 IDs and sorted by them. Lights the v1 API doesn't know are
+// left out.
+func lightsFromV2(lights []clip.Light) []hue.Light {
+	converted := make([]hue.Light, 0, len(lights))
+	for _, l := range lights {
+		id := v1ID(l.IDV1)
+		if id == "" {
+			continue
+		}
+		light := hue.Light{
+			ID:   id,
+			Name: l.Metadata.Name,
+			On:   l.On.On,
+			Type: v1LightType(l),
+		}
+		if l.Dimming != nil {
+			light.Brightness = max(1, int(math.Round(l.Dimming.Brightness*254/100)))
+		}
+		if l.Color != nil {
+			light.XY = [2]float64{l.Color.XY.X, l.Color.XY.Y}
+			light.GamutType = l.Color.GamutType
+			light.ColorMode = "xy"
+		}
+		if ct := l.ColorTemperature; ct != nil && ct.Mirek != nil {
+			light.ColorTemp = *ct.Mirek
+			if ct.MirekValid {
+				light.ColorMode = "ct"
+			}
+		}
+		converted = append(converted, light)
+	}
+	slices.SortFunc(converted, func(a, b hue.Light) int { return compareV1IDs(a.ID, b.ID) })
+	return converted
+}
+
+// groupsFromV2 returns the bridge's rooms and zones in the v1 model.
+func groupsFromV2(ctx context.Context, client *clip.Client) ([]hue.Group, error) {
+	rooms, err := client.GetRooms(ctx)
+	if err != nil {
+		return nil, err
+	}
+	zones, err := client.GetZones(ctx)
+	if err != nil {
+		return nil, err
+	}
+	lights, err := client.GetLights(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return convertGroupsV2(rooms, zones, lights), nil
+}
+
+// convertGroupsV2 converts rooms and zones to v1 groups, identified by
+// their v1 IDs and sorted by them, working out their lights and on state
+// from lights. Rooms list devices as children and zones list lights.
+func convertGroupsV2(rooms, zones []clip.Group, lights []clip.Light) []hue.Group {
+	byID := make(map[string]clip.Light, len(lights))
+	byOwner := make(map[string][]clip.Light)
+	for _, light := range lights {
+		byID[light.ID] = light
+		byOwner[light.Owner.RID] = append(byOwner[light.Owner.RID], light)
+	}
+
+	convert := func(g clip.Group, groupType string) hue.Group {
+		group := hue.Group{ID: v1ID(g.IDV1), Name: g.Metadata.Name, Type: groupType, Lights: []string{}}
+		var members []clip.Light
+		for _, child := range g.Children {
+			switch child.RType {
+			case "device":
+				members = append(members, byOwner[child.RID]...)
+			case "light":
+				if light, ok := byID[child.RID]; ok {
+					members = append(members, light)
+				}
+			}
+		}
+
+		group.AllOn = len(members) > 0
+		for _, light := range members {
+			if id := v1ID(light.IDV1); id != "" {
+				group.Lights = append(group.Lights, id)
+			}
+			group.AnyOn = group.AnyOn || light.On.On
+			group.AllOn = group.AllOn && light.On.On
+		}
+		slices.SortFunc(group.Lights, compareV1IDs)
+		return group
+	}
+
+	groups := make([]hue.Group, 0, len(rooms)+len(zones))
+	for _, room := range rooms {
+		if room.IDV1 != "" {
+			groups = append(groups, convert(room, "Room"))
+		}
+	}
+	for _, zone := range zones {
+		if zone.IDV1 != "" {
+			groups = append(groups, convert(zone, "Zone"))
+		}
+	}
+	slices.SortFunc(groups, func(a, b hue.Group) int { return compareV1IDs(a.ID, b.ID) })
+	return groups
+}
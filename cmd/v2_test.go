@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/LarsEckart/huey/hue/clip"
+)
+
+func TestLightsFromV2(t *testing.T) {
+	mirek := 366
+	lights := lightsFromV2([]clip.Light{
+		{ID: "l10", IDV1: "/lights/10", Metadata: clip.Metadata{Name: "Plug"}},
+		{ID: "l2", IDV1: "/lights/2", Metadata: clip.Metadata{Name: "Desk"}, On: clip.On{On: true}, Dimming: &clip.Dimming{Brightness: 100},
+			ColorTemperature: &clip.ColorTemperature{Mirek: &mirek, MirekValid: true}, Color: &clip.Color{XY: clip.XY{X: 0.45, Y: 0.41}}},
+		{ID: "l3", Metadata: clip.Metadata{Name: "v2 only"}},
+	})
+	if len(lights) != 2 {
+		t.Fatalf("expected the 2 lights with v1 IDs, got %+v", lights)
+	}
+
+	desk := lights[0]
+	if desk.ID != "2" || desk.Type != "Extended color light" || desk.Brightness != 254 || desk.ColorTemp != 366 || desk.ColorMode != "ct" || desk.XY != [2]float64{0.45, 0.41} {
+		t.Errorf("unexpected light: %+v", desk)
+	}
+	if plug := lights[1]; plug.ID != "10" || plug.Type != "On/Off plug-in unit" || plug.Brightness != 0 || plug.ColorMode != "" {
+		t.Errorf("unexpected light: %+v", plug)
+	}
+}
+
+func TestConvertGroupsV2(t *testing.T) {
+	lights := []clip.Light{
+		{ID: "l1", IDV1: "/lights/11", Owner: clip.ResourceRef{RID: "d1", RType: "device"}, On: clip.On{On: true}},
+		{ID: "l2", IDV1: "/lights/2", Owner: clip.ResourceRef{RID: "d2", RType: "device"}, On: clip.On{On: false}},
+	}
+	rooms := []clip.Group{{ID: "r1", IDV1: "/groups/3", Metadata: clip.Metadata{Name: "Office"}, Children: []clip.ResourceRef{
+		{RID: "d1", RType: "device"},
+		{RID: "d2", RType: "device"},
+		{RID: "d3", RType: "device"}, // a switch, without lights
+	}}}
+	zones := []clip.Group{
+		{ID: "z1", IDV1: "/groups/1", Metadata: clip.Metadata{Name: "Desk"}, Children: []clip.ResourceRef{{RID: "l1", RType: "light"}}},
+		{ID: "z2", Metadata: clip.Metadata{Name: "v2 only"}},
+	}
+
+	groups := convertGroupsV2(rooms, zones, lights)
+	if len(groups) != 2 {
+		t.Fatalf("expected the 2 groups with v1 IDs, got %+v", groups)
+	}
+
+	desk := groups[0]
+	if desk.ID != "1" || desk.Type != "Zone" || !slices.Equal(desk.Lights, []string{"11"}) || !desk.AllOn {
+		t.Errorf("unexpected zone: %+v", desk)
+	}
+	office := groups[1]
+	if office.ID != "3" || office.Type != "Room" || !slices.Equal(office.Lights, []string{"2", "11"}) || !office.AnyOn || office.AllOn {
+		t.Errorf("unexpected room: %+v", office)
+	}
+}
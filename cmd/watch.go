@@ -0,0 +1,282 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/LarsEckart/huey/hue"
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagWatchLights  bool
+	flagWatchGroups  bool
+	flagWatchSensors bool
+)
+
+// WatchCmd prints bridge state changes as they happen.
+var WatchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Print state changes as they happen",
+	Long: `Print one line per state change on the bridge, with the old and new
+value, until interrupted with Ctrl-C. Changes made by wall switches, the
+Hue app and automations show up too. Without --lights, --groups or
+--sensors, all three are watched.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := authenticatedClient(cmd)
+		if err != nil {
+			return err
+		}
+
+		all := !flagWatchLights && !flagWatchGroups && !flagWatchSensors
+		w := &watcher{
+			client:  client,
+			out:     &recordStream{cmd: cmd},
+			lights:  all || flagWatchLights,
+			groups:  all || flagWatchGroups,
+			sensors: all || flagWatchSensors,
+		}
+
+		ctx, cancel := context.WithCancelCause(cmd.Context())
+		defer cancel(nil)
+
+		state, err := client.GetFullState(ctx)
+		if err != nil {
+			return fmt.Errorf("get bridge state: %w", err)
+		}
+		w.snapshot(state)
+
+		if !structuredOutput() {
+			_, _ = fmt.Fprintln(cmd.ErrOrStderr(), "Watching for changes, press Ctrl-C to stop.")
+		}
+
+		err = client.Watch(ctx, func(event hue.Event) {
+			if err := w.handle(ctx, event); err != nil {
+				cancel(err)
+			}
+		})
+		if cause := context.Cause(ctx); cause != nil && !errors.Is(cause, context.Canceled) {
+			return cause
+		}
+		if err != nil && cmd.Context().Err() == nil {
+			return fmt.Errorf("watch bridge: %w", err)
+		}
+		// Interrupted, which is how watching ends.
+		return nil
+	},
+}
+
+// watcher keeps the last known state so it can report old and new values.
+type watcher struct {
+	client                  *hue.Client
+	out                     *recordStream
+	lights, groups, sensors bool // which resources to report
+
+	lightsByID  map[string]hue.Light
+	groupsByID  map[string]hue.Group
+	sensorsByID map[string]hue.Sensor
+}
+
+// change is a field whose value changed.
+type change struct {
+	field    string
+	old, new any
+}
+
+func (w *watcher) snapshot(state *hue.BridgeState) {
+	w.lightsByID = hue.IndexByID(state.Lights, func(l hue.Light) string { return l.ID })
+	w.groupsByID = hue.IndexByID(state.Groups, func(g hue.Group) string { return g.ID })
+	w.sensorsByID = hue.IndexByID(state.Sensors, func(s hue.Sensor) string { return s.ID })
+}
+
+// handle reports the changes in event. A sensor event only says that the
+// sensor changed, so the sensor is loaded again. Events for resources
+// that are unknown, or that say resources were added or removed, are
+// resolved by loading the full state again.
+func (w *watcher) handle(ctx context.Context, event hue.Event) error {
+	switch e := event.(type) {
+	case hue.LightEvent:
+		if old, ok := w.lightsByID[e.ID]; ok {
+			cur := old
+			e.Apply(&cur)
+			w.lightsByID[e.ID] = cur
+			if w.lights {
+				return w.report("light", e.ID, cur.Name, lightChanges(old, cur))
+			}
+			return nil
+		}
+	case hue.GroupEvent:
+		if old, ok := w.groupsByID[e.ID]; ok {
+			cur := old
+			e.Apply(&cur)
+			w.groupsByID[e.ID] = cur
+			if w.groups {
+				return w.report("group", e.ID, cur.Name, groupChanges(old, cur))
+			}
+			return nil
+		}
+	case hue.SceneEvent:
+		return nil
+	case hue.SensorEvent:
+		if !w.sensors {
+			return nil
+		}
+		if old, ok := w.sensorsByID[e.ID]; ok {
+			cur, err := w.client.GetSensor(ctx, e.ID)
+			if err != nil {
+				w.warn(ctx, fmt.Errorf("get sensor: %w", err))
+				return nil
+			}
+			w.sensorsByID[e.ID] = *cur
+			return w.report("sensor", e.ID, cur.Name, sensorChanges(old, *cur))
+		}
+	}
+
+	state, err := w.client.GetFullState(ctx)
+	if err != nil {
+		w.warn(ctx, fmt.Errorf("get bridge state: %w", err))
+		return nil
+	}
+	return w.resync(state)
+}
+
+// warn reports a failed refresh, which watching survives, unless it
+// failed because watching ends.
+func (w *watcher) warn(ctx context.Context, err error) {
+	if ctx.Err() != nil {
+		return
+	}
+	_, _ = fmt.Fprintf(w.out.cmd.ErrOrStderr(), "Warning: %v\n", err)
+}
+
+// resync reports every difference between the last known state and state.
+func (w *watcher) resync(state *hue.BridgeState) error {
+	oldLights, oldGroups, oldSensors := w.lightsByID, w.groupsByID, w.sensorsByID
+	w.snapshot(state)
+
+	if w.lights {
+		if err := reportAll(w, "light", oldLights, state.Lights, func(l hue.Light) (string, string) { return l.ID, l.Name }, lightChanges); err != nil {
+			return err
+		}
+	}
+	if w.groups {
+		if err := reportAll(w, "group", oldGroups, state.Groups, func(g hue.Group) (string, string) { return g.ID, g.Name }, groupChanges); err != nil {
+			return err
+		}
+	}
+	if w.sensors {
+		if err := reportAll(w, "sensor", oldSensors, state.Sensors, func(s hue.Sensor) (string, string) { return s.ID, s.Name }, sensorChanges); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reportAll reports the changes from the resources in old to those in
+// cur, including resources that were added or removed.
+func reportAll[T any](w *watcher, resource string, old map[string]T, cur []T, describe func(T) (id, name string), changes func(old, cur T) []change) error {
+	seen := make(map[string]bool, len(cur))
+	for _, item := range cur {
+		id, name := describe(item)
+		seen[id] = true
+
+		found := []change{{field: "added"}}
+		if before, ok := old[id]; ok {
+			found = changes(before, item)
+		}
+		if err := w.report(resource, id, name, found); err != nil {
+			return err
+		}
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(old)) {
+		if !seen[id] {
+			_, name := describe(old[id])
+			if err := w.report(resource, id, name, []change{{field: "removed"}}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// report writes one line per change.
+func (w *watcher) report(resource, id, name string, changes []change) error {
+	now := time.Now()
+	for _, c := range changes {
+		record := changeRecord{
+			Time:     now.Format(time.RFC3339Nano),
+			Resource: resource,
+			ID:       id,
+			Name:     name,
+			Field:    c.field,
+			Old:      c.old,
+			New:      c.new,
+		}
+		err := w.out.write(record, func(out io.Writer) {
+			if c.field == "added" || c.field == "removed" {
+				_, _ = fmt.Fprintf(out, "%s  %s %s (%s) %s\n", now.Format("15:04:05.000"), resource, id, name, c.field)
+				return
+			}
+			_, _ = fmt.Fprintf(out, "%s  %s %s (%s)  %s: %v → %v\n", now.Format("15:04:05.000"), resource, id, name, c.field, c.old, c.new)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func lightChanges(old, cur hue.Light) []change {
+	return changedFields(
+		change{"name", old.Name, cur.Name},
+		change{"on", old.On, cur.On},
+		change{"brightness", old.Brightness, cur.Brightness},
+		change{"xy", old.XY, cur.XY},
+		change{"color_temp", old.ColorTemp, cur.ColorTemp},
+	)
+}
+
+func groupChanges(old, cur hue.Group) []change {
+	return changedFields(
+		change{"name", old.Name, cur.Name},
+		change{"any_on", old.AnyOn, cur.AnyOn},
+		change{"all_on", old.AllOn, cur.AllOn},
+	)
+}
+
+// sensorChanges compares the name and every state value, old or new, in
+// key order.
+func sensorChanges(old, cur hue.Sensor) []change {
+	candidates := []change{{"name", old.Name, cur.Name}}
+	keys := slices.Concat(slices.Collect(maps.Keys(old.RawState)), slices.Collect(maps.Keys(cur.RawState)))
+	slices.Sort(keys)
+	for _, key := range slices.Compact(keys) {
+		candidates = append(candidates, change{key, old.RawState[key], cur.RawState[key]})
+	}
+	return changedFields(candidates...)
+}
+
+// changedFields returns the candidates whose old and new values differ.
+func changedFields(candidates ...change) []change {
+	var changes []change
+	for _, c := range candidates {
+		if !reflect.DeepEqual(c.old, c.new) {
+			changes = append(changes, c)
+		}
+	}
+	return changes
+}
+
+func init() {
+	WatchCmd.Flags().BoolVar(&flagWatchLights, "lights", false, "Watch lights")
+	WatchCmd.Flags().BoolVar(&flagWatchGroups, "groups", false, "Watch groups")
+	WatchCmd.Flags().BoolVar(&flagWatchSensors, "sensors", false, "Watch sensors")
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/LarsEckart/huey/hue"
+	"github.com/spf13/cobra"
+)
+
+func TestLightChanges(t *testing.T) {
+	old := hue.Light{ID: "1", Name: "Desk", On: true, Brightness: 200}
+	cur := hue.Light{ID: "1", Name: "Desk", On: false, Brightness: 200, XY: [2]float64{0.4, 0.4}}
+
+	changes := lightChanges(old, cur)
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got %+v", changes)
+	}
+	if changes[0] != (change{"on", true, false}) {
+		t.Errorf("unexpected change: %+v", changes[0])
+	}
+	if changes[1].field != "xy" {
+		t.Errorf("expected xy change, got %+v", changes[1])
+	}
+}
+
+func TestSensorChanges(t *testing.T) {
+	old := hue.Sensor{ID: "5", Name: "Hall motion", RawState: map[string]any{"presence": false, "battery": 90.0, "dark": true, "reachable": true}}
+	cur := hue.Sensor{ID: "5", Name: "Hall motion", RawState: map[string]any{"presence": true, "lastupdated": "2026-10-17T10:00:00"}}
+
+	var fields []string
+	for _, c := range sensorChanges(old, cur) {
+		fields = append(fields, c.field)
+	}
+	if got := strings.Join(fields, ","); got != "battery,dark,lastupdated,presence,reachable" {
+		t.Errorf("changed fields = %s", got)
+	}
+}
+
+func TestRecordStream_CSVHeaderOnce(t *testing.T) {
+	original := outputFormat
+	outputFormat = outputCSV
+	t.Cleanup(func() { outputFormat = original })
+
+	var buf bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&buf)
+	stream := &recordStream{cmd: cmd}
+
+	for _, value := range []bool{true, false} {
+		record := changeRecord{Time: "t", Resource: "light", ID: "1", Name: "Desk", Field: "on", Old: !value, New: value}
+		if err := stream.write(record, nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	want := "time,resource,id,name,field,old,new\n" +
+		"t,light,1,Desk,on,false,true\n" +
+		"t,light,1,Desk,on,true,false\n"
+	if buf.String() != want {
+		t.Errorf("unexpected CSV:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
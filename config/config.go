@@ -7,10 +7,37 @@ import (
 	"path/filepath"
 )
 
+// APIv2 is the Config.API value that selects the CLIP v2 API.
+const APIv2 = "v2"
+
 // Config holds the Hue bridge connection settings.
 type Config struct {
 	BridgeIP string `json:"bridge_ip"`
-	Username string `json:"username"`
+	BridgeID string `json:"bridge_id,omitempty"` // used to find the bridge again if its IP changes
+	Username string `json:"username"`            // also the API v2 application key
+
+	// ClientKey is issued alongside the username; the bridge only shows it
+	// once, at registration.
+	ClientKey string `json:"client_key,omitempty"`
+
+	// API selects the bridge API huey lights and huey groups read from:
+	// "v2" for CLIP v2 over HTTPS, anything else for the v1 API. Their
+	// IDs and output are the v1 ones either way.
+	API string `json:"api,omitempty"`
+
+	// HTTPS sends v1 API requests over HTTPS. CertFingerprint pins the
+	// certificate of bridges that aren't verifiable through the Signify CA,
+	// or is hue.CAVerified for bridges that are.
+	HTTPS           bool   `json:"https,omitempty"`
+	CertFingerprint string `json:"cert_fingerprint,omitempty"`
+
+	// Optional connection tuning; zero values mean the client defaults.
+	TimeoutMS int  `json:"timeout_ms,omitempty"` // per-request timeout in milliseconds
+	Retries   *int `json:"retries,omitempty"`    // retries for failed idempotent requests, 0 disables
+
+	// TransitionMS is the default fade for state changes in milliseconds,
+	// 0 for instant. Unset means the bridge's default of 400ms.
+	TransitionMS *int `json:"transition_ms,omitempty"`
 }
 
 // Path returns the config file path: ~/.config/huey/config.json
@@ -65,6 +92,18 @@ func (config *Config) Save() error {
 	return os.WriteFile(path, data, 0600)
 }
 
+// SetCredentials stores newly issued credentials. A key from an earlier
+// registration is dropped even if the bridge did not issue a new one.
+func (config *Config) SetCredentials(username, clientKey string) {
+	config.Username = username
+	config.ClientKey = clientKey
+}
+
+// UseV2 reports whether the CLIP v2 API is selected.
+func (config *Config) UseV2() bool {
+	return config.API == APIv2
+}
+
 // IsConfigured returns true if both bridge IP and username are set.
 func (config *Config) IsConfigured() bool {
 	return config.BridgeIP != "" && config.Username != ""
@@ -57,3 +57,41 @@ func TestLoadSave(t *testing.T) {
 		t.Error("Filled config should be configured")
 	}
 }
+
+func TestLoad_WithoutBridgeID(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	// Configs written before the bridge ID was stored only have IP and username.
+	dir := filepath.Join(tmpDir, ".config", "huey")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	legacy := `{"bridge_ip":"192.168.1.100","username":"testuser123"}`
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(legacy), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !config.IsConfigured() {
+		t.Error("Legacy config should be configured")
+	}
+	if config.BridgeID != "" {
+		t.Errorf("Expected empty BridgeID, got %q", config.BridgeID)
+	}
+
+	config.BridgeID = "001788fffe23bfc2"
+	if err := config.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	loaded, err := Load()
+	if err != nil {
+		t.Fatalf("Load after save failed: %v", err)
+	}
+	if loaded.BridgeID != "001788fffe23bfc2" {
+		t.Errorf("BridgeID mismatch: %s", loaded.BridgeID)
+	}
+}
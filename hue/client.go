@@ -5,63 +5,133 @@ import (
 	"cmp"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
 	"slices"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
 // Client handles HTTP communication with a Hue bridge.
 type Client struct {
+	mu         sync.Mutex // guards bridgeIP, which changes on relocation
 	bridgeIP   string
 	username   string
+	scheme     string      // "http", or "https" with WithHTTPS
+	certPolicy *CertPolicy // set with WithHTTPS or WithCertPolicy
 	httpClient *http.Client
+	timeout    time.Duration
+	retry      RetryPolicy
+	scheduler  *scheduler
+	relocation *relocation
+	transition *int // deciseconds, set with WithTransition
 }
 
 // NewClient creates a Client for the given bridge IP and username.
 // Username can be empty for registration calls.
-func NewClient(bridgeIP, username string) *Client {
-	transport := &http.Transport{
-		DialContext: (&net.Dialer{
-			Timeout: 500 * time.Millisecond,
-		}).DialContext,
-	}
-	return &Client{
-		bridgeIP: bridgeIP,
-		username: username,
-		httpClient: &http.Client{
-			Timeout:   500 * time.Millisecond,
+func NewClient(bridgeIP, username string, opts ...Option) *Client {
+	c := &Client{
+		bridgeIP:  bridgeIP,
+		username:  username,
+		scheme:    "http",
+		timeout:   DefaultTimeout,
+		retry:     DefaultRetryPolicy,
+		scheduler: newScheduler(DefaultLightCommandsPerSecond, DefaultGroupCommandsPerSecond),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.httpClient == nil {
+		transport := &http.Transport{
+			DialContext: (&net.Dialer{
+				Timeout: c.timeout,
+			}).DialContext,
+		}
+		if c.certPolicy != nil {
+			transport.TLSClientConfig = c.certPolicy.TLSConfig()
+		}
+		c.httpClient = &http.Client{
+			Timeout:   c.timeout,
 			Transport: transport,
-		},
+		}
 	}
+	return c
+}
+
+// BridgeIP returns the address the client currently talks to.
+func (c *Client) BridgeIP() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.bridgeIP
 }
 
 // baseURL returns the API base URL.
 func (c *Client) baseURL() string {
-	return fmt.Sprintf("http://%s/api", c.bridgeIP)
+	return fmt.Sprintf("%s://%s/api", c.scheme, c.BridgeIP())
 }
 
-// doWithRetry performs an HTTP request with one retry on failure.
+// doWithRetry performs an HTTP request, retrying failures as allowed by
+// the client's retry policy. If the bridge still can't be reached and
+// relocation is enabled, the bridge is looked up by ID and the request
+// sent to its new address. Nothing is retried once the request's context
+// is done, or when the bridge's certificate is not trusted.
 func (c *Client) doWithRetry(req *http.Request) (*http.Response, error) {
-	resp, err := c.httpClient.Do(req)
-	if err == nil {
-		return resp, nil
+	ctx := req.Context()
+	retry := c.retry.allows(req.Method)
+
+	attemptReq := req
+	for attempt := 0; ; attempt++ {
+		resp, err := c.httpClient.Do(attemptReq)
+		var certErr *CertificateError
+		if err == nil || ctx.Err() != nil || !retry || errors.As(err, &certErr) {
+			return resp, err
+		}
+
+		if attempt >= c.retry.MaxRetries {
+			if c.relocation == nil {
+				return nil, err
+			}
+			return c.doRelocated(req, err)
+		}
+
+		if err := sleep(ctx, c.retry.delay(attempt)); err != nil {
+			return nil, err
+		}
+
+		attemptReq, err = cloneRequestForRetry(req)
+		if err != nil {
+			return nil, fmt.Errorf("clone request for retry: %w", err)
+		}
 	}
+}
 
-	retryReq, retryErr := cloneRequestForRetry(req)
-	if retryErr != nil {
-		return nil, fmt.Errorf("clone request for retry: %w", retryErr)
+// doRelocated finds the bridge after req failed with err and sends req to
+// the bridge's new address.
+func (c *Client) doRelocated(req *http.Request, err error) (*http.Response, error) {
+	newIP, relocateErr := c.relocate(req.Context(), req.URL.Host)
+	if relocateErr != nil {
+		return nil, fmt.Errorf("%w (rediscovery: %v)", err, relocateErr)
 	}
 
-	return c.httpClient.Do(retryReq)
+	movedReq, cloneErr := cloneRequestForRetry(req)
+	if cloneErr != nil {
+		return nil, fmt.Errorf("clone request for retry: %w", cloneErr)
+	}
+	movedReq.URL.Host = newIP
+	movedReq.Host = newIP
+
+	return c.httpClient.Do(movedReq)
 }
 
-// getWithRetry performs a GET request with one retry on failure.
-func (c *Client) getWithRetry(url string) (*http.Response, error) {
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, url, nil)
+// getWithRetry performs a GET request, retrying on failure.
+func (c *Client) getWithRetry(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
 	}
@@ -69,9 +139,10 @@ func (c *Client) getWithRetry(url string) (*http.Response, error) {
 	return c.doWithRetry(req)
 }
 
-// postWithRetry performs a POST request with one retry on failure.
-func (c *Client) postWithRetry(url string, contentType string, body []byte) (*http.Response, error) {
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, url, bytes.NewReader(body))
+// postWithRetry performs a POST request. POST requests create resources,
+// so they are only retried if the retry policy allows it.
+func (c *Client) postWithRetry(ctx context.Context, url string, contentType string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
 	}
@@ -94,46 +165,139 @@ func cloneRequestForRetry(req *http.Request) (*http.Request, error) {
 	return clone, nil
 }
 
-// Register creates a new username on the bridge.
+// Credentials are what the bridge issues when huey registers.
+type Credentials struct {
+	Username  string // v1 username, also the v2 hue-application-key
+	ClientKey string // 32 hex digits, used for Entertainment streaming
+}
+
+// Register creates a new username on the bridge, along with a client key.
 // Requires the bridge link button to be pressed first.
 // deviceType format: "app_name#device_name" (e.g., "huey#macbook")
-func (c *Client) Register(deviceType string) (string, error) {
-	body := map[string]string{"devicetype": deviceType}
+func (c *Client) Register(ctx context.Context, deviceType string) (*Credentials, error) {
+	body := map[string]any{"devicetype": deviceType, "generateclientkey": true}
 	jsonBody, err := json.Marshal(body)
 	if err != nil {
-		return "", fmt.Errorf("marshal request: %w", err)
+		return nil, fmt.Errorf("marshal request: %w", err)
 	}
 
-	resp, err := c.postWithRetry(c.baseURL(), "application/json", jsonBody)
+	resp, err := c.postWithRetry(ctx, c.baseURL(), "application/json", jsonBody)
 	if err != nil {
-		return "", fmt.Errorf("post request: %w", err)
+		return nil, fmt.Errorf("post request: %w", err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("read response: %w", err)
+		return nil, fmt.Errorf("read response: %w", err)
 	}
 
 	results, err := parseBridgeResults(data)
 	if err != nil {
-		return "", fmt.Errorf("unmarshal response: %w", err)
+		return nil, fmt.Errorf("unmarshal response: %w", err)
 	}
 
 	if len(results) == 0 {
-		return "", fmt.Errorf("empty response from bridge")
+		return nil, fmt.Errorf("empty response from bridge")
 	}
 
-	if err := bridgeErrorFromResult(results[0]); err != nil {
-		return "", err
+	if err := bridgeErrors(results); err != nil {
+		return nil, err
 	}
 
 	var success registerSuccessResponse
 	if err := json.Unmarshal(results[0].Success, &success); err == nil && success.Username != "" {
-		return success.Username, nil
+		return &Credentials{Username: success.Username, ClientKey: success.ClientKey}, nil
 	}
 
-	return "", fmt.Errorf("unexpected response format: %s", string(data))
+	return nil, fmt.Errorf("unexpected response format: %s", string(data))
+}
+
+// BridgeConfig holds the public bridge details served without authentication.
+type BridgeConfig struct {
+	Name       string `json:"name"`
+	BridgeID   string `json:"bridgeid"` // 16 hex digits, e.g. "001788fffe23bfc2"
+	ModelID    string `json:"modelid"`
+	APIVersion string `json:"apiversion"`
+	SWVersion  string `json:"swversion"`
+	MAC        string `json:"mac"`
+
+	// Only reported to paired clients, e.g. in GetFullState.
+	TimeZone  string `json:"timezone"`  // IANA name, e.g. "Europe/Amsterdam", or "none"
+	LocalTime string `json:"localtime"` // bridge's wall clock, e.g. "2026-10-17T14:00:00"
+	UTC       string `json:"UTC"`       // same moment in UTC
+}
+
+// Location returns the bridge's time zone, which schedules and rules use.
+// If the zone isn't known on this computer, it is a fixed zone with the
+// bridge's current offset from UTC; if the bridge reports no time,
+// time.Local.
+func (b BridgeConfig) Location() *time.Location {
+	if b.TimeZone != "" && b.TimeZone != "none" {
+		if loc, err := time.LoadLocation(b.TimeZone); err == nil {
+			return loc
+		}
+	}
+	local, err1 := time.Parse(absoluteLayout, b.LocalTime)
+	utc, err2 := time.Parse(absoluteLayout, b.UTC)
+	if err1 != nil || err2 != nil {
+		return time.Local
+	}
+	offset := local.Sub(utc).Round(15 * time.Minute)
+	return time.FixedZone("", int(offset.Seconds()))
+}
+
+// GetBridgeConfig returns the bridge's public configuration.
+// It does not require a username, so it can identify a bridge before pairing.
+func (c *Client) GetBridgeConfig(ctx context.Context) (*BridgeConfig, error) {
+	url := fmt.Sprintf("%s/config", c.baseURL())
+	resp, err := c.getWithRetry(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("get request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+
+	if err := c.checkError(data); err != nil {
+		return nil, err
+	}
+
+	var config BridgeConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("unmarshal response: %w", err)
+	}
+
+	if config.BridgeID == "" {
+		return nil, fmt.Errorf("response is not from a Hue bridge: missing bridge ID")
+	}
+	config.BridgeID = strings.ToLower(config.BridgeID)
+
+	return &config, nil
+}
+
+// CheckAuth verifies that the bridge accepts the client's username.
+// It returns an error matching ErrUnauthorized if the username is unknown,
+// for example because it was removed in the Hue app.
+func (c *Client) CheckAuth(ctx context.Context) error {
+	// Group 0 always exists and contains all lights, so this is cheap and
+	// only fails on bad credentials.
+	url := fmt.Sprintf("%s/%s/groups/0", c.baseURL(), c.username)
+	resp, err := c.getWithRetry(ctx, url)
+	if err != nil {
+		return fmt.Errorf("get request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+
+	return c.checkError(data)
 }
 
 // Light represents a Hue light.
@@ -141,9 +305,13 @@ type Light struct {
 	ID         string
 	Name       string
 	On         bool
-	Brightness int // 0-254
-	Hue        int // 0-65535
-	Saturation int // 0-254
+	Brightness int        // 0-254
+	Hue        int        // 0-65535
+	Saturation int        // 0-254
+	XY         [2]float64 // CIE 1931 color space
+	ColorTemp  int        // mired, 153-500
+	ColorMode  string     // "hs", "xy", "ct", or empty for lights without color
+	GamutType  string     // "A", "B", "C", or empty for lights without color
 	Type       string
 }
 
@@ -152,11 +320,35 @@ type lightResponse struct {
 	Name  string `json:"name"`
 	Type  string `json:"type"`
 	State struct {
-		On         bool `json:"on"`
-		Brightness int  `json:"bri"`
-		Hue        int  `json:"hue"`
-		Saturation int  `json:"sat"`
+		On         bool       `json:"on"`
+		Brightness int        `json:"bri"`
+		Hue        int        `json:"hue"`
+		Saturation int        `json:"sat"`
+		XY         [2]float64 `json:"xy"`
+		ColorTemp  int        `json:"ct"`
+		ColorMode  string     `json:"colormode"`
 	} `json:"state"`
+	Capabilities struct {
+		Control struct {
+			ColorGamutType string `json:"colorgamuttype"`
+		} `json:"control"`
+	} `json:"capabilities"`
+}
+
+func (lr lightResponse) toLight(id string) Light {
+	return Light{
+		ID:         id,
+		Name:       lr.Name,
+		On:         lr.State.On,
+		Brightness: lr.State.Brightness,
+		Hue:        lr.State.Hue,
+		Saturation: lr.State.Saturation,
+		XY:         lr.State.XY,
+		ColorTemp:  lr.State.ColorTemp,
+		ColorMode:  lr.State.ColorMode,
+		GamutType:  lr.Capabilities.Control.ColorGamutType,
+		Type:       lr.Type,
+	}
 }
 
 type bridgeErrorResponse struct {
@@ -171,7 +363,8 @@ type bridgeResult struct {
 }
 
 type registerSuccessResponse struct {
-	Username string `json:"username"`
+	Username  string `json:"username"`
+	ClientKey string `json:"clientkey"`
 }
 
 type createResourceSuccessResponse struct {
@@ -186,13 +379,6 @@ func parseBridgeResults(data []byte) ([]bridgeResult, error) {
 	return results, nil
 }
 
-func bridgeErrorFromResult(result bridgeResult) error {
-	if result.Error == nil {
-		return nil
-	}
-	return fmt.Errorf("bridge error: %s", result.Error.Description)
-}
-
 // compareNumericIDs compares string IDs numerically when possible.
 // If one or both IDs are not numeric, numeric IDs come first and non-numeric
 // IDs are compared lexicographically.
@@ -213,9 +399,9 @@ func compareNumericIDs(a, b string) int {
 }
 
 // GetLights returns all lights from the bridge.
-func (c *Client) GetLights() ([]Light, error) {
+func (c *Client) GetLights(ctx context.Context) ([]Light, error) {
 	url := fmt.Sprintf("%s/%s/lights", c.baseURL(), c.username)
-	resp, err := c.getWithRetry(url)
+	resp, err := c.getWithRetry(ctx, url)
 	if err != nil {
 		return nil, fmt.Errorf("get request: %w", err)
 	}
@@ -237,17 +423,15 @@ func (c *Client) GetLights() ([]Light, error) {
 		return nil, fmt.Errorf("unmarshal response: %w", err)
 	}
 
+	return lightsFromResponse(lightsMap), nil
+}
+
+// lightsFromResponse converts the bridge's ID -> light map to a list
+// sorted by ID.
+func lightsFromResponse(lightsMap map[string]lightResponse) []Light {
 	lights := make([]Light, 0, len(lightsMap))
 	for id, lr := range lightsMap {
-		lights = append(lights, Light{
-			ID:         id,
-			Name:       lr.Name,
-			On:         lr.State.On,
-			Brightness: lr.State.Brightness,
-			Hue:        lr.State.Hue,
-			Saturation: lr.State.Saturation,
-			Type:       lr.Type,
-		})
+		lights = append(lights, lr.toLight(id))
 	}
 
 	// Sort by ID numerically for natural order.
@@ -255,13 +439,13 @@ func (c *Client) GetLights() ([]Light, error) {
 		return compareNumericIDs(a.ID, b.ID)
 	})
 
-	return lights, nil
+	return lights
 }
 
 // GetLight returns a single light by ID.
-func (c *Client) GetLight(id string) (*Light, error) {
+func (c *Client) GetLight(ctx context.Context, id string) (*Light, error) {
 	url := fmt.Sprintf("%s/%s/lights/%s", c.baseURL(), c.username, id)
-	resp, err := c.getWithRetry(url)
+	resp, err := c.getWithRetry(ctx, url)
 	if err != nil {
 		return nil, fmt.Errorf("get request: %w", err)
 	}
@@ -281,27 +465,85 @@ func (c *Client) GetLight(id string) (*Light, error) {
 		return nil, fmt.Errorf("unmarshal response: %w", err)
 	}
 
-	return &Light{
-		ID:         id,
-		Name:       lr.Name,
-		On:         lr.State.On,
-		Brightness: lr.State.Brightness,
-		Hue:        lr.State.Hue,
-		Saturation: lr.State.Saturation,
-		Type:       lr.Type,
-	}, nil
+	light := lr.toLight(id)
+	return &light, nil
+}
+
+// Value ranges accepted by the bridge for light state attributes.
+const (
+	MinBrightness = 1
+	MaxBrightness = 254
+	MaxHue        = 65535
+	MaxSaturation = 254
+	MinColorTemp  = 153 // mired, about 6500K
+	MaxColorTemp  = 500 // mired, 2000K
+
+	// Limits for the *_inc fields.
+	MaxBrightnessInc = 254
+	MaxHueInc        = 65534
+	MaxXYInc         = 0.5
+)
+
+// Alerts make lights blink, e.g. to find one among many.
+const (
+	AlertSelect     = "select"  // a single blink
+	AlertLongSelect = "lselect" // blink for 15 seconds
+	AlertNone       = "none"    // stop blinking
+)
+
+// Effects are dynamic light effects.
+const (
+	EffectColorLoop = "colorloop" // cycle through all hues
+	EffectNone      = "none"
+)
+
+// MaxTransition is the longest transition the bridge accepts.
+const MaxTransition = 65535 * 100 * time.Millisecond
+
+// TransitionTime converts d to the bridge's transition time unit,
+// deciseconds, rounded to the nearest 100ms. Without a transition time
+// the bridge fades over 400ms; zero changes the state instantly.
+func TransitionTime(d time.Duration) *int {
+	ds := int(d.Round(100*time.Millisecond) / (100 * time.Millisecond))
+	return &ds
 }
 
 // LightState represents the state to set on a light.
 type LightState struct {
-	On         *bool `json:"on,omitempty"`
-	Brightness *int  `json:"bri,omitempty"`
-	Hue        *int  `json:"hue,omitempty"`
-	Saturation *int  `json:"sat,omitempty"`
+	On         *bool       `json:"on,omitempty"`
+	Brightness *int        `json:"bri,omitempty"`
+	Hue        *int        `json:"hue,omitempty"`
+	Saturation *int        `json:"sat,omitempty"`
+	ColorTemp  *int        `json:"ct,omitempty"` // mired
+	XY         *[2]float64 `json:"xy,omitempty"` // CIE 1931 color space
+
+	// Relative changes, added to the light's current value by the bridge
+	// and clamped to the valid range; hue wraps around.
+	BrightnessInc *int        `json:"bri_inc,omitempty"` // -254 to 254
+	SaturationInc *int        `json:"sat_inc,omitempty"` // -254 to 254
+	HueInc        *int        `json:"hue_inc,omitempty"` // -65534 to 65534
+	ColorTempInc  *int        `json:"ct_inc,omitempty"`  // -65534 to 65534
+	XYInc         *[2]float64 `json:"xy_inc,omitempty"`  // -0.5 to 0.5
+
+	Alert  string `json:"alert,omitempty"`  // AlertSelect, AlertLongSelect or AlertNone
+	Effect string `json:"effect,omitempty"` // EffectColorLoop or EffectNone
+
+	TransitionTime *int `json:"transitiontime,omitempty"` // deciseconds
+}
+
+// SetLightState changes the state of a light. Commands are paced to what
+// the bridge can handle; if an earlier update to the same light is still
+// waiting to be sent, the two are merged and sent as one.
+func (c *Client) SetLightState(ctx context.Context, id string, state LightState) error {
+	if state.TransitionTime == nil {
+		state.TransitionTime = c.transition
+	}
+	return c.scheduler.light(ctx, id, state, func(ctx context.Context, state LightState) error {
+		return c.putLightState(ctx, id, state)
+	})
 }
 
-// SetLightState changes the state of a light.
-func (c *Client) SetLightState(id string, state LightState) error {
+func (c *Client) putLightState(ctx context.Context, id string, state LightState) error {
 	url := fmt.Sprintf("%s/%s/lights/%s/state", c.baseURL(), c.username, id)
 
 	jsonBody, err := json.Marshal(state)
@@ -309,7 +551,7 @@ func (c *Client) SetLightState(id string, state LightState) error {
 		return fmt.Errorf("marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodPut, url, bytes.NewReader(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewReader(jsonBody))
 	if err != nil {
 		return fmt.Errorf("create request: %w", err)
 	}
@@ -330,7 +572,7 @@ func (c *Client) SetLightState(id string, state LightState) error {
 }
 
 // RenameLight changes the name of a light.
-func (c *Client) RenameLight(id string, name string) error {
+func (c *Client) RenameLight(ctx context.Context, id string, name string) error {
 	url := fmt.Sprintf("%s/%s/lights/%s", c.baseURL(), c.username, id)
 
 	body := map[string]string{"name": name}
@@ -339,7 +581,7 @@ func (c *Client) RenameLight(id string, name string) error {
 		return fmt.Errorf("marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodPut, url, bytes.NewReader(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewReader(jsonBody))
 	if err != nil {
 		return fmt.Errorf("create request: %w", err)
 	}
@@ -359,7 +601,7 @@ func (c *Client) RenameLight(id string, name string) error {
 	return c.checkError(data)
 }
 
-// checkError checks if the response contains an error.
+// checkError returns the errors reported in a response, if any.
 // Bridge errors come as: [{"error":{"type":1,"address":"/...","description":"..."}}]
 func (c *Client) checkError(data []byte) error {
 	results, err := parseBridgeResults(data)
@@ -368,11 +610,7 @@ func (c *Client) checkError(data []byte) error {
 		return nil
 	}
 
-	if len(results) == 0 {
-		return nil
-	}
-
-	return bridgeErrorFromResult(results[0])
+	return bridgeErrors(results)
 }
 
 // Group represents a Hue group (room, zone, etc.).
@@ -397,9 +635,9 @@ type groupResponse struct {
 }
 
 // GetGroups returns all groups from the bridge.
-func (c *Client) GetGroups() ([]Group, error) {
+func (c *Client) GetGroups(ctx context.Context) ([]Group, error) {
 	url := fmt.Sprintf("%s/%s/groups", c.baseURL(), c.username)
-	resp, err := c.getWithRetry(url)
+	resp, err := c.getWithRetry(ctx, url)
 	if err != nil {
 		return nil, fmt.Errorf("get request: %w", err)
 	}
@@ -420,6 +658,40 @@ func (c *Client) GetGroups() ([]Group, error) {
 		return nil, fmt.Errorf("unmarshal response: %w", err)
 	}
 
+	return groupsFromResponse(groupsMap), nil
+}
+
+// GetGroup returns one group. Unlike GetGroups, it also finds group 0,
+// which the bridge keeps with all lights in it.
+func (c *Client) GetGroup(ctx context.Context, id string) (*Group, error) {
+	url := fmt.Sprintf("%s/%s/groups/%s", c.baseURL(), c.username, id)
+	resp, err := c.getWithRetry(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("get request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+
+	if err := c.checkError(data); err != nil {
+		return nil, err
+	}
+
+	var gr groupResponse
+	if err := json.Unmarshal(data, &gr); err != nil {
+		return nil, fmt.Errorf("unmarshal response: %w", err)
+	}
+
+	groups := groupsFromResponse(map[string]groupResponse{id: gr})
+	return &groups[0], nil
+}
+
+// groupsFromResponse converts the bridge's ID -> group map to a list
+// sorted by ID.
+func groupsFromResponse(groupsMap map[string]groupResponse) []Group {
 	groups := make([]Group, 0, len(groupsMap))
 	for id, gr := range groupsMap {
 		// Sort light IDs numerically within each group.
@@ -441,24 +713,56 @@ func (c *Client) GetGroups() ([]Group, error) {
 		return compareNumericIDs(a.ID, b.ID)
 	})
 
-	return groups, nil
+	return groups
 }
 
 // GroupAction represents the action to set on a group.
+// It accepts the same attributes as LightState and applies them to every
+// light in the group in a single call.
 type GroupAction struct {
-	On *bool `json:"on,omitempty"`
+	On         *bool       `json:"on,omitempty"`
+	Brightness *int        `json:"bri,omitempty"`
+	Hue        *int        `json:"hue,omitempty"`
+	Saturation *int        `json:"sat,omitempty"`
+	ColorTemp  *int        `json:"ct,omitempty"` // mired
+	XY         *[2]float64 `json:"xy,omitempty"` // CIE 1931 color space
+	Scene      string      `json:"scene,omitempty"`
+
+	// Relative changes, as in LightState.
+	BrightnessInc *int        `json:"bri_inc,omitempty"`
+	SaturationInc *int        `json:"sat_inc,omitempty"`
+	HueInc        *int        `json:"hue_inc,omitempty"`
+	ColorTempInc  *int        `json:"ct_inc,omitempty"`
+	XYInc         *[2]float64 `json:"xy_inc,omitempty"`
+
+	Alert  string `json:"alert,omitempty"`
+	Effect string `json:"effect,omitempty"`
+
+	TransitionTime *int `json:"transitiontime,omitempty"` // deciseconds
 }
 
-// SetGroupState changes the state of all lights in a group.
-func (c *Client) SetGroupState(id string, action GroupAction) error {
-	url := fmt.Sprintf("%s/%s/groups/%s/action", c.baseURL(), c.username, id)
-
+// SetGroupState changes the state of all lights in a group. Group
+// commands are expensive for the bridge and paced more strictly than
+// light commands.
+func (c *Client) SetGroupState(ctx context.Context, id string, action GroupAction) error {
+	if action.TransitionTime == nil {
+		action.TransitionTime = c.transition
+	}
 	jsonBody, err := json.Marshal(action)
 	if err != nil {
 		return fmt.Errorf("marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodPut, url, bytes.NewReader(jsonBody))
+	return c.scheduler.group(ctx, func(ctx context.Context) error {
+		return c.putGroupAction(ctx, id, jsonBody)
+	})
+}
+
+// putGroupAction sends an encoded action to a group.
+func (c *Client) putGroupAction(ctx context.Context, id string, jsonBody []byte) error {
+	url := fmt.Sprintf("%s/%s/groups/%s/action", c.baseURL(), c.username, id)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewReader(jsonBody))
 	if err != nil {
 		return fmt.Errorf("create request: %w", err)
 	}
@@ -479,7 +783,7 @@ func (c *Client) SetGroupState(id string, action GroupAction) error {
 }
 
 // RenameGroup changes the name of a group.
-func (c *Client) RenameGroup(id string, name string) error {
+func (c *Client) RenameGroup(ctx context.Context, id string, name string) error {
 	url := fmt.Sprintf("%s/%s/groups/%s", c.baseURL(), c.username, id)
 
 	body := map[string]string{"name": name}
@@ -488,7 +792,7 @@ func (c *Client) RenameGroup(id string, name string) error {
 		return fmt.Errorf("marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodPut, url, bytes.NewReader(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewReader(jsonBody))
 	if err != nil {
 		return fmt.Errorf("create request: %w", err)
 	}
@@ -509,10 +813,10 @@ func (c *Client) RenameGroup(id string, name string) error {
 }
 
 // DeleteGroup removes a group from the bridge.
-func (c *Client) DeleteGroup(id string) error {
+func (c *Client) DeleteGroup(ctx context.Context, id string) error {
 	url := fmt.Sprintf("%s/%s/groups/%s", c.baseURL(), c.username, id)
 
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodDelete, url, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
 	if err != nil {
 		return fmt.Errorf("create request: %w", err)
 	}
@@ -533,7 +837,7 @@ func (c *Client) DeleteGroup(id string) error {
 
 // CreateGroup creates a new group on the bridge.
 // groupType should be "Room" or "Zone".
-func (c *Client) CreateGroup(name, groupType string, lightIDs []string) (string, error) {
+func (c *Client) CreateGroup(ctx context.Context, name, groupType string, lightIDs []string) (string, error) {
 	url := fmt.Sprintf("%s/%s/groups", c.baseURL(), c.username)
 
 	body := map[string]any{
@@ -546,7 +850,7 @@ func (c *Client) CreateGroup(name, groupType string, lightIDs []string) (string,
 		return "", fmt.Errorf("marshal request: %w", err)
 	}
 
-	resp, err := c.postWithRetry(url, "application/json", jsonBody)
+	resp, err := c.postWithRetry(ctx, url, "application/json", jsonBody)
 	if err != nil {
 		return "", fmt.Errorf("post request: %w", err)
 	}
@@ -566,7 +870,7 @@ func (c *Client) CreateGroup(name, groupType string, lightIDs []string) (string,
 		return "", fmt.Errorf("empty response from bridge")
 	}
 
-	if err := bridgeErrorFromResult(results[0]); err != nil {
+	if err := bridgeErrors(results); err != nil {
 		return "", err
 	}
 
@@ -585,14 +889,20 @@ type Scene struct {
 	Group  string   // Group ID this scene belongs to
 	Type   string   // "LightScene", "GroupScene", etc.
 	Lights []string // Light IDs in this scene
+
+	// LightStates holds the state the scene sets each light to, by light
+	// ID. The bridge only returns it for a single scene, so it is nil in
+	// lists of scenes; see GetScene.
+	LightStates map[string]LightState
 }
 
 // sceneResponse matches the JSON structure from the bridge for a single scene.
 type sceneResponse struct {
-	Name   string   `json:"name"`
-	Group  string   `json:"group"`
-	Type   string   `json:"type"`
-	Lights []string `json:"lights"`
+	Name        string                `json:"name"`
+	Group       string                `json:"group"`
+	Type        string                `json:"type"`
+	Lights      []string              `json:"lights"`
+	LightStates map[string]LightState `json:"lightstates"`
 }
 
 func sortScenes(scenes []Scene) {
@@ -609,9 +919,9 @@ func sortScenes(scenes []Scene) {
 }
 
 // GetScenes returns all scenes from the bridge.
-func (c *Client) GetScenes() ([]Scene, error) {
+func (c *Client) GetScenes(ctx context.Context) ([]Scene, error) {
 	url := fmt.Sprintf("%s/%s/scenes", c.baseURL(), c.username)
-	resp, err := c.getWithRetry(url)
+	resp, err := c.getWithRetry(ctx, url)
 	if err != nil {
 		return nil, fmt.Errorf("get request: %w", err)
 	}
@@ -632,6 +942,11 @@ func (c *Client) GetScenes() ([]Scene, error) {
 		return nil, fmt.Errorf("unmarshal response: %w", err)
 	}
 
+	return scenesFromResponse(scenesMap), nil
+}
+
+// scenesFromResponse converts the bridge's ID -> scene map to a sorted list.
+func scenesFromResponse(scenesMap map[string]sceneResponse) []Scene {
 	scenes := make([]Scene, 0, len(scenesMap))
 	for id, sr := range scenesMap {
 		scenes = append(scenes, Scene{
@@ -645,13 +960,13 @@ func (c *Client) GetScenes() ([]Scene, error) {
 
 	sortScenes(scenes)
 
-	return scenes, nil
+	return scenes
 }
 
 // GetScene returns a single scene by ID.
-func (c *Client) GetScene(id string) (*Scene, error) {
+func (c *Client) GetScene(ctx context.Context, id string) (*Scene, error) {
 	url := fmt.Sprintf("%s/%s/scenes/%s", c.baseURL(), c.username, id)
-	resp, err := c.getWithRetry(url)
+	resp, err := c.getWithRetry(ctx, url)
 	if err != nil {
 		return nil, fmt.Errorf("get request: %w", err)
 	}
@@ -672,35 +987,28 @@ func (c *Client) GetScene(id string) (*Scene, error) {
 	}
 
 	return &Scene{
-		ID:     id,
-		Name:   sr.Name,
-		Group:  sr.Group,
-		Type:   sr.Type,
-		Lights: sr.Lights,
+		ID:          id,
+		Name:        sr.Name,
+		Group:       sr.Group,
+		Type:        sr.Type,
+		Lights:      sr.Lights,
+		LightStates: sr.LightStates,
 	}, nil
 }
 
-// ActivateScene activates a scene on its group.
-func (c *Client) ActivateScene(sceneID string) error {
-	// First, get the scene to find its group
-	scene, err := c.GetScene(sceneID)
-	if err != nil {
-		return fmt.Errorf("get scene: %w", err)
-	}
-
-	if scene.Group == "" {
-		return fmt.Errorf("scene %s has no associated group", sceneID)
-	}
-
-	url := fmt.Sprintf("%s/%s/groups/%s/action", c.baseURL(), c.username, scene.Group)
+// UpdateSceneLightState changes the state a scene sets one of its lights
+// to. Lights showing the scene don't change until it is activated again.
+// Unlike SetLightState, no default transition is added: a TransitionTime
+// here is stored in the scene.
+func (c *Client) UpdateSceneLightState(ctx context.Context, sceneID, lightID string, state LightState) error {
+	url := fmt.Sprintf("%s/%s/scenes/%s/lightstates/%s", c.baseURL(), c.username, sceneID, lightID)
 
-	body := map[string]string{"scene": sceneID}
-	jsonBody, err := json.Marshal(body)
+	jsonBody, err := json.Marshal(state)
 	if err != nil {
 		return fmt.Errorf("marshal request: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodPut, url, bytes.NewReader(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewReader(jsonBody))
 	if err != nil {
 		return fmt.Errorf("create request: %w", err)
 	}
@@ -720,8 +1028,25 @@ func (c *Client) ActivateScene(sceneID string) error {
 	return c.checkError(data)
 }
 
+// ActivateScene activates a scene on its group, fading to it over
+// transitionTime deciseconds. A nil transitionTime uses the client's
+// default transition, or the bridge's if there is none.
+func (c *Client) ActivateScene(ctx context.Context, sceneID string, transitionTime *int) error {
+	// First, get the scene to find its group
+	scene, err := c.GetScene(ctx, sceneID)
+	if err != nil {
+		return fmt.Errorf("get scene: %w", err)
+	}
+
+	if scene.Group == "" {
+		return fmt.Errorf("scene %s has no associated group", sceneID)
+	}
+
+	return c.SetGroupState(ctx, scene.Group, GroupAction{Scene: sceneID, TransitionTime: transitionTime})
+}
+
 // CreateScene creates a new scene that captures the current state of lights in a group.
-func (c *Client) CreateScene(name, groupID string) (string, error) {
+func (c *Client) CreateScene(ctx context.Context, name, groupID string) (string, error) {
 	url := fmt.Sprintf("%s/%s/scenes", c.baseURL(), c.username)
 
 	body := map[string]any{
@@ -735,7 +1060,7 @@ func (c *Client) CreateScene(name, groupID string) (string, error) {
 		return "", fmt.Errorf("marshal request: %w", err)
 	}
 
-	resp, err := c.postWithRetry(url, "application/json", jsonBody)
+	resp, err := c.postWithRetry(ctx, url, "application/json", jsonBody)
 	if err != nil {
 		return "", fmt.Errorf("post request: %w", err)
 	}
@@ -755,7 +1080,7 @@ func (c *Client) CreateScene(name, groupID string) (string, error) {
 		return "", fmt.Errorf("empty response from bridge")
 	}
 
-	if err := bridgeErrorFromResult(results[0]); err != nil {
+	if err := bridgeErrors(results); err != nil {
 		return "", err
 	}
 
@@ -768,10 +1093,10 @@ func (c *Client) CreateScene(name, groupID string) (string, error) {
 }
 
 // DeleteScene removes a scene from the bridge.
-func (c *Client) DeleteScene(id string) error {
+func (c *Client) DeleteScene(ctx context.Context, id string) error {
 	url := fmt.Sprintf("%s/%s/scenes/%s", c.baseURL(), c.username, id)
 
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodDelete, url, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
 	if err != nil {
 		return fmt.Errorf("create request: %w", err)
 	}
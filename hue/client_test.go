@@ -1,11 +1,27 @@
 package hue
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestRegister_Success(t *testing.T) {
@@ -17,13 +33,16 @@ func TestRegister_Success(t *testing.T) {
 			t.Errorf("expected /api, got %s", r.URL.Path)
 		}
 
-		var body map[string]string
+		var body map[string]any
 		_ = json.NewDecoder(r.Body).Decode(&body)
 		if body["devicetype"] != "huey#test" {
-			t.Errorf("expected devicetype huey#test, got %s", body["devicetype"])
+			t.Errorf("expected devicetype huey#test, got %v", body["devicetype"])
+		}
+		if body["generateclientkey"] != true {
+			t.Errorf("expected generateclientkey true, got %v", body["generateclientkey"])
 		}
 
-		_, _ = w.Write([]byte(`[{"success":{"username":"abc123"}}]`))
+		_, _ = w.Write([]byte(`[{"success":{"username":"abc123","clientkey":"0123456789ABCDEF0123456789ABCDEF"}}]`))
 	}))
 	defer server.Close()
 
@@ -31,12 +50,15 @@ func TestRegister_Success(t *testing.T) {
 	addr := strings.TrimPrefix(server.URL, "http://")
 	client := NewClient(addr, "")
 
-	username, err := client.Register("huey#test")
+	creds, err := client.Register(t.Context(), "huey#test")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if username != "abc123" {
-		t.Errorf("expected username abc123, got %s", username)
+	if creds.Username != "abc123" {
+		t.Errorf("expected username abc123, got %s", creds.Username)
+	}
+	if creds.ClientKey != "0123456789ABCDEF0123456789ABCDEF" {
+		t.Errorf("expected client key, got %q", creds.ClientKey)
 	}
 }
 
@@ -49,13 +71,16 @@ func TestRegister_LinkButtonNotPressed(t *testing.T) {
 	addr := strings.TrimPrefix(server.URL, "http://")
 	client := NewClient(addr, "")
 
-	_, err := client.Register("huey#test")
+	_, err := client.Register(t.Context(), "huey#test")
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
 	if !strings.Contains(err.Error(), "link button not pressed") {
 		t.Errorf("expected 'link button not pressed' error, got: %v", err)
 	}
+	if !errors.Is(err, ErrLinkButtonNotPressed) {
+		t.Errorf("expected ErrLinkButtonNotPressed, got: %v", err)
+	}
 }
 
 func TestGetLights(t *testing.T) {
@@ -85,7 +110,7 @@ func TestGetLights(t *testing.T) {
 	addr := strings.TrimPrefix(server.URL, "http://")
 	client := NewClient(addr, "testuser")
 
-	lights, err := client.GetLights()
+	lights, err := client.GetLights(t.Context())
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -137,7 +162,7 @@ func TestGetLights_SortedNumerically(t *testing.T) {
 	addr := strings.TrimPrefix(server.URL, "http://")
 	client := NewClient(addr, "testuser")
 
-	lights, err := client.GetLights()
+	lights, err := client.GetLights(t.Context())
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -168,7 +193,7 @@ func TestGetLight(t *testing.T) {
 	addr := strings.TrimPrefix(server.URL, "http://")
 	client := NewClient(addr, "testuser")
 
-	light, err := client.GetLight("1")
+	light, err := client.GetLight(t.Context(), "1")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -208,7 +233,7 @@ func TestSetLightState(t *testing.T) {
 	client := NewClient(addr, "testuser")
 
 	on := true
-	err := client.SetLightState("1", LightState{On: &on})
+	err := client.SetLightState(t.Context(), "1", LightState{On: &on})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -224,13 +249,95 @@ func TestSetLightState_Error(t *testing.T) {
 	client := NewClient(addr, "testuser")
 
 	on := true
-	err := client.SetLightState("999", LightState{On: &on})
+	err := client.SetLightState(t.Context(), "999", LightState{On: &on})
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
 	if !strings.Contains(err.Error(), "resource not available") {
 		t.Errorf("expected 'resource not available' error, got: %v", err)
 	}
+	if !errors.Is(err, ErrResourceNotAvailable) {
+		t.Errorf("expected ErrResourceNotAvailable, got: %v", err)
+	}
+
+	var bridgeErr *BridgeError
+	if !errors.As(err, &bridgeErr) {
+		t.Fatalf("expected *BridgeError, got %T", err)
+	}
+	if bridgeErr.Type != 3 || bridgeErr.Address != "/lights/999" {
+		t.Errorf("unexpected error details: %+v", bridgeErr)
+	}
+}
+
+func TestSetLightState_ReportsAllErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[
+			{"success":{"/lights/1/state/on":false}},
+			{"error":{"type":201,"address":"/lights/1/state/bri","description":"parameter, bri, is not modifiable. Device is set to off."}},
+			{"error":{"type":201,"address":"/lights/1/state/ct","description":"parameter, ct, is not modifiable. Device is set to off."}}
+		]`))
+	}))
+	defer server.Close()
+
+	addr := strings.TrimPrefix(server.URL, "http://")
+	client := NewClient(addr, "testuser")
+
+	off := false
+	bri := 100
+	err := client.SetLightState(t.Context(), "1", LightState{On: &off, Brightness: &bri})
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if !errors.Is(err, ErrDeviceOff) {
+		t.Errorf("expected ErrDeviceOff, got: %v", err)
+	}
+	if errors.Is(err, ErrUnauthorized) {
+		t.Errorf("did not expect ErrUnauthorized, got: %v", err)
+	}
+	for _, param := range []string{"bri", "ct"} {
+		if !strings.Contains(err.Error(), "parameter, "+param) {
+			t.Errorf("expected error for %s, got: %v", param, err)
+		}
+	}
+}
+
+func TestGetLights_Unauthorized(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"error":{"type":1,"address":"/","description":"unauthorized user"}}]`))
+	}))
+	defer server.Close()
+
+	addr := strings.TrimPrefix(server.URL, "http://")
+	client := NewClient(addr, "stale")
+
+	_, err := client.GetLights(t.Context())
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized, got: %v", err)
+	}
+	if err.Error() != "bridge error: unauthorized user" {
+		t.Errorf("unexpected message: %v", err)
+	}
+}
+
+func TestGetGroup_AllLights(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/testuser/groups/0" {
+			t.Errorf("expected /api/testuser/groups/0, got %s", r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`{"name":"Group 0","type":"LightGroup","lights":["3","1","2"],"state":{"all_on":false,"any_on":true}}`))
+	}))
+	defer server.Close()
+
+	addr := strings.TrimPrefix(server.URL, "http://")
+	client := NewClient(addr, "testuser")
+
+	group, err := client.GetGroup(t.Context(), "0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if group.ID != "0" || group.Name != "Group 0" || !group.AnyOn || strings.Join(group.Lights, ",") != "1,2,3" {
+		t.Errorf("unexpected group: %+v", group)
+	}
 }
 
 func TestGetGroups_LightsSortedNumerically(t *testing.T) {
@@ -260,7 +367,7 @@ func TestGetGroups_LightsSortedNumerically(t *testing.T) {
 	addr := strings.TrimPrefix(server.URL, "http://")
 	client := NewClient(addr, "testuser")
 
-	groups, err := client.GetGroups()
+	groups, err := client.GetGroups(t.Context())
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -310,7 +417,7 @@ func TestGetScenes_SortedByGroupThenName(t *testing.T) {
 	addr := strings.TrimPrefix(server.URL, "http://")
 	client := NewClient(addr, "testuser")
 
-	scenes, err := client.GetScenes()
+	scenes, err := client.GetScenes(t.Context())
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -327,6 +434,66 @@ func TestGetScenes_SortedByGroupThenName(t *testing.T) {
 	}
 }
 
+func TestGetScene_LightStates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/testuser/scenes/abc" {
+			t.Errorf("expected /api/testuser/scenes/abc, got %s", r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`{
+			"name":"Relax","type":"GroupScene","group":"4","lights":["1","2"],
+			"lightstates":{
+				"1":{"on":true,"bri":144,"ct":447},
+				"2":{"on":false}
+			}
+		}`))
+	}))
+	defer server.Close()
+
+	addr := strings.TrimPrefix(server.URL, "http://")
+	client := NewClient(addr, "testuser")
+
+	scene, err := client.GetScene(t.Context(), "abc")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(scene.LightStates) != 2 {
+		t.Fatalf("expected 2 light states, got %+v", scene.LightStates)
+	}
+	desk := scene.LightStates["1"]
+	if desk.On == nil || !*desk.On || desk.Brightness == nil || *desk.Brightness != 144 || desk.ColorTemp == nil || *desk.ColorTemp != 447 {
+		t.Errorf("unexpected state for light 1: %+v", desk)
+	}
+	if hall := scene.LightStates["2"]; hall.On == nil || *hall.On || hall.Brightness != nil {
+		t.Errorf("unexpected state for light 2: %+v", hall)
+	}
+}
+
+func TestUpdateSceneLightState(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			t.Errorf("expected PUT, got %s", r.Method)
+		}
+		if r.URL.Path != "/api/testuser/scenes/abc/lightstates/3" {
+			t.Errorf("expected /api/testuser/scenes/abc/lightstates/3, got %s", r.URL.Path)
+		}
+		// The client's default transition is not stored in the scene.
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `{"on":true,"bri":102}` {
+			t.Errorf("unexpected body: %s", body)
+		}
+		_, _ = w.Write([]byte(`[{"success":{"/scenes/abc/lightstates/3/on":true}},{"success":{"/scenes/abc/lightstates/3/bri":102}}]`))
+	}))
+	defer server.Close()
+
+	addr := strings.TrimPrefix(server.URL, "http://")
+	client := NewClient(addr, "testuser", WithTransition(time.Second))
+
+	on := true
+	if err := client.UpdateSceneLightState(t.Context(), "abc", "3", LightState{On: &on, Brightness: ptr(102)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
 func TestSortScenes_TieBreaksByID(t *testing.T) {
 	scenes := []Scene{
 		{ID: "10", Name: "Alpha", Group: "1"},
@@ -373,3 +540,1425 @@ func TestCompareNumericIDs(t *testing.T) {
 		})
 	}
 }
+
+func TestSetGroupState_FullAction(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			t.Errorf("expected PUT, got %s", r.Method)
+		}
+		if r.URL.Path != "/api/testuser/groups/4/action" {
+			t.Errorf("expected /api/testuser/groups/4/action, got %s", r.URL.Path)
+		}
+
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+
+		if body["on"] != true {
+			t.Errorf("expected on=true, got %v", body["on"])
+		}
+		if body["bri"] != float64(127) {
+			t.Errorf("expected bri=127, got %v", body["bri"])
+		}
+		if body["ct"] != float64(370) {
+			t.Errorf("expected ct=370, got %v", body["ct"])
+		}
+		for _, field := range []string{"hue", "sat", "xy", "scene", "transitiontime"} {
+			if _, ok := body[field]; ok {
+				t.Errorf("expected %s to be omitted, got %v", field, body[field])
+			}
+		}
+
+		_, _ = w.Write([]byte(`[{"success":{"/groups/4/action/on":true}}]`))
+	}))
+	defer server.Close()
+
+	addr := strings.TrimPrefix(server.URL, "http://")
+	client := NewClient(addr, "testuser")
+
+	on := true
+	bri := 127
+	ct := 370
+	err := client.SetGroupState(t.Context(), "4", GroupAction{On: &on, Brightness: &bri, ColorTemp: &ct})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestWithTransition(t *testing.T) {
+	var bodies []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			_, _ = w.Write([]byte(`{"name":"Relax","group":"4","lights":["1"]}`))
+			return
+		}
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		bodies = append(bodies, body)
+		_, _ = w.Write([]byte(`[{"success":{}}]`))
+	}))
+	defer server.Close()
+
+	addr := strings.TrimPrefix(server.URL, "http://")
+	client := NewClient(addr, "testuser", WithTransition(1500*time.Millisecond))
+
+	on := true
+	if err := client.SetLightState(t.Context(), "1", LightState{On: &on}); err != nil {
+		t.Fatalf("set light state: %v", err)
+	}
+	if err := client.SetLightState(t.Context(), "1", LightState{On: &on, TransitionTime: TransitionTime(0)}); err != nil {
+		t.Fatalf("set light state: %v", err)
+	}
+	if err := client.SetGroupState(t.Context(), "4", GroupAction{On: &on}); err != nil {
+		t.Fatalf("set group state: %v", err)
+	}
+	if err := client.ActivateScene(t.Context(), "abc", TransitionTime(3*time.Second)); err != nil {
+		t.Fatalf("activate scene: %v", err)
+	}
+
+	want := []float64{15, 0, 15, 30}
+	if len(bodies) != len(want) {
+		t.Fatalf("expected %d requests, got %d", len(want), len(bodies))
+	}
+	for i, body := range bodies {
+		if body["transitiontime"] != want[i] {
+			t.Errorf("request %d: expected transitiontime=%v, got %v", i, want[i], body["transitiontime"])
+		}
+	}
+	if bodies[3]["scene"] != "abc" {
+		t.Errorf("expected scene=abc, got %v", bodies[3]["scene"])
+	}
+}
+
+func TestGetLight_ColorFields(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			var body map[string]json.RawMessage
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if string(body["xy"]) != "[0.5016,0.4151]" {
+				t.Errorf("expected xy to round-trip unchanged, got %s", body["xy"])
+			}
+			_, _ = w.Write([]byte(`[{"success":{"/lights/1/state/xy":[0.5016,0.4151]}}]`))
+			return
+		}
+
+		_, _ = w.Write([]byte(`{
+			"name": "Desk",
+			"type": "Extended color light",
+			"state": {"on": true, "bri": 200, "xy": [0.5016, 0.4151], "ct": 443, "colormode": "xy"},
+			"capabilities": {"control": {"colorgamuttype": "C"}}
+		}`))
+	}))
+	defer server.Close()
+
+	addr := strings.TrimPrefix(server.URL, "http://")
+	client := NewClient(addr, "testuser")
+
+	light, err := client.GetLight(t.Context(), "1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if light.XY != [2]float64{0.5016, 0.4151} {
+		t.Errorf("expected xy [0.5016 0.4151], got %v", light.XY)
+	}
+	if light.ColorTemp != 443 {
+		t.Errorf("expected ct 443, got %d", light.ColorTemp)
+	}
+	if light.ColorMode != "xy" {
+		t.Errorf("expected colormode xy, got %q", light.ColorMode)
+	}
+	if light.GamutType != "C" {
+		t.Errorf("expected gamut C, got %q", light.GamutType)
+	}
+
+	if err := client.SetLightState(t.Context(), "1", LightState{XY: &light.XY}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestGetBridgeConfig(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/config" {
+			t.Errorf("expected /api/config, got %s", r.URL.Path)
+		}
+
+		_, _ = w.Write([]byte(`{
+			"name": "Philips hue",
+			"datastoreversion": "131",
+			"swversion": "1962154010",
+			"apiversion": "1.62.0",
+			"mac": "00:17:88:23:bf:c2",
+			"bridgeid": "001788FFFE23BFC2",
+			"factorynew": false,
+			"modelid": "BSB002"
+		}`))
+	}))
+	defer server.Close()
+
+	addr := strings.TrimPrefix(server.URL, "http://")
+	client := NewClient(addr, "")
+
+	config, err := client.GetBridgeConfig(t.Context())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if config.BridgeID != "001788fffe23bfc2" {
+		t.Errorf("expected lowercase bridge ID, got %s", config.BridgeID)
+	}
+	if config.Name != "Philips hue" || config.ModelID != "BSB002" || config.APIVersion != "1.62.0" {
+		t.Errorf("config data mismatch: %+v", config)
+	}
+}
+
+func TestRelocation_FollowsBridgeToNewAddress(t *testing.T) {
+	oldServer := httptest.NewServer(http.NotFoundHandler())
+	oldAddr := strings.TrimPrefix(oldServer.URL, "http://")
+	oldServer.Close() // the bridge got a new DHCP lease
+
+	newServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/config":
+			_, _ = w.Write([]byte(`{"name":"Hue Bridge","bridgeid":"001788FFFE23BFC2"}`))
+		case "/api/testuser/lights":
+			_, _ = w.Write([]byte(`{"1":{"name":"Desk","type":"Extended color light","state":{"on":true,"bri":254}}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer newServer.Close()
+	newAddr := strings.TrimPrefix(newServer.URL, "http://")
+
+	client := NewClient(oldAddr, "testuser")
+	var lookups int
+	var movedFrom, movedTo string
+	client.EnableRelocation("001788fffe23bfc2",
+		func(ctx context.Context, bridgeID string) (string, error) {
+			lookups++
+			if bridgeID != "001788fffe23bfc2" {
+				t.Errorf("expected lookup by bridge ID, got %q", bridgeID)
+			}
+			return newAddr, nil
+		},
+		func(oldIP, newIP string) { movedFrom, movedTo = oldIP, newIP })
+
+	lights, err := client.GetLights(t.Context())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(lights) != 1 || lights[0].Name != "Desk" {
+		t.Errorf("unexpected lights: %+v", lights)
+	}
+	if movedFrom != oldAddr || movedTo != newAddr {
+		t.Errorf("onMove(%q, %q), expected (%q, %q)", movedFrom, movedTo, oldAddr, newAddr)
+	}
+	if client.BridgeIP() != newAddr {
+		t.Errorf("expected client to use %s, got %s", newAddr, client.BridgeIP())
+	}
+
+	// Later requests go straight to the new address.
+	if _, err := client.GetLights(t.Context()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if lookups != 1 {
+		t.Errorf("expected 1 lookup, got %d", lookups)
+	}
+}
+
+func TestRelocation_RejectsDifferentBridge(t *testing.T) {
+	oldServer := httptest.NewServer(http.NotFoundHandler())
+	oldAddr := strings.TrimPrefix(oldServer.URL, "http://")
+	oldServer.Close()
+
+	otherServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"name":"Neighbour","bridgeid":"001788fffe000000"}`))
+	}))
+	defer otherServer.Close()
+
+	client := NewClient(oldAddr, "testuser")
+	var lookups int
+	client.EnableRelocation("001788fffe23bfc2",
+		func(ctx context.Context, bridgeID string) (string, error) {
+			lookups++
+			return strings.TrimPrefix(otherServer.URL, "http://"), nil
+		},
+		func(oldIP, newIP string) { t.Errorf("unexpected move to %s", newIP) })
+
+	_, err := client.GetLights(t.Context())
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if !strings.Contains(err.Error(), "expected 001788fffe23bfc2") {
+		t.Errorf("expected bridge ID mismatch in error, got: %v", err)
+	}
+	if client.BridgeIP() != oldAddr {
+		t.Errorf("expected client to stay at %s, got %s", oldAddr, client.BridgeIP())
+	}
+
+	// A failed lookup isn't repeated on every request.
+	_, _ = client.GetLights(t.Context())
+	if lookups != 1 {
+		t.Errorf("expected 1 lookup, got %d", lookups)
+	}
+}
+
+func TestRelocation_VerifiesOverHTTPS(t *testing.T) {
+	oldServer := httptest.NewTLSServer(http.NotFoundHandler())
+	oldAddr := strings.TrimPrefix(oldServer.URL, "https://")
+	oldServer.Close()
+
+	var plain atomic.Int32
+	plainServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		plain.Add(1)
+		configHandler(w, r)
+	}))
+	defer plainServer.Close()
+
+	roots, cert := bridgeCA(t, "001788FFFE23BFC2")
+	newServer := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/config":
+			configHandler(w, r)
+		case "/api/testuser/lights":
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	newServer.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	newServer.StartTLS()
+	defer newServer.Close()
+
+	tests := []struct {
+		name    string
+		addr    string
+		wantErr bool
+	}{
+		{"plain HTTP bridge", strings.TrimPrefix(plainServer.URL, "http://"), true},
+		{"HTTPS bridge", strings.TrimPrefix(newServer.URL, "https://"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := CertPolicy{BridgeID: "001788fffe23bfc2", Fingerprint: CAVerified, roots: roots}
+			client := NewClient(oldAddr, "testuser", WithHTTPS(policy), WithRetryPolicy(RetryPolicy{}))
+			client.EnableRelocation("001788fffe23bfc2",
+				func(ctx context.Context, bridgeID string) (string, error) { return tt.addr, nil },
+				nil)
+
+			_, err := client.GetLights(t.Context())
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("GetLights error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr && client.BridgeIP() != oldAddr {
+				t.Errorf("expected client to stay at %s, got %s", oldAddr, client.BridgeIP())
+			}
+		})
+	}
+	if plain.Load() != 0 {
+		t.Errorf("expected no plain HTTP request, got %d", plain.Load())
+	}
+}
+
+func TestCheckAuth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/gooduser/groups/0":
+			_, _ = w.Write([]byte(`{"name":"Group 0","lights":["1","2"],"type":"LightGroup"}`))
+		default:
+			_, _ = w.Write([]byte(`[{"error":{"type":1,"address":"/","description":"unauthorized user"}}]`))
+		}
+	}))
+	defer server.Close()
+
+	addr := strings.TrimPrefix(server.URL, "http://")
+
+	if err := NewClient(addr, "gooduser").CheckAuth(t.Context()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := NewClient(addr, "revoked").CheckAuth(t.Context()); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized, got: %v", err)
+	}
+}
+
+func TestGetLights_Cancelled(t *testing.T) {
+	var attempts atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	addr := strings.TrimPrefix(server.URL, "http://")
+	client := NewClient(addr, "testuser")
+
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := client.GetLights(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context deadline error, got: %v", err)
+	}
+	if attempts.Load() != 1 {
+		t.Errorf("expected no retry after cancellation, got %d attempts", attempts.Load())
+	}
+}
+
+func TestSetLightState_AlreadyCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("no request expected with a cancelled context")
+	}))
+	defer server.Close()
+
+	addr := strings.TrimPrefix(server.URL, "http://")
+	client := NewClient(addr, "testuser")
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	on := true
+	if err := client.SetLightState(ctx, "1", LightState{On: &on}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got: %v", err)
+	}
+}
+
+func TestRetryPolicy_IdempotentOnly(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   RetryPolicy
+		call     func(c *Client) error
+		attempts int32
+	}{
+		{
+			name:     "GET retried",
+			policy:   RetryPolicy{MaxRetries: 2},
+			call:     func(c *Client) error { _, err := c.GetLights(t.Context()); return err },
+			attempts: 3,
+		},
+		{
+			name:     "POST not retried by default",
+			policy:   RetryPolicy{MaxRetries: 2},
+			call:     func(c *Client) error { _, err := c.CreateGroup(t.Context(), "Den", "Room", nil); return err },
+			attempts: 1,
+		},
+		{
+			name:     "POST retried when allowed",
+			policy:   RetryPolicy{MaxRetries: 2, RetryNonIdempotent: true},
+			call:     func(c *Client) error { _, err := c.CreateGroup(t.Context(), "Den", "Room", nil); return err },
+			attempts: 3,
+		},
+		{
+			name:     "retries disabled",
+			policy:   RetryPolicy{},
+			call:     func(c *Client) error { _, err := c.GetLights(t.Context()); return err },
+			attempts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts.Add(1)
+				// Drop the connection without a response.
+				conn, _, _ := w.(http.Hijacker).Hijack()
+				_ = conn.Close()
+			}))
+			defer server.Close()
+
+			addr := strings.TrimPrefix(server.URL, "http://")
+			client := NewClient(addr, "testuser", WithRetryPolicy(tt.policy))
+
+			if err := tt.call(client); err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if attempts.Load() != tt.attempts {
+				t.Errorf("expected %d attempts, got %d", tt.attempts, attempts.Load())
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+
+	tests := []struct {
+		attempt  int
+		min, max time.Duration
+	}{
+		{0, 50 * time.Millisecond, 100 * time.Millisecond},
+		{1, 100 * time.Millisecond, 200 * time.Millisecond},
+		{2, 150 * time.Millisecond, 300 * time.Millisecond}, // capped
+		{10, 150 * time.Millisecond, 300 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		for range 20 {
+			d := policy.delay(tt.attempt)
+			if d < tt.min || d > tt.max {
+				t.Errorf("delay(%d) = %v, want between %v and %v", tt.attempt, d, tt.min, tt.max)
+			}
+		}
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	addr := strings.TrimPrefix(server.URL, "http://")
+
+	short := NewClient(addr, "testuser", WithTimeout(20*time.Millisecond), WithRetryPolicy(RetryPolicy{}))
+	if _, err := short.GetLights(t.Context()); err == nil {
+		t.Error("expected timeout error with 20ms timeout")
+	}
+
+	long := NewClient(addr, "testuser", WithTimeout(time.Second))
+	if _, err := long.GetLights(t.Context()); err != nil {
+		t.Errorf("unexpected error with 1s timeout: %v", err)
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	bucket := newTokenBucket(10)
+	start := time.Now()
+
+	// A full second's worth goes out immediately.
+	for i := range 10 {
+		if wait := bucket.reserve(start); wait != 0 {
+			t.Fatalf("reservation %d: expected no wait, got %v", i, wait)
+		}
+	}
+
+	// Then one slot every 100ms.
+	if wait := bucket.reserve(start); wait != 100*time.Millisecond {
+		t.Errorf("expected 100ms wait, got %v", wait)
+	}
+	if wait := bucket.reserve(start); wait != 200*time.Millisecond {
+		t.Errorf("expected 200ms wait, got %v", wait)
+	}
+
+	// Tokens refill over time, up to the burst size.
+	later := start.Add(10 * time.Second)
+	for i := range 10 {
+		if wait := bucket.reserve(later); wait != 0 {
+			t.Fatalf("after refill, reservation %d: expected no wait, got %v", i, wait)
+		}
+	}
+	if wait := bucket.reserve(later); wait == 0 {
+		t.Error("expected refill to be capped at the burst size")
+	}
+
+	if wait := newTokenBucket(0).reserve(start); wait != 0 {
+		t.Errorf("expected unlimited bucket not to wait, got %v", wait)
+	}
+}
+
+func TestSetLightState_CoalescesQueuedUpdates(t *testing.T) {
+	var mu sync.Mutex
+	bodies := map[string][]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies[r.URL.Path] = append(bodies[r.URL.Path], string(body))
+		mu.Unlock()
+		_, _ = w.Write([]byte(`[{"success":{}}]`))
+	}))
+	defer server.Close()
+
+	addr := strings.TrimPrefix(server.URL, "http://")
+	client := NewClient(addr, "testuser", WithRateLimit(10, 1))
+
+	// Use up the burst so the next update has to queue.
+	on := true
+	for i := range 10 {
+		if err := client.SetLightState(t.Context(), strconv.Itoa(i+2), LightState{On: &on}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		if err := client.SetLightState(t.Context(), "1", LightState{On: &on, Hue: ptr(1000)}); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+	waitFor(t, func() bool { lights, _ := client.QueueDepth(); return lights == 1 })
+
+	wg.Go(func() {
+		if err := client.SetLightState(t.Context(), "1", LightState{Brightness: ptr(100)}); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+	wg.Go(func() {
+		if err := client.SetLightState(t.Context(), "1", LightState{XY: &[2]float64{0.3, 0.3}}); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+	wg.Wait()
+
+	sent := bodies["/api/testuser/lights/1/state"]
+	if len(sent) != 1 {
+		t.Fatalf("expected one merged command, got %d: %v", len(sent), sent)
+	}
+	if sent[0] != `{"on":true,"bri":100,"xy":[0.3,0.3]}` {
+		t.Errorf("unexpected merged state: %s", sent[0])
+	}
+	if lights, groups := client.QueueDepth(); lights != 0 || groups != 0 {
+		t.Errorf("expected empty queues, got %d lights, %d groups", lights, groups)
+	}
+}
+
+func TestSetLightState_FirstCallerCancelled(t *testing.T) {
+	var mu sync.Mutex
+	var sent []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.URL.Path == "/api/testuser/lights/1/state" {
+			mu.Lock()
+			sent = append(sent, string(body))
+			mu.Unlock()
+		}
+		_, _ = w.Write([]byte(`[{"success":{}}]`))
+	}))
+	defer server.Close()
+
+	addr := strings.TrimPrefix(server.URL, "http://")
+	client := NewClient(addr, "testuser", WithRateLimit(10, 1))
+
+	on := true
+	for i := range 10 {
+		if err := client.SetLightState(t.Context(), strconv.Itoa(i+2), LightState{On: &on}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		if err := client.SetLightState(ctx, "1", LightState{On: &on}); !errors.Is(err, context.Canceled) {
+			t.Errorf("expected the cancelled caller to get context.Canceled, got %v", err)
+		}
+	})
+	waitFor(t, func() bool { lights, _ := client.QueueDepth(); return lights == 1 })
+
+	wg.Go(func() {
+		if err := client.SetLightState(t.Context(), "1", LightState{Brightness: ptr(100)}); err != nil {
+			t.Errorf("expected the merged update to be sent, got %v", err)
+		}
+	})
+	waitFor(t, func() bool {
+		client.scheduler.mu.Lock()
+		defer client.scheduler.mu.Unlock()
+		p := client.scheduler.pending["1"]
+		return p != nil && p.waiters == 2
+	})
+	cancel()
+	wg.Wait()
+
+	if len(sent) != 1 || sent[0] != `{"on":true,"bri":100}` {
+		t.Errorf("expected the merged update to be sent once, got %v", sent)
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// waitFor polls cond until it holds or a second has passed.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within 1s")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestLightStateMerge_Increments(t *testing.T) {
+	tests := []struct {
+		name         string
+		older, newer LightState
+		want         string
+	}{
+		{"increments add up", LightState{BrightnessInc: ptr(25)}, LightState{BrightnessInc: ptr(25)}, `{"bri_inc":50}`},
+		{"increment sum is clamped", LightState{BrightnessInc: ptr(-200)}, LightState{BrightnessInc: ptr(-200)}, `{"bri_inc":-254}`},
+		{"increment applies to older value", LightState{Brightness: ptr(240)}, LightState{BrightnessInc: ptr(25)}, `{"bri":254}`},
+		{"value replaces older increment", LightState{BrightnessInc: ptr(25)}, LightState{Brightness: ptr(10)}, `{"bri":10}`},
+		{"hue wraps around", LightState{Hue: ptr(65000)}, LightState{HueInc: ptr(1000)}, `{"hue":464}`},
+		{"color drops older increments", LightState{ColorTempInc: ptr(20)}, LightState{XY: &[2]float64{0.3, 0.3}}, `{"xy":[0.3,0.3]}`},
+		{"color drops other modes", LightState{Hue: ptr(1000), Saturation: ptr(200)}, LightState{ColorTemp: ptr(300)}, `{"ct":300}`},
+		{"same color mode is kept", LightState{Hue: ptr(1000)}, LightState{Saturation: ptr(200)}, `{"hue":1000,"sat":200}`},
+		{"value replaces older color increment", LightState{HueInc: ptr(500), Saturation: ptr(200)}, LightState{Hue: ptr(1000)}, `{"hue":1000,"sat":200}`},
+		{"alert and effect are kept", LightState{Alert: AlertLongSelect}, LightState{Effect: EffectColorLoop}, `{"alert":"lselect","effect":"colorloop"}`},
+		{"xy increments add up", LightState{XYInc: &[2]float64{0.25, 0}}, LightState{XYInc: &[2]float64{0.5, 0.1}}, `{"xy_inc":[0.5,0.1]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.older.merge(tt.newer))
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("got %s, want %s", data, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetSensors_TypedState(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/testuser/sensors" {
+			t.Errorf("expected /api/testuser/sensors, got %s", r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`{
+			"1": {"name":"Daylight","type":"Daylight","modelid":"PHDL00","state":{"daylight":true,"lastupdated":"none"},"config":{"on":true}},
+			"5": {"name":"Hall motion","type":"ZLLPresence","modelid":"SML001","state":{"presence":true,"lastupdated":"2026-10-17T10:00:00"},
+				"config":{"on":true,"reachable":true,"battery":90,"sensitivity":2,"sensitivitymax":2,"ledindication":false}},
+			"6": {"name":"Hall temperature","type":"ZLLTemperature","state":{"temperature":2150,"lastupdated":"2026-10-17T10:00:00"},"config":{"on":true}},
+			"7": {"name":"Hall light level","type":"ZLLLightLevel","state":{"lightlevel":10001,"dark":false,"daylight":true},"config":{"on":true}},
+			"10": {"name":"Dimmer","type":"ZLLSwitch","state":{"buttonevent":1002},"config":{"on":true,"reachable":false}}
+		}`))
+	}))
+	defer server.Close()
+
+	addr := strings.TrimPrefix(server.URL, "http://")
+	client := NewClient(addr, "testuser")
+
+	sensors, err := client.GetSensors(t.Context())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sensors) != 5 {
+		t.Fatalf("expected 5 sensors, got %d", len(sensors))
+	}
+
+	daylight, motion, temperature, lightLevel, dimmer := sensors[0], sensors[1], sensors[2], sensors[3], sensors[4]
+	if !daylight.State.LastUpdated.IsZero() || daylight.State.Daylight == nil || !*daylight.State.Daylight {
+		t.Errorf("unexpected daylight state: %+v", daylight.State)
+	}
+	if daylight.Config.Reachable != nil || daylight.Config.Battery != nil {
+		t.Errorf("expected no reachability or battery for daylight, got %+v", daylight.Config)
+	}
+
+	wantUpdated := time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)
+	if motion.State.Presence == nil || !*motion.State.Presence || !motion.State.LastUpdated.Equal(wantUpdated) {
+		t.Errorf("unexpected motion state: %+v", motion.State)
+	}
+	if motion.Config.Battery == nil || *motion.Config.Battery != 90 || motion.Config.LEDIndication == nil || *motion.Config.LEDIndication {
+		t.Errorf("unexpected motion config: %+v", motion.Config)
+	}
+	if motion.RawState["presence"] != true {
+		t.Errorf("expected raw presence=true, got %v", motion.RawState["presence"])
+	}
+
+	if temperature.State.Temperature == nil || *temperature.State.Temperature != 21.5 {
+		t.Errorf("expected 21.5°C, got %v", temperature.State.Temperature)
+	}
+	if lux := lightLevel.State.Lux(); lux != 10 {
+		t.Errorf("expected 10 lux, got %v", lux)
+	}
+	if dimmer.State.ButtonEvent == nil || *dimmer.State.ButtonEvent != 1002 || *dimmer.Config.Reachable {
+		t.Errorf("unexpected dimmer: %+v %+v", dimmer.State, dimmer.Config)
+	}
+}
+
+func TestSetSensorConfig(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			t.Errorf("expected PUT, got %s", r.Method)
+		}
+		if r.URL.Path != "/api/testuser/sensors/5/config" {
+			t.Errorf("expected /api/testuser/sensors/5/config, got %s", r.URL.Path)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `{"on":false,"sensitivity":0}` {
+			t.Errorf("unexpected body: %s", body)
+		}
+		_, _ = w.Write([]byte(`[{"success":{"/sensors/5/config/on":false}}]`))
+	}))
+	defer server.Close()
+
+	addr := strings.TrimPrefix(server.URL, "http://")
+	client := NewClient(addr, "testuser")
+
+	off := false
+	if err := client.SetSensorConfig(t.Context(), "5", SensorConfigUpdate{On: &off, Sensitivity: ptr(0)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCreateSchedule(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("expected POST, got %s", r.Method)
+		}
+		if r.URL.Path != "/api/testuser/schedules" {
+			t.Errorf("expected /api/testuser/schedules, got %s", r.URL.Path)
+		}
+		body, _ := io.ReadAll(r.Body)
+		want := `{"name":"Wake","description":"","command":{"address":"/api/testuser/groups/1/action","method":"PUT","body":{"scene":"abc","transitiontime":6000}},"localtime":"W124/T07:00:00","status":"enabled","autodelete":false}`
+		if string(body) != want {
+			t.Errorf("unexpected body:\n got %s\nwant %s", body, want)
+		}
+		_, _ = w.Write([]byte(`[{"success":{"id":"4"}}]`))
+	}))
+	defer server.Close()
+
+	addr := strings.TrimPrefix(server.URL, "http://")
+	client := NewClient(addr, "testuser")
+
+	command, err := client.GroupActionCommand("1", GroupAction{Scene: "abc", TransitionTime: TransitionTime(10 * time.Minute)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pattern := TimePattern{Kind: TimeRecurring, Weekdays: WorkDays, TimeOfDay: 7 * time.Hour}
+
+	id, err := client.CreateSchedule(t.Context(), Schedule{Name: "Wake", Command: command, LocalTime: pattern.String()})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id != "4" {
+		t.Errorf("expected ID 4, got %q", id)
+	}
+}
+
+func TestUpdateSchedule(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			t.Errorf("expected PUT, got %s", r.Method)
+		}
+		if r.URL.Path != "/api/testuser/schedules/4" {
+			t.Errorf("expected /api/testuser/schedules/4, got %s", r.URL.Path)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != `{"localtime":"PT00:10:00","status":"disabled"}` {
+			t.Errorf("unexpected body: %s", body)
+		}
+		_, _ = w.Write([]byte(`[{"success":{"/schedules/4/status":"disabled"}}]`))
+	}))
+	defer server.Close()
+
+	addr := strings.TrimPrefix(server.URL, "http://")
+	client := NewClient(addr, "testuser")
+
+	update := ScheduleUpdate{LocalTime: ptr("PT00:10:00"), Status: ptr(ScheduleDisabled)}
+	if err := client.UpdateSchedule(t.Context(), "4", update); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestBridgeConfig_Location(t *testing.T) {
+	// A zone unknown here falls back to the bridge's current UTC offset.
+	bridge := BridgeConfig{TimeZone: "Mars/Olympus_Mons", LocalTime: "2026-10-17T14:00:00", UTC: "2026-10-17T12:00:01"}
+	if _, offset := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC).In(bridge.Location()).Zone(); offset != 2*60*60 {
+		t.Errorf("offset = %ds, want 7200s", offset)
+	}
+
+	if loc := (BridgeConfig{}).Location(); loc != time.Local {
+		t.Errorf("expected time.Local without a bridge time, got %v", loc)
+	}
+}
+
+func TestParseTimePattern(t *testing.T) {
+	bridgeZone := time.FixedZone("", 5*60*60)
+	tests := []struct {
+		pattern string
+		want    TimePattern
+	}{
+		{"2026-10-18T07:30:00", TimePattern{Kind: TimeAbsolute, At: time.Date(2026, 10, 18, 7, 30, 0, 0, bridgeZone)}},
+		{"W124/T07:00:00", TimePattern{Kind: TimeRecurring, Weekdays: WorkDays, TimeOfDay: 7 * time.Hour}},
+		{"W003/T09:15:30", TimePattern{Kind: TimeRecurring, Weekdays: Weekend, TimeOfDay: 9*time.Hour + 15*time.Minute + 30*time.Second}},
+		{"PT00:10:00", TimePattern{Kind: TimeTimer, Timer: 10 * time.Minute}},
+		{"R/PT02:00:00", TimePattern{Kind: TimeTimer, Timer: 2 * time.Hour, Repeat: RepeatForever}},
+		{"R05/PT00:00:30", TimePattern{Kind: TimeTimer, Timer: 30 * time.Second, Repeat: 5}},
+		{"W127/T22:30:00A00:15:00", TimePattern{Kind: TimeRecurring, Weekdays: EveryDay, TimeOfDay: 22*time.Hour + 30*time.Minute, Random: 15 * time.Minute}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			got, err := ParseTimePattern(tt.pattern, bridgeZone)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if s := got.String(); s != tt.pattern {
+				t.Errorf("String() = %q, want %q", s, tt.pattern)
+			}
+			if !got.At.Equal(tt.want.At) {
+				t.Errorf("At = %v, want %v", got.At, tt.want.At)
+			}
+			got.At, tt.want.At = time.Time{}, time.Time{}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	for _, pattern := range []string{"", "W128/T07:00:00", "W124/07:00", "PT10:00", "R0/PT00:10:00", "2026-10-18"} {
+		if _, err := ParseTimePattern(pattern, bridgeZone); err == nil {
+			t.Errorf("ParseTimePattern(%q): expected an error", pattern)
+		}
+	}
+}
+
+func TestWeekdays(t *testing.T) {
+	w := WeekdaysOf(time.Monday, time.Wednesday, time.Sunday)
+	if w != Monday|Wednesday|Sunday {
+		t.Errorf("WeekdaysOf = %d, want %d", w, Monday|Wednesday|Sunday)
+	}
+	if !w.Has(time.Sunday) || w.Has(time.Tuesday) {
+		t.Errorf("Has: unexpected result for %d", w)
+	}
+	if days := w.Days(); len(days) != 3 || days[0] != time.Monday || days[2] != time.Sunday {
+		t.Errorf("Days = %v, want Monday first and Sunday last", days)
+	}
+}
+
+func TestGetRules(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/testuser/rules" {
+			t.Errorf("expected /api/testuser/rules, got %s", r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`{
+			"10": {"name":"Never","status":"disabled","lasttriggered":"none","timestriggered":0,"conditions":[],"actions":[]},
+			"2": {
+				"name":"Dimmer on","status":"enabled","lasttriggered":"2026-10-17T06:58:12","timestriggered":42,
+				"conditions":[
+					{"address":"/sensors/5/state/buttonevent","operator":"eq","value":"1002"},
+					{"address":"/sensors/5/state/lastupdated","operator":"dx"}
+				],
+				"actions":[{"address":"/groups/3/action","method":"PUT","body":{"scene":"abc"}}]
+			}
+		}`))
+	}))
+	defer server.Close()
+
+	addr := strings.TrimPrefix(server.URL, "http://")
+	client := NewClient(addr, "testuser")
+
+	rules, err := client.GetRules(t.Context())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rules) != 2 || rules[0].ID != "2" || rules[1].ID != "10" {
+		t.Fatalf("expected rules 2 and 10 in order, got %+v", rules)
+	}
+
+	rule := rules[0]
+	if rule.TimesTriggered != 42 || !rule.LastTriggered.Equal(time.Date(2026, 10, 17, 6, 58, 12, 0, time.UTC)) {
+		t.Errorf("unexpected trigger stats: %d, %v", rule.TimesTriggered, rule.LastTriggered)
+	}
+	wantConditions := []Condition{
+		{Address: "/sensors/5/state/buttonevent", Operator: OpEqual, Value: "1002"},
+		{Address: "/sensors/5/state/lastupdated", Operator: OpChanged},
+	}
+	if len(rule.Conditions) != 2 || rule.Conditions[0] != wantConditions[0] || rule.Conditions[1] != wantConditions[1] {
+		t.Errorf("unexpected conditions: %+v", rule.Conditions)
+	}
+	if len(rule.Actions) != 1 || rule.Actions[0].Address != "/groups/3/action" || string(rule.Actions[0].Body) != `{"scene":"abc"}` {
+		t.Errorf("unexpected actions: %+v", rule.Actions)
+	}
+
+	if !rules[1].LastTriggered.IsZero() {
+		t.Errorf("expected no last trigger time for a rule that never triggered, got %v", rules[1].LastTriggered)
+	}
+}
+
+func TestCreateRule(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("expected POST, got %s", r.Method)
+		}
+		if r.URL.Path != "/api/testuser/rules" {
+			t.Errorf("expected /api/testuser/rules, got %s", r.URL.Path)
+		}
+		body, _ := io.ReadAll(r.Body)
+		want := `{"name":"Motion","status":"enabled","conditions":[{"address":"/sensors/7/state/presence","operator":"eq","value":"true"}],"actions":[{"address":"/groups/1/action","method":"PUT","body":{"on":true}}]}`
+		if string(body) != want {
+			t.Errorf("unexpected body:\n got %s\nwant %s", body, want)
+		}
+		_, _ = w.Write([]byte(`[{"success":{"id":"6"}}]`))
+	}))
+	defer server.Close()
+
+	addr := strings.TrimPrefix(server.URL, "http://")
+	client := NewClient(addr, "testuser")
+
+	id, err := client.CreateRule(t.Context(), Rule{
+		Name:       "Motion",
+		Conditions: []Condition{{Address: "/sensors/7/state/presence", Operator: OpEqual, Value: "true"}},
+		Actions:    []Action{{Address: "/groups/1/action", Method: http.MethodPut, Body: json.RawMessage(`{"on":true}`)}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id != "6" {
+		t.Errorf("expected ID 6, got %q", id)
+	}
+}
+
+func TestGetFullState(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path != "/api/testuser" {
+			t.Errorf("expected /api/testuser, got %s", r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`{
+			"lights": {
+				"10": {"name":"Desk","type":"Extended color light","state":{"on":true,"bri":200}},
+				"2": {"name":"Hall","type":"Dimmable light","state":{"on":false,"bri":1}}
+			},
+			"groups": {"1": {"name":"Office","type":"Room","lights":["10","2"],"state":{"all_on":false,"any_on":true}}},
+			"scenes": {"abc": {"name":"Focus","group":"1","type":"GroupScene","lights":["10"]}},
+			"sensors": {"1": {"name":"Daylight","type":"Daylight","modelid":"PHDL00"}},
+			"schedules": {"3": {"name":"Wake up","localtime":"W124/T07:00:00","status":"enabled"}},
+			"rules": {"5": {"name":"Motion on","status":"enabled"}},
+			"config": {"name":"Philips hue","bridgeid":"001788FFFE23BFC2","apiversion":"1.65.0","whitelist":{}}
+		}`))
+	}))
+	defer server.Close()
+
+	addr := strings.TrimPrefix(server.URL, "http://")
+	client := NewClient(addr, "testuser")
+
+	state, err := client.GetFullState(t.Context())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if requests.Load() != 1 {
+		t.Errorf("expected a single request, got %d", requests.Load())
+	}
+
+	if len(state.Lights) != 2 || state.Lights[0].ID != "2" || state.Lights[1].Name != "Desk" {
+		t.Errorf("unexpected lights: %+v", state.Lights)
+	}
+	if len(state.Groups) != 1 || state.Groups[0].Name != "Office" || !state.Groups[0].AnyOn {
+		t.Errorf("unexpected groups: %+v", state.Groups)
+	}
+	if len(state.Scenes) != 1 || state.Scenes[0].ID != "abc" {
+		t.Errorf("unexpected scenes: %+v", state.Scenes)
+	}
+	if len(state.Sensors) != 1 || state.Sensors[0].Type != "Daylight" {
+		t.Errorf("unexpected sensors: %+v", state.Sensors)
+	}
+	if len(state.Schedules) != 1 || state.Schedules[0].LocalTime != "W124/T07:00:00" {
+		t.Errorf("unexpected schedules: %+v", state.Schedules)
+	}
+	if len(state.Rules) != 1 || state.Rules[0].Name != "Motion on" {
+		t.Errorf("unexpected rules: %+v", state.Rules)
+	}
+	if state.Config.BridgeID != "001788fffe23bfc2" || state.Config.APIVersion != "1.65.0" {
+		t.Errorf("unexpected config: %+v", state.Config)
+	}
+}
+
+// bridgeCA returns a CA pool and a TLS certificate it issued for the
+// given bridge ID, standing in for the Signify CA.
+func bridgeCA(t *testing.T, bridgeID string) (*x509.CertPool, tls.Certificate) {
+	t.Helper()
+	newKey := func() *ecdsa.PrivateKey {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatalf("generate key: %v", err)
+		}
+		return key
+	}
+
+	caKey := newKey()
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "root-bridge"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("create CA: %v", err)
+	}
+	ca, _ := x509.ParseCertificate(caDER)
+
+	leafKey := newKey()
+	leafTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: bridgeID},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTemplate, ca, &leafKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("create leaf: %v", err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	return pool, tls.Certificate{Certificate: [][]byte{leafDER}, PrivateKey: leafKey}
+}
+
+// configHandler serves /api/config like a bridge.
+var configHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	_, _ = w.Write([]byte(`{"name":"Hue Bridge","bridgeid":"001788FFFE23BFC2"}`))
+})
+
+func TestSignifyRootCA(t *testing.T) {
+	block, _ := pem.Decode([]byte(signifyRootCA))
+	ca, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("parse root CA: %v", err)
+	}
+	if ca.Subject.CommonName != "root-bridge" || ca.CheckSignatureFrom(ca) != nil {
+		t.Errorf("unexpected root CA %s", ca.Subject)
+	}
+}
+
+func TestHTTPS_VerifiesBridgeID(t *testing.T) {
+	roots, cert := bridgeCA(t, "001788FFFE23BFC2")
+	server := httptest.NewUnstartedServer(configHandler)
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	server.StartTLS()
+	defer server.Close()
+	addr := strings.TrimPrefix(server.URL, "https://")
+
+	var pins []string
+	policy := CertPolicy{BridgeID: "001788fffe23bfc2", OnPin: func(fp string) { pins = append(pins, fp) }, roots: roots}
+	if _, err := NewClient(addr, "", WithHTTPS(policy)).GetBridgeConfig(t.Context()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pins) != 1 || pins[0] != CAVerified {
+		t.Errorf("expected the bridge to be marked %s, got pins %v", CAVerified, pins)
+	}
+
+	policy.BridgeID = "001788fffe000000"
+	_, err := NewClient(addr, "", WithHTTPS(policy)).GetBridgeConfig(t.Context())
+	var certErr *CertificateError
+	if !errors.As(err, &certErr) || !strings.Contains(certErr.Reason, "001788fffe23bfc2") {
+		t.Errorf("expected certificate error for another bridge, got %v", err)
+	}
+}
+
+func TestHTTPS_TrustOnFirstUse(t *testing.T) {
+	server := httptest.NewTLSServer(configHandler)
+	defer server.Close()
+	addr := strings.TrimPrefix(server.URL, "https://")
+	want := Fingerprint(server.Certificate().Raw)
+
+	var pins []string
+	client := NewClient(addr, "", WithHTTPS(CertPolicy{OnPin: func(fp string) { pins = append(pins, fp) }}))
+	for range 2 {
+		if _, err := client.GetBridgeConfig(t.Context()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		client.httpClient.CloseIdleConnections() // force a new handshake
+	}
+	if len(pins) != 1 || pins[0] != want {
+		t.Errorf("expected one pin of %s, got %v", want, pins)
+	}
+
+	pinnedClient := NewClient(addr, "", WithHTTPS(CertPolicy{BridgeID: "001788fffe23bfc2", Fingerprint: want}))
+	if _, err := pinnedClient.GetBridgeConfig(t.Context()); err != nil {
+		t.Fatalf("unexpected error with matching pin: %v", err)
+	}
+}
+
+func TestHTTPS_KnownBridgeTrustedOnFirstUse(t *testing.T) {
+	// Issued by a CA other than Signify's, like an older bridge's own.
+	_, cert := bridgeCA(t, "001788FFFE23BFC2")
+	server := httptest.NewUnstartedServer(configHandler)
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	server.StartTLS()
+	defer server.Close()
+	addr := strings.TrimPrefix(server.URL, "https://")
+	want := Fingerprint(server.Certificate().Raw)
+
+	var pins []string
+	client := NewClient(addr, "", WithHTTPS(CertPolicy{BridgeID: "001788fffe23bfc2", OnPin: func(fp string) { pins = append(pins, fp) }}))
+	for range 2 {
+		if _, err := client.GetBridgeConfig(t.Context()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		client.httpClient.CloseIdleConnections() // force a new handshake
+	}
+	if len(pins) != 1 || pins[0] != want {
+		t.Errorf("expected one pin of %s, got %v", want, pins)
+	}
+
+	_, err := NewClient(addr, "", WithHTTPS(CertPolicy{BridgeID: "001788fffe000000"})).GetBridgeConfig(t.Context())
+	var certErr *CertificateError
+	if !errors.As(err, &certErr) || !strings.Contains(certErr.Reason, "001788FFFE23BFC2") {
+		t.Errorf("expected certificate error for another bridge, got %v", err)
+	}
+}
+
+func TestHTTPS_KnownBridgeNotTrustedOnFirstUse(t *testing.T) {
+	server := httptest.NewTLSServer(configHandler)
+	defer server.Close()
+	addr := strings.TrimPrefix(server.URL, "https://")
+
+	tests := []struct {
+		name   string
+		policy CertPolicy
+	}{
+		{"bridge ID not in the certificate", CertPolicy{BridgeID: "001788fffe23bfc2"}},
+		{"CA-verified bridge", CertPolicy{Fingerprint: CAVerified}},
+		{"CA-verified bridge ID", CertPolicy{BridgeID: "001788fffe23bfc2", Fingerprint: CAVerified}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pinned := false
+			tt.policy.OnPin = func(string) { pinned = true }
+			_, err := NewClient(addr, "", WithHTTPS(tt.policy)).GetBridgeConfig(t.Context())
+			var certErr *CertificateError
+			if !errors.As(err, &certErr) {
+				t.Fatalf("expected a certificate error for a self-signed certificate, got %v", err)
+			}
+			if pinned {
+				t.Error("a self-signed certificate should not be pinned")
+			}
+		})
+	}
+}
+
+func TestHTTPS_CertificateChanged(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		configHandler(w, r)
+	}))
+	defer server.Close()
+	addr := strings.TrimPrefix(server.URL, "https://")
+
+	stale := strings.Repeat("AB:", 31) + "AB"
+	client := NewClient(addr, "", WithHTTPS(CertPolicy{Fingerprint: stale}), WithRetryPolicy(RetryPolicy{MaxRetries: 3}))
+	_, err := client.GetBridgeConfig(t.Context())
+	if !errors.Is(err, ErrCertificateChanged) {
+		t.Fatalf("expected ErrCertificateChanged, got %v", err)
+	}
+	if !strings.Contains(err.Error(), stale) {
+		t.Errorf("expected error to name the pinned fingerprint, got %v", err)
+	}
+	if requests.Load() != 0 {
+		t.Errorf("expected no request to reach the bridge, got %d", requests.Load())
+	}
+}
+
+func TestWatch_EventStream(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/eventstream/clip/v2" || r.Header.Get("hue-application-key") != "testuser" {
+			t.Errorf("unexpected request %s with key %q", r.URL.Path, r.Header.Get("hue-application-key"))
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(": hi\n\n" +
+			"id: 1700000000:0\n" +
+			`data: [{"creationtime":"2024-01-01T00:00:00Z","id":"e1","type":"update","data":[` +
+			`{"id":"3f4ac4e9","id_v1":"/lights/1","type":"light","on":{"on":false},"dimming":{"brightness":100.0}},` +
+			`{"id":"b6b5e5b8","id_v1":"/lights/1","type":"device","metadata":{"name":"Desk"}},` +
+			`{"id":"f7c2e1a0","id_v1":"/groups/2","type":"grouped_light","on":{"on":true}}]}]` + "\n\n" +
+			"id: 1700000001:0\n" +
+			`data: [{"id":"e2","type":"add","data":[{"id":"5e1c4a2b","id_v1":"/scenes/abc","type":"scene"}]}]` + "\n\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	addr := strings.TrimPrefix(server.URL, "https://")
+	client := NewClient(addr, "testuser", WithHTTPS(CertPolicy{}))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	var events []Event
+	err := client.Watch(ctx, func(e Event) {
+		events = append(events, e)
+		if len(events) == 3 {
+			cancel()
+		}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d: %+v", len(events), events)
+	}
+
+	light, ok := events[0].(LightEvent)
+	if !ok || light.ID != "1" || light.On == nil || *light.On || light.Brightness == nil || *light.Brightness != 254 || light.Name != nil {
+		t.Errorf("unexpected light event: %+v", events[0])
+	}
+	group, ok := events[1].(GroupEvent)
+	if !ok || group.ID != "2" || group.AnyOn == nil || !*group.AnyOn {
+		t.Errorf("unexpected group event: %+v", events[1])
+	}
+	if _, ok := events[2].(ResyncEvent); !ok {
+		t.Errorf("expected ResyncEvent for an added scene, got %+v", events[2])
+	}
+}
+
+func TestWatch_PollsUntilEventStreamConnects(t *testing.T) {
+	originalPoll, originalRetry := pollInterval, streamRetryPolicy
+	pollInterval = 10 * time.Millisecond
+	streamRetryPolicy = RetryPolicy{BaseDelay: 50 * time.Millisecond, MaxDelay: 50 * time.Millisecond}
+	t.Cleanup(func() { pollInterval, streamRetryPolicy = originalPoll, originalRetry })
+
+	var streamAttempts, polls atomic.Int32
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/eventstream/clip/v2" {
+			polls.Add(1)
+			_, _ = w.Write([]byte(`{"lights":{},"groups":{},"scenes":{}}`))
+			return
+		}
+		if streamAttempts.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(`data: [{"id":"e1","type":"update","data":[{"id":"3f4ac4e9","id_v1":"/lights/1","type":"light","on":{"on":true}}]}]` + "\n\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	addr := strings.TrimPrefix(server.URL, "https://")
+	client := NewClient(addr, "testuser", WithHTTPS(CertPolicy{}))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	var events []Event
+	err := client.Watch(ctx, func(e Event) {
+		events = append(events, e)
+		if len(events) == 2 {
+			cancel()
+		}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if streamAttempts.Load() != 3 || polls.Load() == 0 {
+		t.Errorf("expected polling between 3 stream attempts, got %d attempts and %d polls", streamAttempts.Load(), polls.Load())
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %+v", events)
+	}
+	if _, ok := events[0].(ResyncEvent); !ok {
+		t.Errorf("expected a ResyncEvent once the stream connects, got %+v", events[0])
+	}
+	if light, ok := events[1].(LightEvent); !ok || light.ID != "1" {
+		t.Errorf("unexpected light event: %+v", events[1])
+	}
+}
+
+func TestWatch_Unauthorized(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	client := NewClient(strings.TrimPrefix(server.URL, "https://"), "revoked", WithHTTPS(CertPolicy{}))
+	err := client.Watch(t.Context(), func(Event) {})
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized, got %v", err)
+	}
+}
+
+func TestWatch_PollsWithoutEventStream(t *testing.T) {
+	original := pollInterval
+	pollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { pollInterval = original })
+
+	var polls atomic.Int32
+	// Plain HTTP, so the event stream can't connect and Watch polls.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		on := polls.Add(1) == 1
+		_, _ = fmt.Fprintf(w, `{
+			"lights": {"1": {"name":"Desk","state":{"on":%t,"bri":200}}},
+			"groups": {"2": {"name":"Office","lights":["1"],"state":{"all_on":%t,"any_on":%t}}},
+			"scenes": {}
+		}`, on, on, on)
+	}))
+	defer server.Close()
+
+	client := NewClient(strings.TrimPrefix(server.URL, "http://"), "testuser")
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	var events []Event
+	err := client.Watch(ctx, func(e Event) {
+		events = append(events, e)
+		if len(events) == 2 {
+			cancel()
+		}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %+v", events)
+	}
+	light := events[0].(LightEvent)
+	if light.ID != "1" || *light.On || light.Brightness != nil {
+		t.Errorf("unexpected light event: %+v", light)
+	}
+	group := events[1].(GroupEvent)
+	if group.ID != "2" || *group.AnyOn || *group.AllOn || group.Name != nil {
+		t.Errorf("unexpected group event: %+v", group)
+	}
+}
+
+func TestDiffState(t *testing.T) {
+	old := &BridgeState{
+		Lights: []Light{{ID: "1", Name: "Desk", On: true}},
+		Scenes: []Scene{{ID: "abc", Name: "Focus"}},
+	}
+
+	renamed := &BridgeState{
+		Lights: []Light{{ID: "1", Name: "Desk", On: true}},
+		Scenes: []Scene{{ID: "abc", Name: "Read"}},
+	}
+	events := diffState(old, renamed)
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %+v", events)
+	}
+	if scene, ok := events[0].(SceneEvent); !ok || scene.ID != "abc" || *scene.Name != "Read" {
+		t.Errorf("unexpected event: %+v", events[0])
+	}
+
+	added := &BridgeState{
+		Lights: []Light{{ID: "1", Name: "Desk", On: false}, {ID: "2", Name: "Hall"}},
+		Scenes: old.Scenes,
+	}
+	if events := diffState(old, added); len(events) != 1 || events[0] != (ResyncEvent{}) {
+		t.Errorf("expected a single ResyncEvent, got %+v", events)
+	}
+
+	if events := diffState(old, old); len(events) != 0 {
+		t.Errorf("expected no events, got %+v", events)
+	}
+
+	motion := &BridgeState{
+		Lights:  old.Lights,
+		Scenes:  old.Scenes,
+		Sensors: []Sensor{{ID: "5", RawState: map[string]any{"presence": true}}},
+	}
+	before := &BridgeState{Lights: old.Lights, Scenes: old.Scenes, Sensors: []Sensor{{ID: "5", RawState: map[string]any{"presence": false}}}}
+	if events := diffState(before, motion); len(events) != 1 || events[0] != (SensorEvent{ID: "5"}) {
+		t.Errorf("expected a SensorEvent, got %+v", events)
+	}
+}
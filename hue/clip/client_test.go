@@ -0,0 +1,198 @@
+package clip
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/LarsEckart/huey/hue"
+)
+
+const testKey = "abc123"
+
+// fakeBridge serves handler over TLS like a bridge and returns a client
+// for it. Requests without the application key are rejected.
+func fakeBridge(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("hue-application-key") != testKey {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":[{"description":"unauthorized user"}],"data":[]}`))
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	addr := strings.TrimPrefix(server.URL, "https://")
+	return NewClient(addr, testKey, WithHTTPClient(server.Client()))
+}
+
+func TestGetLights(t *testing.T) {
+	client := fakeBridge(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/clip/v2/resource/light" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`{"errors":[],"data":[
+			{"id":"3f4ac4e9-d67a-4dbd-8a16-5ea7e373f281","id_v1":"/lights/1","type":"light",
+			 "owner":{"rid":"b6b5e5b8-2a3f-4d3c-9f44-3c7d1d8a9a10","rtype":"device"},
+			 "metadata":{"name":"Desk Lamp","archetype":"sultan_bulb"},
+			 "on":{"on":true},"dimming":{"brightness":50.2},
+			 "color_temperature":{"mirek":null,"mirek_valid":false},
+			 "color":{"xy":{"x":0.4573,"y":0.41},"gamut_type":"C"}},
+			{"id":"9b0d3a44-7c1e-4f6b-a8d2-2e5f0c9d1b7e","type":"light",
+			 "owner":{"rid":"0a1b2c3d-0000-4000-8000-000000000001","rtype":"device"},
+			 "metadata":{"name":"Plug","archetype":"plug"},"on":{"on":false}}
+		]}`))
+	})
+
+	lights, err := client.GetLights(t.Context())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(lights) != 2 {
+		t.Fatalf("expected 2 lights, got %d", len(lights))
+	}
+
+	desk := lights[0]
+	if desk.ID != "3f4ac4e9-d67a-4dbd-8a16-5ea7e373f281" || desk.Metadata.Name != "Desk Lamp" || !desk.On.On {
+		t.Errorf("unexpected light: %+v", desk)
+	}
+	if desk.Dimming == nil || desk.Dimming.Brightness != 50.2 {
+		t.Errorf("expected brightness 50.2, got %+v", desk.Dimming)
+	}
+	if desk.Color == nil || desk.Color.XY.X != 0.4573 || desk.Color.GamutType != "C" {
+		t.Errorf("unexpected color: %+v", desk.Color)
+	}
+	if desk.ColorTemperature == nil || desk.ColorTemperature.Mirek != nil {
+		t.Errorf("expected null mirek, got %+v", desk.ColorTemperature)
+	}
+
+	if plug := lights[1]; plug.Dimming != nil || plug.Color != nil {
+		t.Errorf("expected on/off-only light, got %+v", plug)
+	}
+}
+
+func TestGetLight_NotFound(t *testing.T) {
+	client := fakeBridge(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"errors":[{"description":"Not Found"}],"data":[]}`))
+	})
+
+	_, err := client.GetLight(t.Context(), "00000000-0000-4000-8000-000000000000")
+	if !errors.Is(err, hue.ErrResourceNotAvailable) {
+		t.Errorf("expected ErrResourceNotAvailable, got %v", err)
+	}
+}
+
+func TestUnauthorized(t *testing.T) {
+	client := fakeBridge(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request should have been rejected")
+	})
+	client.appKey = "revoked"
+
+	_, err := client.GetRooms(t.Context())
+	if !errors.Is(err, hue.ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized, got %v", err)
+	}
+}
+
+func TestNewClient_VerifiesCertificate(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		_, _ = w.Write([]byte(`{"errors":[],"data":[]}`))
+	}))
+	defer server.Close()
+	addr := strings.TrimPrefix(server.URL, "https://")
+
+	_, err := NewClient(addr, testKey).GetLights(t.Context())
+	var certErr *hue.CertificateError
+	if !errors.As(err, &certErr) {
+		t.Errorf("expected a certificate error for a self-signed certificate, got %v", err)
+	}
+	if requests.Load() != 0 {
+		t.Errorf("expected the key not to reach an untrusted server, got %d requests", requests.Load())
+	}
+
+	pinned := hue.CertPolicy{Fingerprint: hue.Fingerprint(server.Certificate().Raw)}
+	if _, err := NewClient(addr, testKey, WithTLSConfig(pinned.TLSConfig())).GetLights(t.Context()); err != nil {
+		t.Errorf("unexpected error with the pinned certificate: %v", err)
+	}
+}
+
+func TestUpdateLight(t *testing.T) {
+	var body map[string]any
+	client := fakeBridge(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			t.Errorf("expected PUT, got %s", r.Method)
+		}
+		if r.URL.Path != "/clip/v2/resource/light/3f4ac4e9-d67a-4dbd-8a16-5ea7e373f281" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		_, _ = w.Write([]byte(`{"errors":[],"data":[{"rid":"3f4ac4e9-d67a-4dbd-8a16-5ea7e373f281","rtype":"light"}]}`))
+	})
+
+	mirek := 366
+	err := client.UpdateLight(t.Context(), "3f4ac4e9-d67a-4dbd-8a16-5ea7e373f281", LightUpdate{
+		On:               &On{On: true},
+		ColorTemperature: &ColorTemperature{Mirek: &mirek},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `{"color_temperature":{"mirek":366},"on":{"on":true}}`
+	if got, _ := json.Marshal(body); string(got) != want {
+		t.Errorf("body = %s, want %s", got, want)
+	}
+}
+
+func TestUpdateGroupedLight_PartialFailure(t *testing.T) {
+	client := fakeBridge(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMultiStatus)
+		_, _ = w.Write([]byte(`{"errors":[{"description":"device (grouped_light) is \"soft off\", command (.on) may not have effect"}],"data":[]}`))
+	})
+
+	err := client.UpdateGroupedLight(t.Context(), "f7c2e1a0-5b4d-4c3e-9a8f-1e2d3c4b5a69", LightUpdate{On: &On{On: true}})
+	if err == nil || !strings.Contains(err.Error(), "soft off") {
+		t.Errorf("expected the bridge's description, got %v", err)
+	}
+}
+
+func TestRecallScene(t *testing.T) {
+	client := fakeBridge(t, func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Recall struct {
+				Action string `json:"action"`
+			} `json:"recall"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if r.URL.Path != "/clip/v2/resource/scene/5e1c4a2b-8d7f-4e3a-b6c9-0f1e2d3c4b5a" || body.Recall.Action != "active" {
+			t.Errorf("unexpected request %s %+v", r.URL.Path, body)
+		}
+		_, _ = w.Write([]byte(`{"errors":[],"data":[]}`))
+	})
+
+	if err := client.RecallScene(t.Context(), "5e1c4a2b-8d7f-4e3a-b6c9-0f1e2d3c4b5a"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestGroup_GroupedLight(t *testing.T) {
+	room := Group{Services: []ResourceRef{
+		{RID: "a", RType: "motion"},
+		{RID: "b", RType: "grouped_light"},
+	}}
+	if got := room.GroupedLight(); got != "b" {
+		t.Errorf("GroupedLight() = %q, want b", got)
+	}
+	if got := (Group{}).GroupedLight(); got != "" {
+		t.Errorf("GroupedLight() = %q, want empty", got)
+	}
+}
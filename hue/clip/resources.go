@@ -0,0 +1,130 @@
+package clip
+
+// ResourceRef points to another resource.
+type ResourceRef struct {
+	RID   string `json:"rid"`   // resource UUID
+	RType string `json:"rtype"` // resource type, e.g. "light" or "grouped_light"
+}
+
+// Metadata holds the user-facing details of a resource.
+type Metadata struct {
+	Name      string `json:"name"`
+	Archetype string `json:"archetype,omitempty"` // e.g. "sultan_bulb" or "living_room"
+}
+
+// On is the on/off state of a light or group.
+type On struct {
+	On bool `json:"on"`
+}
+
+// Dimming is the brightness of a light or group.
+type Dimming struct {
+	Brightness float64 `json:"brightness"` // percent, 0-100
+}
+
+// ColorTemperature is the white color temperature of a light.
+type ColorTemperature struct {
+	Mirek      *int `json:"mirek,omitempty"`       // 153-500, nil when the light is in color mode
+	MirekValid bool `json:"mirek_valid,omitempty"` // only in responses
+}
+
+// XY is a point in the CIE 1931 color space.
+type XY struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// Color is the color of a light.
+type Color struct {
+	XY        XY     `json:"xy"`
+	GamutType string `json:"gamut_type,omitempty"` // "A", "B", "C" or "other"; only in responses
+}
+
+// Dynamics controls how a state change is applied.
+type Dynamics struct {
+	Duration int `json:"duration"` // transition time in milliseconds
+}
+
+// Light is a light service of a device.
+type Light struct {
+	ID               string            `json:"id"`
+	IDV1             string            `json:"id_v1,omitempty"` // v1 address, e.g. "/lights/3"
+	Owner            ResourceRef       `json:"owner"`           // the device the light belongs to
+	Metadata         Metadata          `json:"metadata"`
+	On               On                `json:"on"`
+	Dimming          *Dimming          `json:"dimming,omitempty"`           // nil for on/off-only lights
+	ColorTemperature *ColorTemperature `json:"color_temperature,omitempty"` // nil without white ambiance
+	Color            *Color            `json:"color,omitempty"`             // nil without color
+}
+
+// Group is a room or zone. Rooms contain devices and zones contain lights.
+type Group struct {
+	ID       string        `json:"id"`
+	IDV1     string        `json:"id_v1,omitempty"` // v1 address, e.g. "/groups/1"
+	Metadata Metadata      `json:"metadata"`
+	Children []ResourceRef `json:"children"`
+	Services []ResourceRef `json:"services"`
+}
+
+// GroupedLight returns the ID of the group's grouped_light service, used
+// to control all its lights at once, or "" if it has none.
+func (g Group) GroupedLight() string {
+	for _, service := range g.Services {
+		if service.RType == "grouped_light" {
+			return service.RID
+		}
+	}
+	return ""
+}
+
+// GroupedLight controls all lights of a room, zone or the whole bridge.
+type GroupedLight struct {
+	ID      string      `json:"id"`
+	IDV1    string      `json:"id_v1,omitempty"`
+	Owner   ResourceRef `json:"owner"` // the room, zone or bridge_home it belongs to
+	On      *On         `json:"on,omitempty"`
+	Dimming *Dimming    `json:"dimming,omitempty"`
+}
+
+// SceneAction is the state a scene sets on one light.
+type SceneAction struct {
+	Target ResourceRef `json:"target"`
+	Action LightUpdate `json:"action"`
+}
+
+// Scene is a stored set of light states for a room or zone.
+type Scene struct {
+	ID       string        `json:"id"`
+	IDV1     string        `json:"id_v1,omitempty"`
+	Metadata Metadata      `json:"metadata"`
+	Group    ResourceRef   `json:"group"`
+	Actions  []SceneAction `json:"actions"`
+}
+
+// ProductData describes the hardware of a device.
+type ProductData struct {
+	ModelID          string `json:"model_id"`
+	ManufacturerName string `json:"manufacturer_name"`
+	ProductName      string `json:"product_name"`
+	SoftwareVersion  string `json:"software_version"`
+}
+
+// Device is a physical device, such as a bulb, switch or the bridge. Its
+// functions are exposed as services, e.g. a light.
+type Device struct {
+	ID          string        `json:"id"`
+	IDV1        string        `json:"id_v1,omitempty"`
+	Metadata    Metadata      `json:"metadata"`
+	ProductData ProductData   `json:"product_data"`
+	Services    []ResourceRef `json:"services"`
+}
+
+// LightUpdate is a change to the state of a light or grouped light.
+// Nil fields are left unchanged.
+type LightUpdate struct {
+	On               *On               `json:"on,omitempty"`
+	Dimming          *Dimming          `json:"dimming,omitempty"`
+	ColorTemperature *ColorTemperature `json:"color_temperature,omitempty"`
+	Color            *Color            `json:"color,omitempty"`
+	Dynamics         *Dynamics         `json:"dynamics,omitempty"`
+}
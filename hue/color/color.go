@@ -0,0 +1,260 @@
+// Package color converts between the color notations people use (hex codes,
+// RGB, HSV, CSS names, Kelvin) and the CIE xy chromaticity the Hue bridge
+// expects, taking the gamut of the target bulb into account.
+package color
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// XY is a point in the CIE 1931 color space.
+type XY struct {
+	X float64
+	Y float64
+}
+
+// RGB is an 8-bit sRGB color.
+type RGB struct {
+	R uint8
+	G uint8
+	B uint8
+}
+
+// whitePoint is the D65 white point, used for black and invalid input.
+var whitePoint = XY{X: 0.3127, Y: 0.3290}
+
+// Kelvin range covered by the color temperature approximation.
+const (
+	minKelvin = 1667
+	maxKelvin = 25000
+)
+
+// Parse interprets a color given as a hex code ("#ff8800", "f80"),
+// "rgb(255,136,0)", "hsv(32,100,100)", a color temperature ("2700K"),
+// a named white ("warm white") or a CSS color name, and returns its xy
+// coordinates clamped to the given gamut.
+func Parse(value string, gamut Gamut) (XY, error) {
+	text := strings.ToLower(strings.TrimSpace(value))
+	if text == "" {
+		return XY{}, fmt.Errorf("color cannot be empty")
+	}
+
+	if kelvin, ok := namedWhites[text]; ok {
+		return gamut.Clamp(KelvinToXY(kelvin)), nil
+	}
+	if rgb, ok := cssColors[strings.ReplaceAll(text, " ", "")]; ok {
+		return rgb.XY(gamut), nil
+	}
+
+	switch {
+	case strings.HasPrefix(text, "rgb(") && strings.HasSuffix(text, ")"):
+		values, err := parseComponents(text[len("rgb(") : len(text)-1])
+		if err != nil {
+			return XY{}, fmt.Errorf("invalid rgb color %q: %w", value, err)
+		}
+		for _, v := range values {
+			if v < 0 || v > 255 {
+				return XY{}, fmt.Errorf("invalid rgb color %q: components must be between 0 and 255", value)
+			}
+		}
+		rgb := RGB{R: uint8(values[0]), G: uint8(values[1]), B: uint8(values[2])}
+		return rgb.XY(gamut), nil
+
+	case strings.HasPrefix(text, "hsv(") && strings.HasSuffix(text, ")"):
+		values, err := parseComponents(text[len("hsv(") : len(text)-1])
+		if err != nil {
+			return XY{}, fmt.Errorf("invalid hsv color %q: %w", value, err)
+		}
+		if values[0] < 0 || values[0] > 360 || values[1] < 0 || values[1] > 100 || values[2] < 0 || values[2] > 100 {
+			return XY{}, fmt.Errorf("invalid hsv color %q: want hue 0-360, saturation and value 0-100", value)
+		}
+		return FromHSV(values[0], values[1]/100, values[2]/100).XY(gamut), nil
+
+	case strings.HasSuffix(text, "k"):
+		kelvin, err := strconv.Atoi(strings.TrimSuffix(text, "k"))
+		if err != nil || kelvin <= 0 {
+			return XY{}, fmt.Errorf("invalid color temperature %q", value)
+		}
+		return gamut.Clamp(KelvinToXY(kelvin)), nil
+	}
+
+	rgb, err := ParseHex(text)
+	if err != nil {
+		return XY{}, fmt.Errorf("unknown color %q: use a hex code, rgb(), hsv(), a Kelvin value like 2700K, or a color name", value)
+	}
+	return rgb.XY(gamut), nil
+}
+
+// parseComponents parses three comma-separated numbers.
+func parseComponents(text string) ([3]float64, error) {
+	var values [3]float64
+
+	parts := strings.Split(text, ",")
+	if len(parts) != 3 {
+		return values, fmt.Errorf("expected 3 components, got %d", len(parts))
+	}
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(part), "%"), 64)
+		if err != nil {
+			return values, fmt.Errorf("component %q is not a number", strings.TrimSpace(part))
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// ParseHex parses "#rrggbb", "rrggbb", "#rgb" or "rgb".
+func ParseHex(value string) (RGB, error) {
+	hex := strings.TrimPrefix(strings.TrimSpace(value), "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return RGB{}, fmt.Errorf("invalid hex color %q", value)
+	}
+
+	n, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return RGB{}, fmt.Errorf("invalid hex color %q", value)
+	}
+	return RGB{R: uint8(n >> 16), G: uint8(n >> 8), B: uint8(n)}, nil
+}
+
+// Hex formats the color as "#rrggbb".
+func (c RGB) Hex() string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// XY converts the color to CIE xy, clamped to the given gamut.
+func (c RGB) XY(gamut Gamut) XY {
+	r := linearize(float64(c.R) / 255)
+	g := linearize(float64(c.G) / 255)
+	b := linearize(float64(c.B) / 255)
+
+	// sRGB to CIE XYZ (D65).
+	x := r*0.4124 + g*0.3576 + b*0.1805
+	y := r*0.2126 + g*0.7152 + b*0.0722
+	z := r*0.0193 + g*0.1192 + b*0.9505
+
+	sum := x + y + z
+	if sum == 0 {
+		return gamut.Clamp(whitePoint)
+	}
+	return gamut.Clamp(XY{X: x / sum, Y: y / sum})
+}
+
+// RGB converts xy back to the brightest sRGB color with that chromaticity,
+// for display purposes.
+func (p XY) RGB() RGB {
+	if p.Y <= 0 {
+		return RGB{}
+	}
+
+	// CIE XYZ at full luminance.
+	x := p.X / p.Y
+	z := (1 - p.X - p.Y) / p.Y
+
+	r := x*3.2406 - 1.5372 - z*0.4986
+	g := -x*0.9689 + 1.8758 + z*0.0415
+	b := x*0.0557 - 0.2040 + z*1.0570
+
+	r, g, b = max(r, 0), max(g, 0), max(b, 0)
+	if peak := max(r, g, b); peak > 0 {
+		r, g, b = r/peak, g/peak, b/peak
+	}
+
+	return RGB{
+		R: uint8(math.Round(compand(r) * 255)),
+		G: uint8(math.Round(compand(g) * 255)),
+		B: uint8(math.Round(compand(b) * 255)),
+	}
+}
+
+// Kelvin estimates the correlated color temperature of xy (McCamy's formula).
+func (p XY) Kelvin() int {
+	n := (p.X - 0.3320) / (0.1858 - p.Y)
+	return int(math.Round(449*n*n*n + 3525*n*n + 6823.3*n + 5520.33))
+}
+
+// FromHSV converts hue (0-360 degrees), saturation and value (0-1) to RGB.
+func FromHSV(h, s, v float64) RGB {
+	h = math.Mod(h, 360)
+	c := v * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := v - c
+
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+
+	return RGB{
+		R: uint8(math.Round((r + m) * 255)),
+		G: uint8(math.Round((g + m) * 255)),
+		B: uint8(math.Round((b + m) * 255)),
+	}
+}
+
+// KelvinToXY returns the xy coordinates of a black body at the given
+// temperature, using the Kim et al. cubic spline approximation.
+// Temperatures are clamped to 1667K-25000K.
+func KelvinToXY(kelvin int) XY {
+	t := float64(min(max(kelvin, minKelvin), maxKelvin))
+
+	var x float64
+	if t <= 4000 {
+		x = -0.2661239e9/(t*t*t) - 0.2343589e6/(t*t) + 0.8776956e3/t + 0.179910
+	} else {
+		x = -3.0258469e9/(t*t*t) + 2.1070379e6/(t*t) + 0.2226347e3/t + 0.240390
+	}
+
+	var y float64
+	switch {
+	case t <= 2222:
+		y = -1.1063814*x*x*x - 1.34811020*x*x + 2.18555832*x - 0.20219683
+	case t <= 4000:
+		y = -0.9549476*x*x*x - 1.37418593*x*x + 2.09137015*x - 0.16748867
+	default:
+		y = 3.0817580*x*x*x - 5.87338670*x*x + 3.75112997*x - 0.37001483
+	}
+
+	return XY{X: x, Y: y}
+}
+
+// MiredToKelvin converts a mired color temperature to Kelvin.
+func MiredToKelvin(mired int) int {
+	if mired <= 0 {
+		return 0
+	}
+	return int(math.Round(1_000_000 / float64(mired)))
+}
+
+// linearize applies the inverse sRGB companding.
+func linearize(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+// compand applies the sRGB companding.
+func compand(v float64) float64 {
+	if v <= 0.0031308 {
+		return v * 12.92
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
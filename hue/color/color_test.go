@@ -0,0 +1,117 @@
+package color
+
+import (
+	"math"
+	"testing"
+)
+
+func approxEqual(a, b XY, tolerance float64) bool {
+	return math.Abs(a.X-b.X) <= tolerance && math.Abs(a.Y-b.Y) <= tolerance
+}
+
+func TestParse_Formats(t *testing.T) {
+	red := RGB{R: 255}.XY(GamutC)
+
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "hex with hash", input: "#ff0000"},
+		{name: "hex without hash", input: "FF0000"},
+		{name: "short hex", input: "#f00"},
+		{name: "rgb", input: "rgb(255, 0, 0)"},
+		{name: "hsv", input: "hsv(0, 100, 100)"},
+		{name: "css name", input: "Red"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input, GamutC)
+			if err != nil {
+				t.Fatalf("Parse(%q) unexpected error: %v", tt.input, err)
+			}
+			if !approxEqual(got, red, 0.0001) {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.input, got, red)
+			}
+		})
+	}
+}
+
+func TestParse_Whites(t *testing.T) {
+	warm, err := Parse("warm white", GamutC)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	kelvin, err := Parse("2700K", GamutC)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if warm != kelvin {
+		t.Errorf("warm white %+v != 2700K %+v", warm, kelvin)
+	}
+
+	// 2700K sits at roughly (0.460, 0.411) on the Planckian locus.
+	if !approxEqual(warm, XY{X: 0.460, Y: 0.411}, 0.005) {
+		t.Errorf("2700K = %+v, want about (0.460, 0.411)", warm)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, input := range []string{"", "notacolor", "#12345", "rgb(300,0,0)", "hsv(0,200,0)", "abcK"} {
+		if _, err := Parse(input, GamutC); err == nil {
+			t.Errorf("Parse(%q) expected error, got nil", input)
+		}
+	}
+}
+
+func TestGamutClamp(t *testing.T) {
+	// Pure sRGB green lies outside gamut B.
+	outside := XY{X: 0.3, Y: 0.6}
+	if GamutB.Contains(outside) {
+		t.Fatalf("expected %+v to be outside gamut B", outside)
+	}
+
+	clamped := GamutB.Clamp(outside)
+	if !GamutB.Contains(clamped) {
+		t.Errorf("clamped point %+v is outside gamut B", clamped)
+	}
+
+	inside := XY{X: 0.4, Y: 0.4}
+	if got := GamutB.Clamp(inside); got != inside {
+		t.Errorf("Clamp moved a point inside the gamut: %+v -> %+v", inside, got)
+	}
+}
+
+func TestRGBRoundTrip(t *testing.T) {
+	// xy drops luminance, so use colors at full brightness.
+	for _, hex := range []string{"#ff8800", "#0000ff", "#ffffff", "#3366ff"} {
+		rgb, err := ParseHex(hex)
+		if err != nil {
+			t.Fatalf("ParseHex(%q) unexpected error: %v", hex, err)
+		}
+
+		// Use a gamut wide enough not to clamp sRGB colors.
+		wide := Gamut{Red: XY{X: 1, Y: 0}, Green: XY{X: 0, Y: 1}, Blue: XY{X: 0, Y: 0}}
+		back := rgb.XY(wide).RGB()
+
+		if diff(back.R, rgb.R) > 2 || diff(back.G, rgb.G) > 2 || diff(back.B, rgb.B) > 2 {
+			t.Errorf("%s round-tripped to %s", hex, back.Hex())
+		}
+	}
+}
+
+func TestKelvinRoundTrip(t *testing.T) {
+	for _, kelvin := range []int{2200, 2700, 4000, 6500} {
+		got := KelvinToXY(kelvin).Kelvin()
+		if math.Abs(float64(got-kelvin)) > 50 {
+			t.Errorf("KelvinToXY(%d).Kelvin() = %d", kelvin, got)
+		}
+	}
+}
+
+func diff(a, b uint8) int {
+	if a > b {
+		return int(a - b)
+	}
+	return int(b - a)
+}
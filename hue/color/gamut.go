@@ -0,0 +1,95 @@
+package color
+
+import "strings"
+
+// Gamut is the triangle of colors a bulb can reproduce.
+type Gamut struct {
+	Red   XY
+	Green XY
+	Blue  XY
+}
+
+// Gamuts used by Hue bulbs, as reported in the light's
+// capabilities.control.colorgamuttype.
+var (
+	// GamutA covers LivingColors and older Hue accessories.
+	GamutA = Gamut{
+		Red:   XY{X: 0.704, Y: 0.296},
+		Green: XY{X: 0.2151, Y: 0.7106},
+		Blue:  XY{X: 0.138, Y: 0.08},
+	}
+	// GamutB covers first generation Hue bulbs.
+	GamutB = Gamut{
+		Red:   XY{X: 0.675, Y: 0.322},
+		Green: XY{X: 0.409, Y: 0.518},
+		Blue:  XY{X: 0.167, Y: 0.04},
+	}
+	// GamutC covers current Hue color bulbs.
+	GamutC = Gamut{
+		Red:   XY{X: 0.6915, Y: 0.3083},
+		Green: XY{X: 0.17, Y: 0.7},
+		Blue:  XY{X: 0.1532, Y: 0.0475},
+	}
+)
+
+// GamutFor returns the gamut for a colorgamuttype ("A", "B" or "C").
+// Unknown types fall back to GamutC, which the bridge clamps as needed.
+func GamutFor(gamutType string) Gamut {
+	switch strings.ToUpper(gamutType) {
+	case "A":
+		return GamutA
+	case "B":
+		return GamutB
+	default:
+		return GamutC
+	}
+}
+
+// Contains reports whether p lies inside the gamut triangle.
+func (g Gamut) Contains(p XY) bool {
+	d1 := cross(p, g.Red, g.Green)
+	d2 := cross(p, g.Green, g.Blue)
+	d3 := cross(p, g.Blue, g.Red)
+
+	hasNeg := d1 < 0 || d2 < 0 || d3 < 0
+	hasPos := d1 > 0 || d2 > 0 || d3 > 0
+	return !(hasNeg && hasPos)
+}
+
+// Clamp returns p if it lies within the gamut, otherwise the closest point
+// on the gamut's edge.
+func (g Gamut) Clamp(p XY) XY {
+	if g.Contains(p) {
+		return p
+	}
+
+	candidates := []XY{
+		closestOnSegment(p, g.Red, g.Green),
+		closestOnSegment(p, g.Green, g.Blue),
+		closestOnSegment(p, g.Blue, g.Red),
+	}
+
+	best := candidates[0]
+	for _, c := range candidates[1:] {
+		if distanceSquared(p, c) < distanceSquared(p, best) {
+			best = c
+		}
+	}
+	return best
+}
+
+func cross(p, a, b XY) float64 {
+	return (p.X-b.X)*(a.Y-b.Y) - (a.X-b.X)*(p.Y-b.Y)
+}
+
+func closestOnSegment(p, a, b XY) XY {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	t := ((p.X-a.X)*dx + (p.Y-a.Y)*dy) / (dx*dx + dy*dy)
+	t = min(max(t, 0), 1)
+	return XY{X: a.X + t*dx, Y: a.Y + t*dy}
+}
+
+func distanceSquared(a, b XY) float64 {
+	dx, dy := a.X-b.X, a.Y-b.Y
+	return dx*dx + dy*dy
+}
@@ -0,0 +1,180 @@
+// Package discovery finds Hue bridges on the local network using mDNS,
+// SSDP and, optionally, a probe of every address in the local subnets.
+package discovery
+
+import (
+	"cmp"
+	"context"
+	"net"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LarsEckart/huey/hue"
+)
+
+// Bridge is a Hue bridge found on the network.
+type Bridge struct {
+	IP      string
+	ID      string // bridge ID, 16 lowercase hex digits
+	Name    string
+	ModelID string
+	Source  string // "mdns", "ssdp" or "probe"
+}
+
+// Default multicast addresses and timing.
+const (
+	DefaultMDNSAddr = "224.0.0.251:5353"
+	DefaultSSDPAddr = "239.255.255.250:1900"
+	DefaultTimeout  = 3 * time.Second
+)
+
+// Discoverer searches for bridges. The zero value uses mDNS and SSDP on
+// their standard multicast addresses. Addresses and ports can be pointed
+// at local responders for testing.
+type Discoverer struct {
+	MDNSAddr string        // mDNS destination, default DefaultMDNSAddr
+	SSDPAddr string        // SSDP destination, default DefaultSSDPAddr
+	Timeout  time.Duration // how long to collect responses, default DefaultTimeout
+
+	// Probe enables a scan of every host in Subnets for /api/config.
+	Probe bool
+	// Subnets to probe. Defaults to the IPv4 networks of the local
+	// interfaces, limited to /22 and smaller.
+	Subnets []netip.Prefix
+	// HTTPPort is the port used to query /api/config, default 80.
+	HTTPPort int
+}
+
+// Discover runs all enabled discovery methods concurrently and returns the
+// bridges found, one entry per bridge, verified and described via
+// /api/config where reachable.
+func Discover(ctx context.Context) ([]Bridge, error) {
+	return (&Discoverer{}).Discover(ctx)
+}
+
+// Discover runs all enabled discovery methods concurrently.
+func (d *Discoverer) Discover(ctx context.Context) ([]Bridge, error) {
+	// Methods listen for answers until the timeout; describing the
+	// bridges afterwards uses the caller's context.
+	listenCtx, cancel := context.WithTimeout(ctx, cmp.Or(d.Timeout, DefaultTimeout))
+	defer cancel()
+
+	var (
+		mu      sync.Mutex
+		found   []Bridge
+		errs    []error
+		wg      sync.WaitGroup
+		methods = []func(context.Context) ([]Bridge, error){d.mdns, d.ssdp}
+	)
+	if d.Probe {
+		methods = append(methods, d.probe)
+	}
+
+	for _, method := range methods {
+		wg.Go(func() {
+			bridges, err := method(listenCtx)
+			mu.Lock()
+			defer mu.Unlock()
+			found = append(found, bridges...)
+			if err != nil {
+				errs = append(errs, err)
+			}
+		})
+	}
+	wg.Wait()
+
+	bridges := d.describe(ctx, merge(found))
+
+	// Only report errors when every method failed to find anything.
+	if len(bridges) == 0 && len(errs) == len(methods) {
+		return nil, errs[0]
+	}
+	return bridges, nil
+}
+
+// FindByID discovers bridges and returns the one with the given bridge ID.
+func (d *Discoverer) FindByID(ctx context.Context, bridgeID string) (*Bridge, error) {
+	bridges, err := d.Discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, b := range bridges {
+		if strings.EqualFold(b.ID, bridgeID) {
+			return &b, nil
+		}
+	}
+	return nil, &NotFoundError{BridgeID: bridgeID}
+}
+
+// NotFoundError is returned by FindByID when no bridge with the ID answered.
+type NotFoundError struct {
+	BridgeID string
+}
+
+func (e *NotFoundError) Error() string {
+	return "bridge " + e.BridgeID + " not found on the network"
+}
+
+// merge combines results from several methods, keeping one entry per
+// bridge ID (or per IP when the ID is unknown).
+func merge(found []Bridge) []Bridge {
+	var merged []Bridge
+	for _, b := range found {
+		b.ID = strings.ToLower(b.ID)
+
+		i := slices.IndexFunc(merged, func(m Bridge) bool {
+			return (b.ID != "" && m.ID == b.ID) || m.IP == b.IP
+		})
+		if i < 0 {
+			merged = append(merged, b)
+			continue
+		}
+
+		m := &merged[i]
+		m.ID = cmp.Or(m.ID, b.ID)
+		m.Name = cmp.Or(m.Name, b.Name)
+		m.ModelID = cmp.Or(m.ModelID, b.ModelID)
+	}
+
+	slices.SortFunc(merged, func(a, b Bridge) int {
+		return cmp.Compare(a.IP, b.IP)
+	})
+	return merged
+}
+
+// describe fills in name, ID and model from each bridge's /api/config.
+// Bridges that don't answer keep whatever the discovery method reported.
+func (d *Discoverer) describe(ctx context.Context, bridges []Bridge) []Bridge {
+	var wg sync.WaitGroup
+	for i := range bridges {
+		if bridges[i].Source == "probe" {
+			continue // already described by the probe
+		}
+		wg.Go(func() {
+			config, err := d.bridgeConfig(ctx, bridges[i].IP)
+			if err != nil {
+				return
+			}
+			bridges[i].ID = config.BridgeID
+			bridges[i].Name = config.Name
+			bridges[i].ModelID = config.ModelID
+		})
+	}
+	wg.Wait()
+	return bridges
+}
+
+// bridgeConfig fetches /api/config from ip.
+func (d *Discoverer) bridgeConfig(ctx context.Context, ip string) (*hue.BridgeConfig, error) {
+	addr := ip
+	if d.HTTPPort != 0 && d.HTTPPort != 80 {
+		addr = net.JoinHostPort(ip, strconv.Itoa(d.HTTPPort))
+	}
+
+	return hue.NewClient(addr, "").GetBridgeConfig(ctx)
+}
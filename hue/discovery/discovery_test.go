@@ -0,0 +1,259 @@
+package discovery
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// fakeResponder answers every UDP packet it receives on loopback with the
+// packet returned by reply.
+func fakeResponder(t *testing.T, reply func(query []byte) []byte) string {
+	t.Helper()
+
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, from, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			if packet := reply(buf[:n]); packet != nil {
+				_, _ = conn.WriteToUDP(packet, from)
+			}
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+// fakeBridge serves /api/config like a bridge and returns its port.
+func fakeBridge(t *testing.T, bridgeID, name string) int {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/config" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"name":%q,"bridgeid":%q,"modelid":"BSB002","apiversion":"1.65.0"}`, name, bridgeID)
+	}))
+	t.Cleanup(server.Close)
+
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	p, _ := strconv.Atoi(port)
+	return p
+}
+
+// unreachable returns a loopback address nothing answers on.
+func unreachable(t *testing.T) string {
+	t.Helper()
+	return fakeResponder(t, func([]byte) []byte { return nil })
+}
+
+// mdnsResponse builds an mDNS answer for a bridge instance, using name
+// compression for the service name like real responders do.
+func mdnsResponse(instance, bridgeID string, ip net.IP) []byte {
+	msg := make([]byte, 12)
+	binary.BigEndian.PutUint16(msg[2:], 0x8400)
+	binary.BigEndian.PutUint16(msg[6:], 3)
+
+	serviceOffset := len(msg)
+	msg = appendName(msg, hueService)
+	msg = binary.BigEndian.AppendUint16(msg, typePTR)
+	msg = binary.BigEndian.AppendUint16(msg, classIN)
+	msg = binary.BigEndian.AppendUint32(msg, 120)
+	msg = binary.BigEndian.AppendUint16(msg, uint16(1+len(instance)+2))
+	instanceOffset := len(msg)
+	msg = append(msg, byte(len(instance)))
+	msg = append(msg, instance...)
+	msg = binary.BigEndian.AppendUint16(msg, 0xc000|uint16(serviceOffset))
+
+	txt := []string{"bridgeid=" + bridgeID, "modelid=BSB002"}
+	var txtData []byte
+	for _, entry := range txt {
+		txtData = append(txtData, byte(len(entry)))
+		txtData = append(txtData, entry...)
+	}
+	msg = binary.BigEndian.AppendUint16(msg, 0xc000|uint16(instanceOffset))
+	msg = binary.BigEndian.AppendUint16(msg, typeTXT)
+	msg = binary.BigEndian.AppendUint16(msg, classIN)
+	msg = binary.BigEndian.AppendUint32(msg, 120)
+	msg = binary.BigEndian.AppendUint16(msg, uint16(len(txtData)))
+	msg = append(msg, txtData...)
+
+	msg = appendName(msg, "bridge.local.")
+	msg = binary.BigEndian.AppendUint16(msg, typeA)
+	msg = binary.BigEndian.AppendUint16(msg, classIN)
+	msg = binary.BigEndian.AppendUint32(msg, 120)
+	msg = binary.BigEndian.AppendUint16(msg, 4)
+	return append(msg, ip.To4()...)
+}
+
+func ssdpResponse(bridgeID string, port int) []byte {
+	return fmt.Appendf(nil, "HTTP/1.1 200 OK\r\n"+
+		"CACHE-CONTROL: max-age=100\r\n"+
+		"LOCATION: http://127.0.0.1:%d/description.xml\r\n"+
+		"SERVER: Hue/1.0 UPnP/1.0 IpBridge/1.65.0\r\n"+
+		"ST: upnp:rootdevice\r\n"+
+		"hue-bridgeid: %s\r\n"+
+		"\r\n", port, bridgeID)
+}
+
+func TestDiscover_MDNS(t *testing.T) {
+	port := fakeBridge(t, "001788FFFE23BFC2", "Living Room Bridge")
+	mdnsAddr := fakeResponder(t, func(query []byte) []byte {
+		name, _, err := readName(query, 12)
+		if err != nil || name != hueService {
+			t.Errorf("query name = %q, err %v", name, err)
+			return nil
+		}
+		return mdnsResponse("Philips Hue - 23BFC2", "001788FFFE23BFC2", net.IPv4(127, 0, 0, 1))
+	})
+
+	d := &Discoverer{MDNSAddr: mdnsAddr, SSDPAddr: unreachable(t), Timeout: 300 * time.Millisecond, HTTPPort: port}
+	bridges, err := d.Discover(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(bridges) != 1 {
+		t.Fatalf("expected 1 bridge, got %d: %+v", len(bridges), bridges)
+	}
+	want := Bridge{IP: "127.0.0.1", ID: "001788fffe23bfc2", Name: "Living Room Bridge", ModelID: "BSB002", Source: "mdns"}
+	if bridges[0] != want {
+		t.Errorf("bridge = %+v, want %+v", bridges[0], want)
+	}
+}
+
+func TestDiscover_SSDPWithoutConfig(t *testing.T) {
+	ssdpAddr := fakeResponder(t, func(query []byte) []byte {
+		return ssdpResponse("001788FFFE23BFC2", 80)
+	})
+
+	// Nothing serves /api/config, so only what SSDP reported is known.
+	d := &Discoverer{MDNSAddr: unreachable(t), SSDPAddr: ssdpAddr, Timeout: 300 * time.Millisecond, HTTPPort: 1}
+	bridges, err := d.Discover(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(bridges) != 1 {
+		t.Fatalf("expected 1 bridge, got %d: %+v", len(bridges), bridges)
+	}
+	if bridges[0].IP != "127.0.0.1" || bridges[0].ID != "001788fffe23bfc2" || bridges[0].Source != "ssdp" {
+		t.Errorf("unexpected bridge: %+v", bridges[0])
+	}
+}
+
+func TestDiscover_MergesMethods(t *testing.T) {
+	port := fakeBridge(t, "001788fffe23bfc2", "Hue Bridge")
+	mdnsAddr := fakeResponder(t, func([]byte) []byte {
+		return mdnsResponse("Philips Hue - 23BFC2", "001788fffe23bfc2", net.IPv4(127, 0, 0, 1))
+	})
+	ssdpAddr := fakeResponder(t, func([]byte) []byte {
+		return ssdpResponse("001788FFFE23BFC2", port)
+	})
+
+	d := &Discoverer{
+		MDNSAddr: mdnsAddr,
+		SSDPAddr: ssdpAddr,
+		Timeout:  300 * time.Millisecond,
+		Probe:    true,
+		Subnets:  []netip.Prefix{netip.MustParsePrefix("127.0.0.1/32")},
+		HTTPPort: port,
+	}
+	bridges, err := d.Discover(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(bridges) != 1 {
+		t.Fatalf("expected 1 bridge, got %d: %+v", len(bridges), bridges)
+	}
+	if bridges[0].Name != "Hue Bridge" {
+		t.Errorf("expected name from /api/config, got %q", bridges[0].Name)
+	}
+}
+
+func TestDiscover_IgnoresOtherDevices(t *testing.T) {
+	ssdpAddr := fakeResponder(t, func([]byte) []byte {
+		return []byte("HTTP/1.1 200 OK\r\nLOCATION: http://127.0.0.1/desc.xml\r\nSERVER: Linux UPnP/1.0 MediaServer/2.0\r\n\r\n")
+	})
+	mdnsAddr := fakeResponder(t, func([]byte) []byte { return []byte("garbage") })
+
+	d := &Discoverer{MDNSAddr: mdnsAddr, SSDPAddr: ssdpAddr, Timeout: 200 * time.Millisecond}
+	bridges, err := d.Discover(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(bridges) != 0 {
+		t.Errorf("expected no bridges, got %+v", bridges)
+	}
+}
+
+func TestFindByID(t *testing.T) {
+	port := fakeBridge(t, "001788fffe23bfc2", "Hue Bridge")
+	ssdpAddr := fakeResponder(t, func([]byte) []byte {
+		return ssdpResponse("001788FFFE23BFC2", port)
+	})
+	d := &Discoverer{MDNSAddr: unreachable(t), SSDPAddr: ssdpAddr, Timeout: 200 * time.Millisecond, HTTPPort: port}
+
+	bridge, err := d.FindByID(context.Background(), "001788FFFE23BFC2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if bridge.IP != "127.0.0.1" {
+		t.Errorf("expected IP 127.0.0.1, got %s", bridge.IP)
+	}
+
+	_, err = d.FindByID(context.Background(), "ffffffffffffffff")
+	if _, ok := err.(*NotFoundError); !ok {
+		t.Errorf("expected *NotFoundError, got %v", err)
+	}
+}
+
+func TestParseDNSMessage_Truncated(t *testing.T) {
+	msg := mdnsResponse("Philips Hue - 23BFC2", "001788fffe23bfc2", net.IPv4(10, 0, 0, 2))
+	for _, n := range []int{5, 20, len(msg) - 1} {
+		if _, err := parseDNSMessage(msg[:n]); err == nil {
+			t.Errorf("expected error for message truncated to %d bytes", n)
+		}
+	}
+}
+
+func TestHosts(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   []string
+	}{
+		{"192.168.1.0/30", []string{"192.168.1.1", "192.168.1.2"}},
+		{"192.168.1.7/32", []string{"192.168.1.7"}},
+		{"10.0.0.0/31", []string{"10.0.0.0", "10.0.0.1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			var got []string
+			for addr := range hosts(netip.MustParsePrefix(tt.prefix)) {
+				got = append(got, addr.String())
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("hosts(%s) = %v, want %v", tt.prefix, got, tt.want)
+			}
+		})
+	}
+}
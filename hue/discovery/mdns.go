@@ -0,0 +1,288 @@
+package discovery
+
+import (
+	"cmp"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// hueService is the DNS-SD service type advertised by Hue bridges.
+const hueService = "_hue._tcp.local."
+
+// DNS record types used by mDNS discovery.
+const (
+	typeA   = 1
+	typePTR = 12
+	typeTXT = 16
+	typeSRV = 33
+)
+
+const (
+	classIN      = 1
+	unicastReply = 0x8000 // QU bit: ask responders to reply directly to us
+)
+
+// mdns sends a DNS-SD query for _hue._tcp and collects answers until ctx
+// expires.
+func (d *Discoverer) mdns(ctx context.Context) ([]Bridge, error) {
+	addr, err := net.ResolveUDPAddr("udp4", cmp.Or(d.MDNSAddr, DefaultMDNSAddr))
+	if err != nil {
+		return nil, fmt.Errorf("mdns: %w", err)
+	}
+
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return nil, fmt.Errorf("mdns: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	if _, err := conn.WriteToUDP(mdnsQuery(hueService), addr); err != nil {
+		return nil, fmt.Errorf("mdns: send query: %w", err)
+	}
+
+	var bridges []Bridge
+	err = readUntilDone(ctx, conn, func(packet []byte, from *net.UDPAddr) {
+		records, err := parseDNSMessage(packet)
+		if err != nil {
+			return // not a DNS packet we understand
+		}
+		if b, ok := bridgeFromRecords(records, from); ok {
+			bridges = append(bridges, b)
+		}
+	})
+	if err != nil {
+		return bridges, fmt.Errorf("mdns: %w", err)
+	}
+	return bridges, nil
+}
+
+// readUntilDone calls handle for every packet received on conn until ctx
+// is done.
+func readUntilDone(ctx context.Context, conn *net.UDPConn, handle func([]byte, *net.UDPAddr)) error {
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetReadDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	buf := make([]byte, 9000)
+	for {
+		n, from, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			var netErr net.Error
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) || (errors.As(err, &netErr) && netErr.Timeout()) {
+				return nil
+			}
+			return err
+		}
+		handle(buf[:n], from)
+	}
+}
+
+// bridgeFromRecords extracts a bridge from the records of one mDNS
+// response. The address comes from an A record, or from the sender when
+// the response carries none.
+func bridgeFromRecords(records []dnsRecord, from *net.UDPAddr) (Bridge, bool) {
+	var (
+		b        Bridge
+		instance string
+		isHue    bool
+	)
+
+	for _, r := range records {
+		if r.Type == typePTR && strings.EqualFold(r.Name, hueService) {
+			isHue = true
+			instance = r.Target
+		}
+	}
+	if !isHue {
+		return Bridge{}, false
+	}
+
+	b.Source = "mdns"
+	b.Name = strings.TrimSuffix(instance, "."+hueService)
+	for _, r := range records {
+		switch r.Type {
+		case typeTXT:
+			for _, entry := range r.Text {
+				key, value, _ := strings.Cut(entry, "=")
+				switch strings.ToLower(key) {
+				case "bridgeid":
+					b.ID = strings.ToLower(value)
+				case "modelid":
+					b.ModelID = value
+				}
+			}
+		case typeA:
+			if b.IP == "" {
+				b.IP = r.IP.String()
+			}
+		}
+	}
+	if b.IP == "" && from != nil {
+		b.IP = from.IP.String()
+	}
+	return b, b.IP != ""
+}
+
+// dnsRecord is a decoded resource record. Only the fields relevant to its
+// type are set.
+type dnsRecord struct {
+	Name   string
+	Type   uint16
+	Target string   // PTR target or SRV host
+	Port   uint16   // SRV
+	Text   []string // TXT entries
+	IP     net.IP   // A
+}
+
+// mdnsQuery builds a DNS query for PTR records of name.
+func mdnsQuery(name string) []byte {
+	msg := make([]byte, 12) // ID 0, no flags, as mDNS queries use
+	binary.BigEndian.PutUint16(msg[4:], 1)
+
+	msg = appendName(msg, name)
+	msg = binary.BigEndian.AppendUint16(msg, typePTR)
+	msg = binary.BigEndian.AppendUint16(msg, classIN|unicastReply)
+	return msg
+}
+
+// appendName appends name in DNS label format, without compression.
+func appendName(msg []byte, name string) []byte {
+	for label := range strings.SplitSeq(strings.TrimSuffix(name, "."), ".") {
+		msg = append(msg, byte(len(label)))
+		msg = append(msg, label...)
+	}
+	return append(msg, 0)
+}
+
+var errShortMessage = errors.New("dns message too short")
+
+// parseDNSMessage decodes the answer, authority and additional records of
+// a DNS response.
+func parseDNSMessage(msg []byte) ([]dnsRecord, error) {
+	if len(msg) < 12 {
+		return nil, errShortMessage
+	}
+	if msg[2]&0x80 == 0 {
+		return nil, errors.New("dns message is a query")
+	}
+
+	questions := int(binary.BigEndian.Uint16(msg[4:]))
+	count := int(binary.BigEndian.Uint16(msg[6:])) +
+		int(binary.BigEndian.Uint16(msg[8:])) +
+		int(binary.BigEndian.Uint16(msg[10:]))
+
+	offset := 12
+	for range questions {
+		_, next, err := readName(msg, offset)
+		if err != nil {
+			return nil, err
+		}
+		offset = next + 4 // type and class
+	}
+
+	records := make([]dnsRecord, 0, count)
+	for range count {
+		name, next, err := readName(msg, offset)
+		if err != nil {
+			return nil, err
+		}
+		if next+10 > len(msg) {
+			return nil, errShortMessage
+		}
+
+		r := dnsRecord{Name: name, Type: binary.BigEndian.Uint16(msg[next:])}
+		length := int(binary.BigEndian.Uint16(msg[next+8:]))
+		start := next + 10
+		end := start + length
+		if end > len(msg) {
+			return nil, errShortMessage
+		}
+
+		switch r.Type {
+		case typePTR:
+			r.Target, _, err = readName(msg, start)
+		case typeSRV:
+			if length < 7 {
+				return nil, errShortMessage
+			}
+			r.Port = binary.BigEndian.Uint16(msg[start+4:])
+			r.Target, _, err = readName(msg, start+6)
+		case typeTXT:
+			r.Text = readText(msg[start:end])
+		case typeA:
+			if length == 4 {
+				r.IP = net.IP(append([]byte(nil), msg[start:end]...))
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		records = append(records, r)
+		offset = end
+	}
+
+	return records, nil
+}
+
+// readName decodes a possibly compressed name starting at offset and
+// returns it with the offset just past it.
+func readName(msg []byte, offset int) (string, int, error) {
+	var labels []string
+	next := -1
+
+	for jumps := 0; ; {
+		if offset >= len(msg) {
+			return "", 0, errShortMessage
+		}
+		length := int(msg[offset])
+
+		switch {
+		case length == 0:
+			if next < 0 {
+				next = offset + 1
+			}
+			return strings.Join(labels, ".") + ".", next, nil
+
+		case length&0xc0 == 0xc0:
+			if offset+1 >= len(msg) {
+				return "", 0, errShortMessage
+			}
+			if jumps++; jumps > 16 {
+				return "", 0, errors.New("dns name compression loop")
+			}
+			if next < 0 {
+				next = offset + 2
+			}
+			offset = int(binary.BigEndian.Uint16(msg[offset:]) & 0x3fff)
+
+		default:
+			end := offset + 1 + length
+			if end > len(msg) {
+				return "", 0, errShortMessage
+			}
+			labels = append(labels, string(msg[offset+1:end]))
+			offset = end
+		}
+	}
+}
+
+// readText splits TXT record data into its length-prefixed strings.
+func readText(data []byte) []string {
+	var text []string
+	for len(data) > 0 {
+		length := int(data[0])
+		if 1+length > len(data) {
+			break
+		}
+		text = append(text, string(data[1:1+length]))
+		data = data[1+length:]
+	}
+	return text
+}
@@ -0,0 +1,123 @@
+package discovery
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"sync"
+)
+
+const (
+	// probeConcurrency limits how many hosts are queried at once.
+	probeConcurrency = 32
+	// maxProbeBits is the largest network probed by default (/22, 1022 hosts).
+	maxProbeBits = 22
+)
+
+// probe queries /api/config on every host in the subnets to probe.
+// It finds bridges on networks that block multicast.
+func (d *Discoverer) probe(ctx context.Context) ([]Bridge, error) {
+	subnets := d.Subnets
+	if subnets == nil {
+		var err error
+		subnets, err = localSubnets()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var (
+		mu      sync.Mutex
+		bridges []Bridge
+		wg      sync.WaitGroup
+		limit   = make(chan struct{}, probeConcurrency)
+	)
+
+	for _, subnet := range subnets {
+		for host := range hosts(subnet) {
+			select {
+			case limit <- struct{}{}:
+			case <-ctx.Done():
+				wg.Wait()
+				return bridges, nil
+			}
+
+			wg.Go(func() {
+				defer func() { <-limit }()
+
+				config, err := d.bridgeConfig(ctx, host.String())
+				if err != nil {
+					return
+				}
+
+				mu.Lock()
+				defer mu.Unlock()
+				bridges = append(bridges, Bridge{
+					IP:      host.String(),
+					ID:      config.BridgeID,
+					Name:    config.Name,
+					ModelID: config.ModelID,
+					Source:  "probe",
+				})
+			})
+		}
+	}
+
+	wg.Wait()
+	return bridges, nil
+}
+
+// localSubnets returns the IPv4 networks of the up, non-loopback
+// interfaces that are small enough to probe.
+func localSubnets() ([]netip.Prefix, error) {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	var subnets []netip.Prefix
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok {
+				continue
+			}
+			prefix, err := netip.ParsePrefix(ipNet.String())
+			if err != nil || !prefix.Addr().Is4() || prefix.Bits() < maxProbeBits {
+				continue
+			}
+			subnets = append(subnets, prefix.Masked())
+		}
+	}
+	return subnets, nil
+}
+
+// hosts yields the host addresses of prefix, skipping the network and
+// broadcast addresses when the prefix has them.
+func hosts(prefix netip.Prefix) func(yield func(netip.Addr) bool) {
+	return func(yield func(netip.Addr) bool) {
+		prefix = prefix.Masked()
+		addr := prefix.Addr()
+		if prefix.Bits() >= 31 {
+			for ; prefix.Contains(addr); addr = addr.Next() {
+				if !yield(addr) {
+					return
+				}
+			}
+			return
+		}
+
+		for addr = addr.Next(); prefix.Contains(addr.Next()); addr = addr.Next() {
+			if !yield(addr) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package discovery
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ssdpSearch is the M-SEARCH request sent to find UPnP devices.
+const ssdpSearch = "M-SEARCH * HTTP/1.1\r\n" +
+	"HOST: 239.255.255.250:1900\r\n" +
+	"MAN: \"ssdp:discover\"\r\n" +
+	"MX: 2\r\n" +
+	"ST: ssdp:all\r\n" +
+	"\r\n"
+
+// ssdp sends an M-SEARCH and collects responses from Hue bridges until ctx
+// expires.
+func (d *Discoverer) ssdp(ctx context.Context) ([]Bridge, error) {
+	addr, err := net.ResolveUDPAddr("udp4", cmp.Or(d.SSDPAddr, DefaultSSDPAddr))
+	if err != nil {
+		return nil, fmt.Errorf("ssdp: %w", err)
+	}
+
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return nil, fmt.Errorf("ssdp: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	if _, err := conn.WriteToUDP([]byte(ssdpSearch), addr); err != nil {
+		return nil, fmt.Errorf("ssdp: send search: %w", err)
+	}
+
+	var bridges []Bridge
+	err = readUntilDone(ctx, conn, func(packet []byte, from *net.UDPAddr) {
+		if b, ok := parseSSDPResponse(packet, from); ok {
+			bridges = append(bridges, b)
+		}
+	})
+	if err != nil {
+		return bridges, fmt.Errorf("ssdp: %w", err)
+	}
+	return bridges, nil
+}
+
+// parseSSDPResponse reads a bridge from an M-SEARCH response. Other UPnP
+// devices answer too; they are recognised by the hue-bridgeid header or
+// the IpBridge server token that Hue bridges send.
+func parseSSDPResponse(packet []byte, from *net.UDPAddr) (Bridge, bool) {
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(packet)), nil)
+	if err != nil {
+		return Bridge{}, false
+	}
+	_ = resp.Body.Close()
+
+	id := resp.Header.Get("hue-bridgeid")
+	if id == "" && !strings.Contains(resp.Header.Get("Server"), "IpBridge") {
+		return Bridge{}, false
+	}
+
+	b := Bridge{ID: strings.ToLower(id), Source: "ssdp"}
+	if location, err := url.Parse(resp.Header.Get("Location")); err == nil {
+		b.IP = location.Hostname()
+	}
+	if b.IP == "" && from != nil {
+		b.IP = from.IP.String()
+	}
+	return b, b.IP != ""
+}
@@ -0,0 +1,52 @@
+package hue
+
+import "errors"
+
+// BridgeError is an error reported by the bridge in a response body.
+type BridgeError struct {
+	Type        int    // bridge error type, e.g. 1 for unauthorized user
+	Address     string // resource the error refers to, e.g. "/lights/7"
+	Description string
+}
+
+func (e *BridgeError) Error() string {
+	return "bridge error: " + e.Description
+}
+
+// Is reports whether target is a *BridgeError of the same type, so
+// errors.Is(err, hue.ErrUnauthorized) matches regardless of address and
+// description.
+func (e *BridgeError) Is(target error) bool {
+	t, ok := target.(*BridgeError)
+	return ok && t.Type == e.Type
+}
+
+// Sentinel errors for the bridge error types callers commonly handle.
+// Use errors.Is to check for them and errors.As to get the details.
+var (
+	ErrUnauthorized         = &BridgeError{Type: 1, Description: "unauthorized user"}
+	ErrResourceNotAvailable = &BridgeError{Type: 3, Description: "resource not available"}
+	ErrLinkButtonNotPressed = &BridgeError{Type: 101, Description: "link button not pressed"}
+	ErrDeviceOff            = &BridgeError{Type: 201, Description: "device is set to off"}
+)
+
+// bridgeErrors returns the errors in a multi-result response: nil if
+// there are none, the *BridgeError itself if there is one, and all of
+// them joined otherwise.
+func bridgeErrors(results []bridgeResult) error {
+	var errs []error
+	for _, result := range results {
+		if result.Error != nil {
+			errs = append(errs, &BridgeError{
+				Type:        result.Error.Type,
+				Address:     result.Error.Address,
+				Description: result.Error.Description,
+			})
+		}
+	}
+
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,499 @@
+package hue
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// pollInterval is how often Watch polls bridges without an event stream.
+var pollInterval = 2 * time.Second
+
+// Event is a change reported by the bridge: a LightEvent, GroupEvent,
+// SceneEvent, SensorEvent or ResyncEvent.
+type Event interface {
+	isEvent()
+}
+
+// LightEvent reports a change to a light. Fields that didn't change are nil.
+type LightEvent struct {
+	ID         string
+	Name       *string
+	On         *bool
+	Brightness *int        // 0-254
+	XY         *[2]float64 // CIE 1931 color space
+	ColorTemp  *int        // mired
+}
+
+// GroupEvent reports a change to a group. Fields that didn't change are nil.
+type GroupEvent struct {
+	ID    string
+	Name  *string
+	AnyOn *bool
+	AllOn *bool
+}
+
+// SceneEvent reports a change to a scene. Fields that didn't change are nil.
+type SceneEvent struct {
+	ID   string
+	Name *string
+}
+
+// SensorEvent reports that a sensor's state changed, e.g. because motion
+// was detected or a button pressed. The sensor must be loaded again to
+// get its new state.
+type SensorEvent struct {
+	ID string
+}
+
+// ResyncEvent reports that lights, groups or scenes were added or removed,
+// or that changes may have been missed. The full state should be loaded
+// again.
+type ResyncEvent struct{}
+
+func (LightEvent) isEvent()  {}
+func (GroupEvent) isEvent()  {}
+func (SceneEvent) isEvent()  {}
+func (SensorEvent) isEvent() {}
+func (ResyncEvent) isEvent() {}
+
+// Apply updates light with the fields that changed.
+func (e LightEvent) Apply(light *Light) {
+	if e.Name != nil {
+		light.Name = *e.Name
+	}
+	if e.On != nil {
+		light.On = *e.On
+	}
+	if e.Brightness != nil {
+		light.Brightness = *e.Brightness
+	}
+	if e.XY != nil {
+		light.XY = *e.XY
+		light.ColorMode = "xy"
+	}
+	if e.ColorTemp != nil {
+		light.ColorTemp = *e.ColorTemp
+		light.ColorMode = "ct"
+	}
+}
+
+// Apply updates group with the fields that changed.
+func (e GroupEvent) Apply(group *Group) {
+	if e.Name != nil {
+		group.Name = *e.Name
+	}
+	if e.AnyOn != nil {
+		group.AnyOn = *e.AnyOn
+	}
+	if e.AllOn != nil {
+		group.AllOn = *e.AllOn
+	}
+}
+
+// Apply updates scene with the fields that changed.
+func (e SceneEvent) Apply(scene *Scene) {
+	if e.Name != nil {
+		scene.Name = *e.Name
+	}
+}
+
+// streamRetryPolicy spaces out attempts to connect to the event stream.
+var streamRetryPolicy = RetryPolicy{BaseDelay: 2 * time.Second, MaxDelay: time.Minute}
+
+// Watch reports changes to lights, groups, scenes and sensors to fn until
+// ctx is done or the bridge rejects the client's credentials. It listens
+// to the bridge's v2 event stream and, while the stream is down or on
+// bridges that don't have one, polls the v1 API, trying the stream again
+// with backoff. Without a certificate policy to check the stream with, it
+// only polls. Events use v1 IDs, and fn is called from a single goroutine.
+func (c *Client) Watch(ctx context.Context, fn func(Event)) error {
+	p := &poller{client: c, fn: fn}
+	if c.certPolicy == nil {
+		// The stream's certificate can't be checked, so the app key
+		// isn't sent to it.
+		return p.poll(ctx, 0, false)
+	}
+
+	streamed := false
+	failures := 0
+	for attempt := 0; ; attempt++ {
+		connected, err := c.streamEvents(ctx, attempt > 0, fn)
+		var certErr *CertificateError
+		switch {
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case errors.Is(err, ErrUnauthorized):
+			return err
+		case errors.As(err, &certErr):
+			if c.scheme == "https" {
+				return err
+			}
+			// Only the stream uses HTTPS, and its certificate isn't
+			// trusted; the v1 API still works.
+			return p.poll(ctx, 0, streamed)
+		}
+
+		if connected {
+			streamed = true
+			failures = 0
+			p.previous = nil // the stream kept fn up to date
+		} else {
+			failures++
+		}
+		if err := p.poll(ctx, streamRetryPolicy.delay(failures), streamed); err != nil {
+			return err
+		}
+	}
+}
+
+// streamEvents reads the bridge's v2 event stream until it ends and
+// reports whether it connected. Once connected, it sends a ResyncEvent
+// first if resync is set, since events may have been missed while
+// disconnected.
+func (c *Client) streamEvents(ctx context.Context, resync bool, fn func(Event)) (bool, error) {
+	url := fmt.Sprintf("https://%s/eventstream/clip/v2", c.BridgeIP())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("hue-application-key", c.username)
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := c.streamClient().Do(req)
+	if err != nil {
+		return false, fmt.Errorf("connect to event stream: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return false, &BridgeError{Type: ErrUnauthorized.Type, Address: "/eventstream/clip/v2", Description: ErrUnauthorized.Description}
+	default:
+		return false, fmt.Errorf("connect to event stream: %s", resp.Status)
+	}
+
+	if resync {
+		fn(ResyncEvent{})
+	}
+	return true, readEventStream(resp.Body, fn)
+}
+
+// streamClient returns an HTTP client for the event stream. It has no
+// request timeout, since the stream stays open. The bridge's certificate
+// is checked by the client's policy, set with WithHTTPS or WithCertPolicy.
+func (c *Client) streamClient() *http.Client {
+	transport := &http.Transport{}
+	if t, ok := c.httpClient.Transport.(*http.Transport); ok {
+		transport = t.Clone()
+	}
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = c.certPolicy.TLSConfig()
+	}
+	return &http.Client{Transport: transport}
+}
+
+// streamEvent is one message of the v2 event stream.
+type streamEvent struct {
+	Type string           `json:"type"` // "update", "add", "delete" or "error"
+	Data []streamResource `json:"data"`
+}
+
+// streamResource holds the changed fields of a v2 resource.
+type streamResource struct {
+	Type     string `json:"type"`  // e.g. "light" or "grouped_light"
+	IDV1     string `json:"id_v1"` // e.g. "/lights/3"
+	Metadata *struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	On *struct {
+		On bool `json:"on"`
+	} `json:"on"`
+	Dimming *struct {
+		Brightness float64 `json:"brightness"` // percent
+	} `json:"dimming"`
+	Color *struct {
+		XY struct {
+			X float64 `json:"x"`
+			Y float64 `json:"y"`
+		} `json:"xy"`
+	} `json:"color"`
+	ColorTemperature *struct {
+		Mirek      *int `json:"mirek"`
+		MirekValid bool `json:"mirek_valid"`
+	} `json:"color_temperature"`
+}
+
+// readEventStream parses server-sent events from r until it ends.
+func readEventStream(r io.Reader, fn func(Event)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var data bytes.Buffer
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			// A blank line ends the message.
+			for _, event := range parseStreamData(data.Bytes()) {
+				fn(event)
+			}
+			data.Reset()
+			continue
+		}
+		if value, ok := strings.CutPrefix(line, "data:"); ok {
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(value, " "))
+		}
+		// id: lines and : comments are not needed.
+	}
+	return scanner.Err()
+}
+
+// parseStreamData converts the data of one event stream message, a list
+// of events, to the events huey cares about. Invalid data is skipped. If
+// anything was added or removed, only a ResyncEvent is returned.
+func parseStreamData(data []byte) []Event {
+	var messages []streamEvent
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return nil
+	}
+
+	var events []Event
+	for _, message := range messages {
+		for _, resource := range message.Data {
+			event := resource.toEvent(message.Type)
+			if event == (ResyncEvent{}) {
+				// Nothing else matters once everything is reloaded.
+				return []Event{event}
+			}
+			if event != nil {
+				events = append(events, event)
+			}
+		}
+	}
+	return events
+}
+
+// toEvent converts a changed resource to an event, or nil if it is not
+// about a light, group, scene or sensor with a v1 ID.
+func (r streamResource) toEvent(messageType string) Event {
+	_, id, ok := strings.Cut(strings.TrimPrefix(r.IDV1, "/"), "/")
+	if !ok {
+		return nil
+	}
+
+	var name *string
+	if r.Metadata != nil {
+		name = &r.Metadata.Name
+	}
+
+	switch r.Type {
+	case "light", "grouped_light", "room", "zone", "scene":
+		if messageType == "add" || messageType == "delete" {
+			return ResyncEvent{}
+		}
+	}
+	if messageType != "update" {
+		return nil
+	}
+
+	switch r.Type {
+	case "light":
+		e := LightEvent{ID: id, Name: name}
+		if r.On != nil {
+			e.On = &r.On.On
+		}
+		if r.Dimming != nil {
+			brightness := int(math.Round(r.Dimming.Brightness * 254 / 100))
+			e.Brightness = &brightness
+		}
+		if r.Color != nil {
+			e.XY = &[2]float64{r.Color.XY.X, r.Color.XY.Y}
+		}
+		if ct := r.ColorTemperature; ct != nil && ct.Mirek != nil && ct.MirekValid {
+			e.ColorTemp = ct.Mirek
+		}
+		if e == (LightEvent{ID: id}) {
+			return nil
+		}
+		return e
+	case "grouped_light":
+		// The v2 on state of a group means any light is on.
+		if r.On == nil {
+			return nil
+		}
+		return GroupEvent{ID: id, AnyOn: &r.On.On}
+	case "room", "zone":
+		if name == nil {
+			return nil
+		}
+		return GroupEvent{ID: id, Name: name}
+	case "scene":
+		if name == nil {
+			return nil
+		}
+		return SceneEvent{ID: id, Name: name}
+	case "motion", "temperature", "light_level", "button", "relative_rotary", "device_power", "contact", "tamper":
+		return SensorEvent{ID: id}
+	}
+	return nil
+}
+
+// poller reports changes by polling the v1 API and comparing each full
+// state with the one before.
+type poller struct {
+	client   *Client
+	fn       func(Event)
+	previous *BridgeState // nil until the first poll
+}
+
+// poll polls the bridge every pollInterval for d, or until ctx is done if
+// d is 0. The first poll only records the state, and sends a ResyncEvent
+// if resync is set, since changes may have been missed before it. Failed
+// polls are skipped; poll returns an error only if ctx is done or the
+// bridge rejects the client's credentials.
+func (p *poller) poll(ctx context.Context, d time.Duration, resync bool) error {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	var deadline <-chan time.Time
+	if d > 0 {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	for {
+		state, err := p.client.GetFullState(ctx)
+		switch {
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case errors.Is(err, ErrUnauthorized):
+			return err
+		case err == nil && p.previous == nil:
+			if resync {
+				p.fn(ResyncEvent{})
+			}
+			p.previous = state
+		case err == nil:
+			for _, event := range diffState(p.previous, state) {
+				p.fn(event)
+			}
+			p.previous = state
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-deadline:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// diffState returns the events that turn old into cur. If lights, groups
+// or scenes were added or removed, it returns a single ResyncEvent.
+func diffState(old, cur *BridgeState) []Event {
+	if !sameIDs(old.Lights, cur.Lights, func(l Light) string { return l.ID }) ||
+		!sameIDs(old.Groups, cur.Groups, func(g Group) string { return g.ID }) ||
+		!sameIDs(old.Scenes, cur.Scenes, func(s Scene) string { return s.ID }) {
+		return []Event{ResyncEvent{}}
+	}
+
+	var events []Event
+
+	oldLights := IndexByID(old.Lights, func(l Light) string { return l.ID })
+	for _, light := range cur.Lights {
+		before := oldLights[light.ID]
+		e := LightEvent{ID: light.ID}
+		if light.Name != before.Name {
+			e.Name = &light.Name
+		}
+		if light.On != before.On {
+			e.On = &light.On
+		}
+		if light.Brightness != before.Brightness {
+			e.Brightness = &light.Brightness
+		}
+		if light.XY != before.XY {
+			e.XY = &light.XY
+		}
+		if light.ColorTemp != before.ColorTemp {
+			e.ColorTemp = &light.ColorTemp
+		}
+		if e != (LightEvent{ID: light.ID}) {
+			events = append(events, e)
+		}
+	}
+
+	oldGroups := IndexByID(old.Groups, func(g Group) string { return g.ID })
+	for _, group := range cur.Groups {
+		before := oldGroups[group.ID]
+		e := GroupEvent{ID: group.ID}
+		if group.Name != before.Name {
+			e.Name = &group.Name
+		}
+		if group.AnyOn != before.AnyOn {
+			e.AnyOn = &group.AnyOn
+		}
+		if group.AllOn != before.AllOn {
+			e.AllOn = &group.AllOn
+		}
+		if e != (GroupEvent{ID: group.ID}) {
+			events = append(events, e)
+		}
+	}
+
+	oldScenes := IndexByID(old.Scenes, func(s Scene) string { return s.ID })
+	for _, scene := range cur.Scenes {
+		if before := oldScenes[scene.ID]; scene.Name != before.Name {
+			events = append(events, SceneEvent{ID: scene.ID, Name: &scene.Name})
+		}
+	}
+
+	// Sensors don't affect the other lists, so new ones need no resync.
+	oldSensors := IndexByID(old.Sensors, func(s Sensor) string { return s.ID })
+	for _, sensor := range cur.Sensors {
+		before, ok := oldSensors[sensor.ID]
+		if !ok || !reflect.DeepEqual(sensor, before) {
+			events = append(events, SensorEvent{ID: sensor.ID})
+		}
+	}
+
+	return events
+}
+
+// IndexByID returns items keyed by the ID id returns for each.
+func IndexByID[T any](items []T, id func(T) string) map[string]T {
+	m := make(map[string]T, len(items))
+	for _, item := range items {
+		m[id(item)] = item
+	}
+	return m
+}
+
+func sameIDs[T any](a, b []T, id func(T) string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	ids := IndexByID(a, id)
+	for _, item := range b {
+		if _, ok := ids[id(item)]; !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,112 @@
+package hue
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+// DefaultTimeout is the dial and request timeout used unless WithTimeout
+// says otherwise. Bridges are on the local network and answer quickly.
+const DefaultTimeout = 500 * time.Millisecond
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithTimeout sets the dial and request timeout. It has no effect when
+// combined with WithHTTPClient.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithRetryPolicy sets how failed requests are retried.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// WithHTTPClient makes the client send requests with httpClient, for
+// example to use a custom transport. Its own timeouts apply.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithTransition sets the transition used for light, group and scene
+// changes that don't set their own transition time.
+func WithTransition(d time.Duration) Option {
+	return func(c *Client) {
+		c.transition = TransitionTime(d)
+	}
+}
+
+// RetryPolicy controls how requests that fail to get a response are retried.
+// Bridge error responses are never retried.
+type RetryPolicy struct {
+	MaxRetries int           // retries after the first attempt; 0 disables retrying
+	BaseDelay  time.Duration // delay before the first retry, doubled for each further one
+	MaxDelay   time.Duration // upper bound for the delay
+
+	// RetryNonIdempotent also retries POST requests. A POST whose response
+	// was lost may have created the resource, so retrying can create
+	// duplicate groups or scenes.
+	RetryNonIdempotent bool
+}
+
+// DefaultRetryPolicy retries idempotent requests once after a short delay.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 1,
+	BaseDelay:  50 * time.Millisecond,
+	MaxDelay:   2 * time.Second,
+}
+
+// allows reports whether requests with method may be retried.
+func (p RetryPolicy) allows(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	default:
+		return p.RetryNonIdempotent
+	}
+}
+
+// delay returns how long to wait before retry number attempt+1: the base
+// delay doubled per attempt, capped at MaxDelay, with random jitter in the
+// upper half so clients that failed together don't retry together.
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	d := p.BaseDelay
+	for range min(attempt, 30) {
+		if p.MaxDelay > 0 && d >= p.MaxDelay {
+			break
+		}
+		d *= 2
+	}
+	if p.MaxDelay > 0 {
+		d = min(d, p.MaxDelay)
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,261 @@
+package hue
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Command rates the bridge handles reliably. Commands sent faster than
+// this are dropped by the bridge's Zigbee queue.
+const (
+	DefaultLightCommandsPerSecond = 10
+	DefaultGroupCommandsPerSecond = 1
+)
+
+// WithRateLimit sets how many light and group commands per second the
+// client sends. A rate of zero or less disables limiting for that class.
+func WithRateLimit(lightsPerSecond, groupsPerSecond float64) Option {
+	return func(c *Client) {
+		c.scheduler = newScheduler(lightsPerSecond, groupsPerSecond)
+	}
+}
+
+// tokenBucket hands out send slots at a fixed rate, allowing a burst of
+// up to one second's worth. Callers reserve a slot and wait until it is
+// due, so no goroutine is needed to refill the bucket.
+type tokenBucket struct {
+	rate   float64 // tokens per second, <= 0 means unlimited
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64) *tokenBucket {
+	return &tokenBucket{rate: rate, tokens: max(rate, 1)}
+}
+
+// reserve takes a token and returns how long to wait before it may be used.
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	if b.rate <= 0 {
+		return 0
+	}
+
+	if !b.last.IsZero() {
+		b.tokens = min(max(b.rate, 1), b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// scheduler paces state commands per resource class. Light updates that
+// are still waiting for a slot are merged with newer updates to the same
+// light, so only the latest state is sent.
+type scheduler struct {
+	mu      sync.Mutex
+	lights  *tokenBucket
+	groups  *tokenBucket
+	pending map[string]*pendingLightState // by light ID, until sent
+	waiting struct{ lights, groups int }
+}
+
+// pendingLightState is a light update waiting for its send slot.
+type pendingLightState struct {
+	state   LightState
+	waiters int // callers still waiting for the result
+	done    chan struct{}
+	err     error
+}
+
+func newScheduler(lightsPerSecond, groupsPerSecond float64) *scheduler {
+	return &scheduler{
+		lights:  newTokenBucket(lightsPerSecond),
+		groups:  newTokenBucket(groupsPerSecond),
+		pending: make(map[string]*pendingLightState),
+	}
+}
+
+// light sends state for light id with send once a light slot is free.
+// If an update for the same light is already waiting, state is merged
+// into it and all callers get the result of the combined command. A
+// queued update is sent as long as any of its callers is still waiting.
+func (s *scheduler) light(ctx context.Context, id string, state LightState, send func(context.Context, LightState) error) error {
+	s.mu.Lock()
+	p, ok := s.pending[id]
+	if ok {
+		p.state = p.state.merge(state)
+		p.waiters++
+	} else {
+		wait := s.lights.reserve(time.Now())
+		if wait <= 0 {
+			// Only queued updates can be merged; sending right away needs no entry.
+			s.mu.Unlock()
+			return send(ctx, state)
+		}
+		p = &pendingLightState{state: state, waiters: 1, done: make(chan struct{})}
+		s.pending[id] = p
+		s.waiting.lights++
+		// Updates merged from other callers must not be lost if the
+		// first caller gives up, so the send is not tied to its context.
+		go s.sendLight(context.WithoutCancel(ctx), id, p, wait, send)
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-p.done:
+		return p.err
+	case <-ctx.Done():
+		s.mu.Lock()
+		p.waiters--
+		s.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// sendLight sends the queued update p for light id after wait, unless
+// every caller waiting for it has given up by then.
+func (s *scheduler) sendLight(ctx context.Context, id string, p *pendingLightState, wait time.Duration, send func(context.Context, LightState) error) {
+	time.Sleep(wait)
+
+	s.mu.Lock()
+	delete(s.pending, id)
+	s.waiting.lights--
+	state, waiters := p.state, p.waiters
+	s.mu.Unlock()
+
+	if waiters > 0 {
+		p.err = send(ctx, state)
+	} else {
+		p.err = context.Canceled
+	}
+	close(p.done)
+}
+
+// group runs send once a group slot is free.
+func (s *scheduler) group(ctx context.Context, send func(context.Context) error) error {
+	s.mu.Lock()
+	wait := s.groups.reserve(time.Now())
+	s.waiting.groups++
+	s.mu.Unlock()
+
+	err := sleep(ctx, wait)
+
+	s.mu.Lock()
+	s.waiting.groups--
+	s.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+	return send(ctx)
+}
+
+// QueueDepth returns how many light and group commands are waiting for a
+// send slot. Merged light updates count once.
+func (c *Client) QueueDepth() (lights, groups int) {
+	c.scheduler.mu.Lock()
+	defer c.scheduler.mu.Unlock()
+	return c.scheduler.waiting.lights, c.scheduler.waiting.groups
+}
+
+// merge returns s updated with the fields set in newer. If newer sets a
+// color in one mode (hue and saturation, color temperature or xy), older
+// color fields of the other modes are dropped, since the bridge would
+// otherwise apply whichever mode has priority instead of the newest.
+// Increments add up, or are applied to an older absolute value, so a
+// burst of merged steps goes as far as the steps sent one by one.
+func (s LightState) merge(newer LightState) LightState {
+	hs, ct, xy := newer.Hue != nil || newer.Saturation != nil, newer.ColorTemp != nil, newer.XY != nil
+	if ct || xy {
+		s.Hue, s.Saturation, s.HueInc, s.SaturationInc = nil, nil, nil, nil
+	}
+	if hs || xy {
+		s.ColorTemp, s.ColorTempInc = nil, nil
+	}
+	if hs || ct {
+		s.XY, s.XYInc = nil, nil
+	}
+
+	if newer.On != nil {
+		s.On = newer.On
+	}
+	if newer.Brightness != nil {
+		s.Brightness, s.BrightnessInc = newer.Brightness, nil
+	}
+	if newer.Hue != nil {
+		s.Hue, s.HueInc = newer.Hue, nil
+	}
+	if newer.Saturation != nil {
+		s.Saturation, s.SaturationInc = newer.Saturation, nil
+	}
+	if newer.ColorTemp != nil {
+		s.ColorTemp, s.ColorTempInc = newer.ColorTemp, nil
+	}
+	if newer.XY != nil {
+		s.XY, s.XYInc = newer.XY, nil
+	}
+	if newer.Alert != "" {
+		s.Alert = newer.Alert
+	}
+	if newer.Effect != "" {
+		s.Effect = newer.Effect
+	}
+	if newer.TransitionTime != nil {
+		s.TransitionTime = newer.TransitionTime
+	}
+
+	s.Brightness, s.BrightnessInc = mergeInc(s.Brightness, s.BrightnessInc, newer.BrightnessInc, MinBrightness, MaxBrightness, MaxBrightnessInc)
+	s.Saturation, s.SaturationInc = mergeInc(s.Saturation, s.SaturationInc, newer.SaturationInc, 0, MaxSaturation, MaxSaturation)
+	s.ColorTemp, s.ColorTempInc = mergeInc(s.ColorTemp, s.ColorTempInc, newer.ColorTempInc, MinColorTemp, MaxColorTemp, MaxHueInc)
+
+	if newer.HueInc != nil {
+		if s.Hue != nil {
+			// Hue is an angle and wraps around.
+			h := ((*s.Hue+*newer.HueInc)%(MaxHue+1) + MaxHue + 1) % (MaxHue + 1)
+			s.Hue = &h
+		} else {
+			_, s.HueInc = mergeInc(nil, s.HueInc, newer.HueInc, 0, MaxHue, MaxHueInc)
+		}
+	}
+
+	if newer.XYInc != nil {
+		if s.XY != nil {
+			xy := [2]float64{
+				min(max(s.XY[0]+newer.XYInc[0], 0), 1),
+				min(max(s.XY[1]+newer.XYInc[1], 0), 1),
+			}
+			s.XY = &xy
+		} else {
+			inc := *newer.XYInc
+			if s.XYInc != nil {
+				inc[0] = min(max(inc[0]+s.XYInc[0], -MaxXYInc), MaxXYInc)
+				inc[1] = min(max(inc[1]+s.XYInc[1], -MaxXYInc), MaxXYInc)
+			}
+			s.XYInc = &inc
+		}
+	}
+	return s
+}
+
+// mergeInc applies a newer increment delta to an older update's value or,
+// if it had none, adds it to the older increment. Results are clamped to
+// lo-hi and ±maxInc.
+func mergeInc(value, inc, delta *int, lo, hi, maxInc int) (*int, *int) {
+	if delta == nil {
+		return value, inc
+	}
+	if value != nil {
+		v := min(max(*value+*delta, lo), hi)
+		return &v, nil
+	}
+	sum := *delta
+	if inc != nil {
+		sum += *inc
+	}
+	sum = min(max(sum, -maxInc), maxInc)
+	return nil, &sum
+}
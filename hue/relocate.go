@@ -0,0 +1,96 @@
+package hue
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// relocateCooldown is how long the client waits after a failed lookup
+// before searching for the bridge again, so an unplugged bridge doesn't
+// cost a full discovery on every request.
+const relocateCooldown = 30 * time.Second
+
+// Locator finds the current address of the bridge with the given ID.
+type Locator func(ctx context.Context, bridgeID string) (string, error)
+
+// relocation holds what the client needs to follow a bridge to a new address.
+type relocation struct {
+	mu          sync.Mutex // serializes lookups
+	bridgeID    string
+	locate      Locator
+	onMove      func(oldIP, newIP string)
+	lastFailure time.Time
+}
+
+// EnableRelocation makes the client look for the bridge with locate when
+// it can't connect, and continue at the new address once /api/config
+// there reports the same bridge ID. onMove, if not nil, is called after
+// each move so the caller can persist the new address.
+func (c *Client) EnableRelocation(bridgeID string, locate Locator, onMove func(oldIP, newIP string)) {
+	c.relocation = &relocation{
+		bridgeID: strings.ToLower(bridgeID),
+		locate:   locate,
+		onMove:   onMove,
+	}
+}
+
+// relocate finds the bridge's new address after a request to failedIP
+// could not connect, and switches the client to it.
+func (c *Client) relocate(ctx context.Context, failedIP string) (string, error) {
+	r := c.relocation
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Another request may have moved the client while we waited.
+	if current := c.BridgeIP(); current != failedIP {
+		return current, nil
+	}
+	if time.Since(r.lastFailure) < relocateCooldown {
+		return "", fmt.Errorf("bridge %s not found recently, not searching again yet", r.bridgeID)
+	}
+
+	newIP, err := c.locateVerified(ctx)
+	if err != nil {
+		r.lastFailure = time.Now()
+		return "", err
+	}
+
+	c.mu.Lock()
+	c.bridgeIP = newIP
+	c.mu.Unlock()
+
+	if r.onMove != nil {
+		r.onMove(failedIP, newIP)
+	}
+	return newIP, nil
+}
+
+// locateVerified looks up the bridge and checks that the address found
+// belongs to the bridge with the expected ID. The check uses the client's
+// scheme and certificate policy, so over HTTPS no other host can claim
+// the bridge ID and receive the username.
+func (c *Client) locateVerified(ctx context.Context) (string, error) {
+	r := c.relocation
+
+	newIP, err := r.locate(ctx, r.bridgeID)
+	if err != nil {
+		return "", err
+	}
+	if newIP == c.BridgeIP() {
+		return "", fmt.Errorf("bridge %s is still at %s but not responding", r.bridgeID, newIP)
+	}
+
+	check := NewClient(newIP, "", WithHTTPClient(c.httpClient), WithRetryPolicy(c.retry))
+	check.scheme = c.scheme
+	config, err := check.GetBridgeConfig(ctx)
+	if err != nil {
+		return "", fmt.Errorf("verify bridge at %s: %w", newIP, err)
+	}
+	if config.BridgeID != r.bridgeID {
+		return "", fmt.Errorf("bridge at %s is %s, expected %s", newIP, config.BridgeID, r.bridgeID)
+	}
+	return newIP, nil
+}
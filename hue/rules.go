@@ -0,0 +1,277 @@
+package hue
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// Rule represents a rule stored on the bridge, which runs actions when
+// its sensor conditions are met.
+type Rule struct {
+	ID             string
+	Name           string
+	Status         string // RuleEnabled or RuleDisabled
+	Conditions     []Condition
+	Actions        []Action
+	TimesTriggered int
+	LastTriggered  time.Time // zero if the rule never triggered
+}
+
+// Rule statuses. The bridge disables rules whose actions keep failing.
+const (
+	RuleEnabled  = "enabled"
+	RuleDisabled = "disabled"
+)
+
+// The bridge accepts at most this many conditions and actions per rule.
+const (
+	MaxRuleConditions = 8
+	MaxRuleActions    = 8
+)
+
+// Condition is one test a rule makes. A rule triggers when any of the
+// attributes it tests changes and all of its conditions hold.
+type Condition struct {
+	Address  string `json:"address"`         // e.g. "/sensors/5/state/buttonevent"
+	Operator string `json:"operator"`        // see the Op constants
+	Value    string `json:"value,omitempty"` // empty for OpChanged
+}
+
+// Condition operators.
+const (
+	OpEqual          = "eq"
+	OpGreater        = "gt"
+	OpLess           = "lt"
+	OpChanged        = "dx"         // the attribute changed
+	OpChangedDelayed = "ddx"        // the attribute changed Value (e.g. "PT00:00:30") ago
+	OpStable         = "stable"     // the attribute hasn't changed for Value
+	OpNotStable      = "not stable" // the attribute changed within Value
+	OpIn             = "in"         // the time is in Value, e.g. "T08:00:00/T20:00:00"
+	OpNotIn          = "not in"
+)
+
+// Action is a request the bridge sends to itself when a rule triggers.
+// Unlike a ScheduleCommand's, its address has no /api/<username> prefix.
+type Action struct {
+	Address string          `json:"address"` // e.g. "/groups/3/action"
+	Method  string          `json:"method"`  // "PUT", "POST" or "DELETE"
+	Body    json.RawMessage `json:"body"`
+}
+
+// RuleUpdate holds the rule attributes to change. Only the fields that
+// are set are sent; conditions and actions are replaced as a whole.
+type RuleUpdate struct {
+	Name       *string     `json:"name,omitempty"`
+	Status     *string     `json:"status,omitempty"`
+	Conditions []Condition `json:"conditions,omitempty"`
+	Actions    []Action    `json:"actions,omitempty"`
+}
+
+type ruleResponse struct {
+	Name           string      `json:"name"`
+	Status         string      `json:"status"`
+	Conditions     []Condition `json:"conditions"`
+	Actions        []Action    `json:"actions"`
+	TimesTriggered int         `json:"timestriggered"`
+	LastTriggered  string      `json:"lasttriggered"`
+}
+
+// ruleRequest is the body for creating a rule.
+type ruleRequest struct {
+	Name       string      `json:"name"`
+	Status     string      `json:"status"`
+	Conditions []Condition `json:"conditions"`
+	Actions    []Action    `json:"actions"`
+}
+
+func (rr ruleResponse) toRule(id string) Rule {
+	rule := Rule{
+		ID:             id,
+		Name:           rr.Name,
+		Status:         rr.Status,
+		Conditions:     rr.Conditions,
+		Actions:        rr.Actions,
+		TimesTriggered: rr.TimesTriggered,
+	}
+	// "none" until the rule first triggers.
+	if t, err := time.Parse(lastUpdatedLayout, rr.LastTriggered); err == nil {
+		rule.LastTriggered = t
+	}
+	return rule
+}
+
+// rulesFromResponse converts the bridge's ID -> rule map to a list
+// sorted by ID.
+func rulesFromResponse(rulesMap map[string]ruleResponse) []Rule {
+	rules := make([]Rule, 0, len(rulesMap))
+	for id, rr := range rulesMap {
+		rules = append(rules, rr.toRule(id))
+	}
+	slices.SortFunc(rules, func(a, b Rule) int {
+		return compareNumericIDs(a.ID, b.ID)
+	})
+	return rules
+}
+
+// GetRules returns all rules, sorted by ID.
+func (c *Client) GetRules(ctx context.Context) ([]Rule, error) {
+	url := fmt.Sprintf("%s/%s/rules", c.baseURL(), c.username)
+	resp, err := c.getWithRetry(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("get request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+
+	if err := c.checkError(data); err != nil {
+		return nil, err
+	}
+
+	var rulesMap map[string]ruleResponse
+	if err := json.Unmarshal(data, &rulesMap); err != nil {
+		return nil, fmt.Errorf("unmarshal response: %w", err)
+	}
+
+	return rulesFromResponse(rulesMap), nil
+}
+
+// GetRule returns a single rule by ID.
+func (c *Client) GetRule(ctx context.Context, id string) (*Rule, error) {
+	url := fmt.Sprintf("%s/%s/rules/%s", c.baseURL(), c.username, id)
+	resp, err := c.getWithRetry(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("get request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+
+	if err := c.checkError(data); err != nil {
+		return nil, err
+	}
+
+	var rr ruleResponse
+	if err := json.Unmarshal(data, &rr); err != nil {
+		return nil, fmt.Errorf("unmarshal response: %w", err)
+	}
+
+	rule := rr.toRule(id)
+	return &rule, nil
+}
+
+// CreateRule creates a rule on the bridge and returns its ID. Only the
+// name, status, conditions and actions of rule are used; an empty Status
+// means enabled.
+func (c *Client) CreateRule(ctx context.Context, rule Rule) (string, error) {
+	url := fmt.Sprintf("%s/%s/rules", c.baseURL(), c.username)
+
+	body := ruleRequest{
+		Name:       rule.Name,
+		Status:     rule.Status,
+		Conditions: rule.Conditions,
+		Actions:    rule.Actions,
+	}
+	if body.Status == "" {
+		body.Status = RuleEnabled
+	}
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return "", fmt.Errorf("marshal request: %w", err)
+	}
+
+	resp, err := c.postWithRetry(ctx, url, "application/json", jsonBody)
+	if err != nil {
+		return "", fmt.Errorf("post request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("read response: %w", err)
+	}
+
+	results, err := parseBridgeResults(data)
+	if err != nil {
+		return "", fmt.Errorf("unmarshal response: %w", err)
+	}
+
+	if len(results) == 0 {
+		return "", fmt.Errorf("empty response from bridge")
+	}
+
+	if err := bridgeErrors(results); err != nil {
+		return "", err
+	}
+
+	var success createResourceSuccessResponse
+	if err := json.Unmarshal(results[0].Success, &success); err == nil && success.ID != "" {
+		return success.ID, nil
+	}
+
+	return "", fmt.Errorf("unexpected response format: %s", string(data))
+}
+
+// UpdateRule changes the attributes of a rule set in update.
+func (c *Client) UpdateRule(ctx context.Context, id string, update RuleUpdate) error {
+	url := fmt.Sprintf("%s/%s/rules/%s", c.baseURL(), c.username, id)
+
+	jsonBody, err := json.Marshal(update)
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewReader(jsonBody))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.doWithRetry(req)
+	if err != nil {
+		return fmt.Errorf("put request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+
+	return c.checkError(data)
+}
+
+// DeleteRule deletes a rule.
+func (c *Client) DeleteRule(ctx context.Context, id string) error {
+	url := fmt.Sprintf("%s/%s/rules/%s", c.baseURL(), c.username, id)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+
+	resp, err := c.doWithRetry(req)
+	if err != nil {
+		return fmt.Errorf("delete request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+
+	return c.checkError(data)
+}
@@ -0,0 +1,466 @@
+package hue
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule represents a timer or alarm stored on the bridge. The bridge
+// runs it on its own, whether or not huey is running.
+type Schedule struct {
+	ID          string
+	Name        string
+	Description string
+	Command     ScheduleCommand
+	LocalTime   string // bridge time pattern, e.g. "W124/T07:00:00"; see ParseTimePattern
+	Status      string // ScheduleEnabled or ScheduleDisabled
+	AutoDelete  bool   // delete a one-off schedule once it has run
+}
+
+// Schedule statuses.
+const (
+	ScheduleEnabled  = "enabled"
+	ScheduleDisabled = "disabled"
+)
+
+// ScheduleCommand is the request the bridge sends to itself when a
+// schedule fires.
+type ScheduleCommand struct {
+	Address string          `json:"address"` // e.g. "/api/<username>/groups/1/action"
+	Method  string          `json:"method"`  // "PUT", "POST" or "DELETE"
+	Body    json.RawMessage `json:"body"`
+}
+
+// LightStateCommand returns a schedule command that sets the state of a
+// light.
+func (c *Client) LightStateCommand(id string, state LightState) (ScheduleCommand, error) {
+	body, err := json.Marshal(state)
+	if err != nil {
+		return ScheduleCommand{}, fmt.Errorf("marshal command: %w", err)
+	}
+	return ScheduleCommand{
+		Address: fmt.Sprintf("/api/%s/lights/%s/state", c.username, id),
+		Method:  http.MethodPut,
+		Body:    body,
+	}, nil
+}
+
+// GroupActionCommand returns a schedule command that sets the state of a
+// group, or activates a scene with action.Scene.
+func (c *Client) GroupActionCommand(id string, action GroupAction) (ScheduleCommand, error) {
+	body, err := json.Marshal(action)
+	if err != nil {
+		return ScheduleCommand{}, fmt.Errorf("marshal command: %w", err)
+	}
+	return ScheduleCommand{
+		Address: fmt.Sprintf("/api/%s/groups/%s/action", c.username, id),
+		Method:  http.MethodPut,
+		Body:    body,
+	}, nil
+}
+
+// ScheduleUpdate holds the schedule attributes to change. Only the fields
+// that are set are sent.
+type ScheduleUpdate struct {
+	Name        *string          `json:"name,omitempty"`
+	Description *string          `json:"description,omitempty"`
+	Command     *ScheduleCommand `json:"command,omitempty"`
+	LocalTime   *string          `json:"localtime,omitempty"`
+	Status      *string          `json:"status,omitempty"`
+	AutoDelete  *bool            `json:"autodelete,omitempty"`
+}
+
+type scheduleResponse struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Command     ScheduleCommand `json:"command"`
+	LocalTime   string          `json:"localtime"`
+	Status      string          `json:"status"`
+	AutoDelete  bool            `json:"autodelete"`
+}
+
+func (sr scheduleResponse) toSchedule(id string) Schedule {
+	return Schedule{
+		ID:          id,
+		Name:        sr.Name,
+		Description: sr.Description,
+		Command:     sr.Command,
+		LocalTime:   sr.LocalTime,
+		Status:      sr.Status,
+		AutoDelete:  sr.AutoDelete,
+	}
+}
+
+// schedulesFromResponse converts the bridge's ID -> schedule map to a
+// list sorted by ID.
+func schedulesFromResponse(schedulesMap map[string]scheduleResponse) []Schedule {
+	schedules := make([]Schedule, 0, len(schedulesMap))
+	for id, sr := range schedulesMap {
+		schedules = append(schedules, sr.toSchedule(id))
+	}
+	slices.SortFunc(schedules, func(a, b Schedule) int {
+		return compareNumericIDs(a.ID, b.ID)
+	})
+	return schedules
+}
+
+// GetSchedules returns all schedules, sorted by ID.
+func (c *Client) GetSchedules(ctx context.Context) ([]Schedule, error) {
+	url := fmt.Sprintf("%s/%s/schedules", c.baseURL(), c.username)
+	resp, err := c.getWithRetry(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("get request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+
+	if err := c.checkError(data); err != nil {
+		return nil, err
+	}
+
+	var schedulesMap map[string]scheduleResponse
+	if err := json.Unmarshal(data, &schedulesMap); err != nil {
+		return nil, fmt.Errorf("unmarshal response: %w", err)
+	}
+
+	return schedulesFromResponse(schedulesMap), nil
+}
+
+// GetSchedule returns a single schedule by ID.
+func (c *Client) GetSchedule(ctx context.Context, id string) (*Schedule, error) {
+	url := fmt.Sprintf("%s/%s/schedules/%s", c.baseURL(), c.username, id)
+	resp, err := c.getWithRetry(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("get request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+
+	if err := c.checkError(data); err != nil {
+		return nil, err
+	}
+
+	var sr scheduleResponse
+	if err := json.Unmarshal(data, &sr); err != nil {
+		return nil, fmt.Errorf("unmarshal response: %w", err)
+	}
+
+	schedule := sr.toSchedule(id)
+	return &schedule, nil
+}
+
+// CreateSchedule creates a schedule on the bridge and returns its ID.
+// schedule.ID is ignored; an empty Status means enabled.
+func (c *Client) CreateSchedule(ctx context.Context, schedule Schedule) (string, error) {
+	url := fmt.Sprintf("%s/%s/schedules", c.baseURL(), c.username)
+
+	body := scheduleResponse{
+		Name:        schedule.Name,
+		Description: schedule.Description,
+		Command:     schedule.Command,
+		LocalTime:   schedule.LocalTime,
+		Status:      schedule.Status,
+		AutoDelete:  schedule.AutoDelete,
+	}
+	if body.Status == "" {
+		body.Status = ScheduleEnabled
+	}
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return "", fmt.Errorf("marshal request: %w", err)
+	}
+
+	resp, err := c.postWithRetry(ctx, url, "application/json", jsonBody)
+	if err != nil {
+		return "", fmt.Errorf("post request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("read response: %w", err)
+	}
+
+	results, err := parseBridgeResults(data)
+	if err != nil {
+		return "", fmt.Errorf("unmarshal response: %w", err)
+	}
+
+	if len(results) == 0 {
+		return "", fmt.Errorf("empty response from bridge")
+	}
+
+	if err := bridgeErrors(results); err != nil {
+		return "", err
+	}
+
+	var success createResourceSuccessResponse
+	if err := json.Unmarshal(results[0].Success, &success); err == nil && success.ID != "" {
+		return success.ID, nil
+	}
+
+	return "", fmt.Errorf("unexpected response format: %s", string(data))
+}
+
+// UpdateSchedule changes the attributes of a schedule set in update.
+func (c *Client) UpdateSchedule(ctx context.Context, id string, update ScheduleUpdate) error {
+	url := fmt.Sprintf("%s/%s/schedules/%s", c.baseURL(), c.username, id)
+
+	jsonBody, err := json.Marshal(update)
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewReader(jsonBody))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.doWithRetry(req)
+	if err != nil {
+		return fmt.Errorf("put request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+
+	return c.checkError(data)
+}
+
+// DeleteSchedule deletes a schedule.
+func (c *Client) DeleteSchedule(ctx context.Context, id string) error {
+	url := fmt.Sprintf("%s/%s/schedules/%s", c.baseURL(), c.username, id)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+
+	resp, err := c.doWithRetry(req)
+	if err != nil {
+		return fmt.Errorf("delete request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+
+	return c.checkError(data)
+}
+
+// TimeKind is the kind of a schedule's time pattern.
+type TimeKind int
+
+const (
+	TimeAbsolute  TimeKind = iota // once, at a date and time
+	TimeRecurring                 // on some days of the week, at a time of day
+	TimeTimer                     // once or repeatedly, after a countdown
+)
+
+// Weekdays is a set of days of the week, as used by recurring schedules.
+type Weekdays int
+
+const (
+	Sunday Weekdays = 1 << iota
+	Saturday
+	Friday
+	Thursday
+	Wednesday
+	Tuesday
+	Monday
+
+	EveryDay = Monday | Tuesday | Wednesday | Thursday | Friday | Saturday | Sunday
+	WorkDays = Monday | Tuesday | Wednesday | Thursday | Friday
+	Weekend  = Saturday | Sunday
+)
+
+// weekdayBits maps time.Weekday (Sunday = 0) to Weekdays.
+var weekdayBits = [7]Weekdays{Sunday, Monday, Tuesday, Wednesday, Thursday, Friday, Saturday}
+
+// Has reports whether day is in w.
+func (w Weekdays) Has(day time.Weekday) bool {
+	return w&weekdayBits[day] != 0
+}
+
+// Days returns the days in w, starting with Monday.
+func (w Weekdays) Days() []time.Weekday {
+	var days []time.Weekday
+	for _, day := range []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday} {
+		if w.Has(day) {
+			days = append(days, day)
+		}
+	}
+	return days
+}
+
+// WeekdaysOf returns the set of the given days.
+func WeekdaysOf(days ...time.Weekday) Weekdays {
+	var w Weekdays
+	for _, day := range days {
+		w |= weekdayBits[day]
+	}
+	return w
+}
+
+// RepeatForever makes a timer restart every time it runs out.
+const RepeatForever = -1
+
+// TimePattern is a parsed schedule time. Times are in the bridge's time
+// zone.
+type TimePattern struct {
+	Kind      TimeKind
+	At        time.Time     // TimeAbsolute: date and time
+	Weekdays  Weekdays      // TimeRecurring: days of the week
+	TimeOfDay time.Duration // TimeRecurring: time since midnight
+	Timer     time.Duration // TimeTimer: countdown
+	Repeat    int           // TimeTimer: number of runs, RepeatForever, or 0 for once
+	Random    time.Duration // random delay of up to this long, 0 for none
+}
+
+// absoluteLayout is the layout of absolute schedule times.
+const absoluteLayout = "2006-01-02T15:04:05"
+
+// ParseTimePattern parses a bridge time pattern:
+//
+//	2026-10-18T07:00:00            absolute
+//	W124/T07:00:00                 recurring on weekdays (see Weekdays)
+//	PT00:10:00                     timer, once
+//	R05/PT00:10:00, R/PT00:10:00   timer, 5 times or forever
+//
+// Any of them may end in a random delay, e.g. "A00:30:00". Absolute times
+// are read in loc, which should be the bridge's time zone (see
+// BridgeConfig.Location).
+func ParseTimePattern(pattern string, loc *time.Location) (TimePattern, error) {
+	var p TimePattern
+
+	base, random, hasRandom := strings.Cut(pattern, "A")
+	if hasRandom {
+		d, err := parseClock(random)
+		if err != nil {
+			return p, fmt.Errorf("time pattern %q: random delay: %w", pattern, err)
+		}
+		p.Random = d
+	}
+
+	switch {
+	case strings.HasPrefix(base, "W"):
+		days, clock, ok := strings.Cut(base[1:], "/T")
+		if !ok {
+			return p, fmt.Errorf("time pattern %q: expected W<days>/T<hh:mm:ss>", pattern)
+		}
+		bits, err := strconv.Atoi(days)
+		if err != nil || bits < 1 || bits > int(EveryDay) {
+			return p, fmt.Errorf("time pattern %q: invalid weekdays %q", pattern, days)
+		}
+		d, err := parseClock(clock)
+		if err != nil {
+			return p, fmt.Errorf("time pattern %q: %w", pattern, err)
+		}
+		p.Kind, p.Weekdays, p.TimeOfDay = TimeRecurring, Weekdays(bits), d
+
+	case strings.HasPrefix(base, "R") || strings.HasPrefix(base, "PT"):
+		timer := base
+		if rest, ok := strings.CutPrefix(base, "R"); ok {
+			count, t, ok := strings.Cut(rest, "/")
+			if !ok {
+				return p, fmt.Errorf("time pattern %q: expected R<count>/PT<hh:mm:ss>", pattern)
+			}
+			p.Repeat = RepeatForever
+			if count != "" {
+				n, err := strconv.Atoi(count)
+				if err != nil || n < 1 {
+					return p, fmt.Errorf("time pattern %q: invalid repeat count %q", pattern, count)
+				}
+				p.Repeat = n
+			}
+			timer = t
+		}
+		clock, ok := strings.CutPrefix(timer, "PT")
+		if !ok {
+			return p, fmt.Errorf("time pattern %q: expected PT<hh:mm:ss>", pattern)
+		}
+		d, err := parseClock(clock)
+		if err != nil {
+			return p, fmt.Errorf("time pattern %q: %w", pattern, err)
+		}
+		p.Kind, p.Timer = TimeTimer, d
+
+	default:
+		t, err := time.ParseInLocation(absoluteLayout, base, loc)
+		if err != nil {
+			return p, fmt.Errorf("time pattern %q: unrecognized format", pattern)
+		}
+		p.Kind, p.At = TimeAbsolute, t
+	}
+
+	return p, nil
+}
+
+// String formats p as a bridge time pattern.
+func (p TimePattern) String() string {
+	var s string
+	switch p.Kind {
+	case TimeAbsolute:
+		s = p.At.Format(absoluteLayout)
+	case TimeRecurring:
+		s = fmt.Sprintf("W%03d/T%s", p.Weekdays, formatClock(p.TimeOfDay))
+	case TimeTimer:
+		s = "PT" + formatClock(p.Timer)
+		switch {
+		case p.Repeat == RepeatForever:
+			s = "R/" + s
+		case p.Repeat > 1:
+			s = fmt.Sprintf("R%02d/%s", p.Repeat, s)
+		}
+	}
+	if p.Random > 0 {
+		s += "A" + formatClock(p.Random)
+	}
+	return s
+}
+
+// parseClock parses "hh:mm:ss" as a duration.
+func parseClock(clock string) (time.Duration, error) {
+	parts := strings.Split(clock, ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid time %q, expected hh:mm:ss", clock)
+	}
+	var d time.Duration
+	for i, unit := range []time.Duration{time.Hour, time.Minute, time.Second} {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil || n < 0 || (i > 0 && n > 59) {
+			return 0, fmt.Errorf("invalid time %q, expected hh:mm:ss", clock)
+		}
+		d += time.Duration(n) * unit
+	}
+	return d, nil
+}
+
+// formatClock formats a duration as "hh:mm:ss", dropping fractions of a
+// second.
+func formatClock(d time.Duration) string {
+	d = d.Truncate(time.Second)
+	return fmt.Sprintf("%02d:%02d:%02d", int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60)
+}
@@ -0,0 +1,239 @@
+package hue
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// Sensor represents a Hue sensor, such as a motion sensor, dimmer switch
+// or the bridge's built-in daylight sensor.
+type Sensor struct {
+	ID       string
+	Name     string
+	Type     string // e.g. "ZLLPresence", "ZLLSwitch", "Daylight"
+	ModelID  string
+	State    SensorState
+	RawState map[string]any // as reported, e.g. {"presence": true, "lastupdated": "..."}
+	Config   SensorConfig
+}
+
+// SensorState is the last reading of a sensor. Only the fields that apply
+// to the sensor's type are set.
+type SensorState struct {
+	LastUpdated time.Time // zero if the sensor never reported
+
+	Presence    *bool    // ZLLPresence, CLIPPresence
+	Temperature *float64 // ZLLTemperature, CLIPTemperature, in °C
+	LightLevel  *int     // ZLLLightLevel, CLIPLightLevel, as 10000 log10(lux) + 1
+	Dark        *bool    // ZLLLightLevel: below the configured dark threshold
+	Daylight    *bool    // ZLLLightLevel, Daylight: between sunrise and sunset
+	ButtonEvent *int     // ZLLSwitch, ZGPSwitch: see ButtonEvent
+	Flag        *bool    // CLIPGenericFlag
+	Status      *int     // CLIPGenericStatus
+}
+
+// Lux converts LightLevel to lux. It returns 0 if LightLevel is not set.
+func (s SensorState) Lux() float64 {
+	if s.LightLevel == nil {
+		return 0
+	}
+	return math.Pow(10, float64(*s.LightLevel-1)/10000)
+}
+
+// Button event codes of ZLLSwitch sensors. A ButtonEvent is the button
+// number (1-4) times 1000 plus one of these.
+const (
+	ButtonInitialPress = 0
+	ButtonHold         = 1
+	ButtonShortRelease = 2
+	ButtonLongRelease  = 3
+)
+
+// SensorConfig holds a sensor's settings and health.
+type SensorConfig struct {
+	On             bool  // whether the sensor is enabled
+	Reachable      *bool // nil for sensors that are always reachable, e.g. Daylight
+	Battery        *int  // percent; nil for sensors without a battery
+	Sensitivity    *int  // motion sensitivity, 0 to SensitivityMax
+	SensitivityMax *int
+	LEDIndication  *bool // whether the LED lights up on motion
+}
+
+// SensorConfigUpdate holds the sensor settings to change. Only the fields
+// that are set are sent.
+type SensorConfigUpdate struct {
+	On            *bool `json:"on,omitempty"`
+	Sensitivity   *int  `json:"sensitivity,omitempty"`
+	LEDIndication *bool `json:"ledindication,omitempty"`
+}
+
+type sensorResponse struct {
+	Name    string               `json:"name"`
+	Type    string               `json:"type"`
+	ModelID string               `json:"modelid"`
+	State   json.RawMessage      `json:"state"`
+	Config  sensorConfigResponse `json:"config"`
+}
+
+type sensorStateResponse struct {
+	LastUpdated string `json:"lastupdated"`
+	Presence    *bool  `json:"presence"`
+	Temperature *int   `json:"temperature"` // hundredths of °C
+	LightLevel  *int   `json:"lightlevel"`
+	Dark        *bool  `json:"dark"`
+	Daylight    *bool  `json:"daylight"`
+	ButtonEvent *int   `json:"buttonevent"`
+	Flag        *bool  `json:"flag"`
+	Status      *int   `json:"status"`
+}
+
+type sensorConfigResponse struct {
+	On             bool  `json:"on"`
+	Reachable      *bool `json:"reachable"`
+	Battery        *int  `json:"battery"`
+	Sensitivity    *int  `json:"sensitivity"`
+	SensitivityMax *int  `json:"sensitivitymax"`
+	LEDIndication  *bool `json:"ledindication"`
+}
+
+// lastUpdatedLayout is the bridge's format for sensor update times, in UTC.
+const lastUpdatedLayout = "2006-01-02T15:04:05"
+
+func (sr sensorResponse) toSensor(id string) Sensor {
+	// State values vary by sensor type; those that don't match the
+	// expected types are left unset rather than failing the whole list.
+	var raw map[string]any
+	_ = json.Unmarshal(sr.State, &raw)
+	var state sensorStateResponse
+	_ = json.Unmarshal(sr.State, &state)
+
+	sensor := Sensor{
+		ID:       id,
+		Name:     sr.Name,
+		Type:     sr.Type,
+		ModelID:  sr.ModelID,
+		RawState: raw,
+		State: SensorState{
+			Presence:    state.Presence,
+			LightLevel:  state.LightLevel,
+			Dark:        state.Dark,
+			Daylight:    state.Daylight,
+			ButtonEvent: state.ButtonEvent,
+			Flag:        state.Flag,
+			Status:      state.Status,
+		},
+		Config: SensorConfig(sr.Config),
+	}
+	if state.Temperature != nil {
+		celsius := float64(*state.Temperature) / 100
+		sensor.State.Temperature = &celsius
+	}
+	// "none" until the sensor first reports.
+	if t, err := time.Parse(lastUpdatedLayout, state.LastUpdated); err == nil {
+		sensor.State.LastUpdated = t
+	}
+	return sensor
+}
+
+// sensorsFromResponse converts the bridge's ID -> sensor map to a list
+// sorted by ID.
+func sensorsFromResponse(sensorsMap map[string]sensorResponse) []Sensor {
+	sensors := make([]Sensor, 0, len(sensorsMap))
+	for id, sr := range sensorsMap {
+		sensors = append(sensors, sr.toSensor(id))
+	}
+	slices.SortFunc(sensors, func(a, b Sensor) int {
+		return compareNumericIDs(a.ID, b.ID)
+	})
+	return sensors
+}
+
+// GetSensors returns all sensors, sorted by ID.
+func (c *Client) GetSensors(ctx context.Context) ([]Sensor, error) {
+	url := fmt.Sprintf("%s/%s/sensors", c.baseURL(), c.username)
+	resp, err := c.getWithRetry(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("get request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+
+	if err := c.checkError(data); err != nil {
+		return nil, err
+	}
+
+	var sensorsMap map[string]sensorResponse
+	if err := json.Unmarshal(data, &sensorsMap); err != nil {
+		return nil, fmt.Errorf("unmarshal response: %w", err)
+	}
+
+	return sensorsFromResponse(sensorsMap), nil
+}
+
+// GetSensor returns a single sensor by ID.
+func (c *Client) GetSensor(ctx context.Context, id string) (*Sensor, error) {
+	url := fmt.Sprintf("%s/%s/sensors/%s", c.baseURL(), c.username, id)
+	resp, err := c.getWithRetry(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("get request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+
+	if err := c.checkError(data); err != nil {
+		return nil, err
+	}
+
+	var sr sensorResponse
+	if err := json.Unmarshal(data, &sr); err != nil {
+		return nil, fmt.Errorf("unmarshal response: %w", err)
+	}
+
+	sensor := sr.toSensor(id)
+	return &sensor, nil
+}
+
+// SetSensorConfig changes a sensor's settings, e.g. to disable a motion
+// sensor or lower its sensitivity.
+func (c *Client) SetSensorConfig(ctx context.Context, id string, update SensorConfigUpdate) error {
+	url := fmt.Sprintf("%s/%s/sensors/%s/config", c.baseURL(), c.username, id)
+
+	jsonBody, err := json.Marshal(update)
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewReader(jsonBody))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.doWithRetry(req)
+	if err != nil {
+		return fmt.Errorf("put request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+
+	return c.checkError(data)
+}
@@ -0,0 +1,70 @@
+package hue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// BridgeState is a snapshot of everything on the bridge, fetched in a
+// single request.
+type BridgeState struct {
+	Lights    []Light
+	Groups    []Group
+	Scenes    []Scene
+	Sensors   []Sensor
+	Schedules []Schedule
+	Rules     []Rule
+	Config    BridgeConfig
+}
+
+type fullStateResponse struct {
+	Lights    map[string]lightResponse    `json:"lights"`
+	Groups    map[string]groupResponse    `json:"groups"`
+	Scenes    map[string]sceneResponse    `json:"scenes"`
+	Sensors   map[string]sensorResponse   `json:"sensors"`
+	Schedules map[string]scheduleResponse `json:"schedules"`
+	Rules     map[string]ruleResponse     `json:"rules"`
+	Config    BridgeConfig                `json:"config"`
+}
+
+// GetFullState returns all lights, groups, scenes, sensors, schedules,
+// rules and the bridge configuration in one round-trip. Prefer it over
+// several list calls when more than one kind of resource is needed.
+func (c *Client) GetFullState(ctx context.Context) (*BridgeState, error) {
+	url := fmt.Sprintf("%s/%s", c.baseURL(), c.username)
+	resp, err := c.getWithRetry(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("get request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+
+	if err := c.checkError(data); err != nil {
+		return nil, err
+	}
+
+	var full fullStateResponse
+	if err := json.Unmarshal(data, &full); err != nil {
+		return nil, fmt.Errorf("unmarshal response: %w", err)
+	}
+
+	config := full.Config
+	config.BridgeID = strings.ToLower(config.BridgeID)
+
+	return &BridgeState{
+		Lights:    lightsFromResponse(full.Lights),
+		Groups:    groupsFromResponse(full.Groups),
+		Scenes:    scenesFromResponse(full.Scenes),
+		Sensors:   sensorsFromResponse(full.Sensors),
+		Schedules: schedulesFromResponse(full.Schedules),
+		Rules:     rulesFromResponse(full.Rules),
+		Config:    config,
+	}, nil
+}
@@ -1,8 +1,12 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/LarsEckart/huey/auth"
 	"github.com/LarsEckart/huey/cmd"
@@ -12,13 +16,21 @@ import (
 )
 
 func rootAction(command *cobra.Command, args []string) error {
-	cfg, err := auth.EnsureAuthenticated()
+	ctx := command.Context()
+	cfg, err := auth.EnsureAuthenticated(ctx)
 	if err != nil {
 		return fmt.Errorf("ensure authentication: %w", err)
 	}
 
-	client := hue.NewClient(cfg.BridgeIP, cfg.Username)
-	if err := tui.Run(client); err != nil {
+	opts := cmd.ClientOptions(command)
+	err = tui.Run(ctx, auth.NewClient(cfg, os.Stderr, opts...))
+	if errors.Is(err, hue.ErrUnauthorized) {
+		if err := auth.Repair(ctx, cfg, os.Stderr); err != nil {
+			return err
+		}
+		err = tui.Run(ctx, auth.NewClient(cfg, os.Stderr, opts...))
+	}
+	if err != nil {
 		return fmt.Errorf("run tui: %w", err)
 	}
 
@@ -36,6 +48,7 @@ func newRootCmd() *cobra.Command {
 		RunE:          rootAction,
 	}
 	rootCmd.SetVersionTemplate("{{.Name}} version {{.Version}}\n")
+	cmd.BindGlobalFlags(rootCmd)
 
 	rootCmd.AddCommand(cmd.LightsCmd)
 	rootCmd.AddCommand(cmd.LightCmd)
@@ -45,13 +58,31 @@ func newRootCmd() *cobra.Command {
 	rootCmd.AddCommand(cmd.ScenesCmd)
 	rootCmd.AddCommand(cmd.SceneCmd)
 	rootCmd.AddCommand(cmd.SceneCreateCmd)
+	rootCmd.AddCommand(cmd.SensorsCmd)
+	rootCmd.AddCommand(cmd.SensorCmd)
+	rootCmd.AddCommand(cmd.ScheduleCmd)
+	rootCmd.AddCommand(cmd.RuleCmd)
+	rootCmd.AddCommand(cmd.WatchCmd)
+	rootCmd.AddCommand(cmd.DiscoverCmd)
+	rootCmd.AddCommand(cmd.AuthCmd)
+	cmd.RepairOnUnauthorized(rootCmd)
 
 	return rootCmd
 }
 
 func main() {
-	if err := newRootCmd().Execute(); err != nil {
+	// Ctrl-C cancels in-flight bridge requests instead of waiting for them.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := newRootCmd().ExecuteContext(ctx); err != nil {
+		if ctx.Err() != nil {
+			os.Exit(130) // interrupted; the error is just the cancellation
+		}
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		if errors.Is(err, hue.ErrCertificateChanged) {
+			fmt.Fprintln(os.Stderr, "If the bridge was reset or replaced, run 'huey auth --repin' to trust its new certificate.")
+		}
 		os.Exit(1)
 	}
 }
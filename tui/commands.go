@@ -1,12 +1,47 @@
 package tui
 
 import (
+	"time"
+
 	"github.com/LarsEckart/huey/hue"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
+// loadState fetches lights, groups and scenes in one request.
+func (m Model) loadState() tea.Msg {
+	state, err := m.client.GetFullState(m.ctx)
+	if err != nil {
+		return errMsg{err: err}
+	}
+	return stateLoadedMsg{state: state}
+}
+
+// watch forwards bridge events to m.events until the TUI exits.
+func (m Model) watch() tea.Msg {
+	err := m.client.Watch(m.ctx, func(event hue.Event) {
+		select {
+		case m.events <- event:
+		case <-m.ctx.Done():
+		}
+	})
+	if err != nil && m.ctx.Err() == nil {
+		return errMsg{err: err}
+	}
+	return nil
+}
+
+// waitForEvent waits for the next bridge event.
+func (m Model) waitForEvent() tea.Msg {
+	select {
+	case event := <-m.events:
+		return bridgeEventMsg{event: event}
+	case <-m.ctx.Done():
+		return nil
+	}
+}
+
 func (m Model) loadLights() tea.Msg {
-	lights, err := m.client.GetLights()
+	lights, err := m.client.GetLights(m.ctx)
 	if err != nil {
 		return errMsg{err: err}
 	}
@@ -14,7 +49,7 @@ func (m Model) loadLights() tea.Msg {
 }
 
 func (m Model) loadGroups() tea.Msg {
-	groups, err := m.client.GetGroups()
+	groups, err := m.client.GetGroups(m.ctx)
 	if err != nil {
 		return errMsg{err: err}
 	}
@@ -22,16 +57,53 @@ func (m Model) loadGroups() tea.Msg {
 }
 
 func (m Model) loadScenes() tea.Msg {
-	scenes, err := m.client.GetScenes()
+	scenes, err := m.client.GetScenes(m.ctx)
 	if err != nil {
 		return errMsg{err: err}
 	}
 	return scenesLoadedMsg{scenes: scenes}
 }
 
+func (m Model) loadSensors() tea.Msg {
+	sensors, err := m.client.GetSensors(m.ctx)
+	if err != nil {
+		return errMsg{err: err}
+	}
+	return sensorsLoadedMsg{sensors: sensors}
+}
+
+// loadSensor fetches a sensor after the bridge reported a change.
+func (m Model) loadSensor(id string) tea.Cmd {
+	return func() tea.Msg {
+		sensor, err := m.client.GetSensor(m.ctx, id)
+		if err != nil {
+			return errMsg{err: err}
+		}
+		return sensorLoadedMsg{sensor: *sensor}
+	}
+}
+
+// toggleSensor enables or disables a sensor.
+func (m Model) toggleSensor(id string, currentOn bool) tea.Cmd {
+	return func() tea.Msg {
+		newOn := !currentOn
+		if err := m.client.SetSensorConfig(m.ctx, id, hue.SensorConfigUpdate{On: &newOn}); err != nil {
+			return errMsg{err: err}
+		}
+		return sensorToggledMsg{id: id, newOn: newOn}
+	}
+}
+
+// sensorAgeTick schedules the next redraw of last-event ages.
+func sensorAgeTick() tea.Cmd {
+	return tea.Tick(30*time.Second, func(time.Time) tea.Msg {
+		return sensorAgeTickMsg{}
+	})
+}
+
 func (m Model) activateScene(id, name string) tea.Cmd {
 	return func() tea.Msg {
-		if err := m.client.ActivateScene(id); err != nil {
+		if err := m.client.ActivateScene(m.ctx, id, nil); err != nil {
 			return errMsg{err: err}
 		}
 		return sceneActivatedMsg{id: id, name: name}
@@ -40,7 +112,7 @@ func (m Model) activateScene(id, name string) tea.Cmd {
 
 func (m Model) createScene(name, groupID string) tea.Cmd {
 	return func() tea.Msg {
-		id, err := m.client.CreateScene(name, groupID)
+		id, err := m.client.CreateScene(m.ctx, name, groupID)
 		if err != nil {
 			return errMsg{err: err}
 		}
@@ -57,7 +129,7 @@ func (m Model) createScene(name, groupID string) tea.Cmd {
 
 func (m Model) deleteScene(id string) tea.Cmd {
 	return func() tea.Msg {
-		if err := m.client.DeleteScene(id); err != nil {
+		if err := m.client.DeleteScene(m.ctx, id); err != nil {
 			return errMsg{err: err}
 		}
 		return sceneDeletedMsg{id: id}
@@ -68,7 +140,7 @@ func (m Model) toggleLight(id string, currentOn bool) tea.Cmd {
 	return func() tea.Msg {
 		newOn := !currentOn
 		state := hue.LightState{On: &newOn}
-		if err := m.client.SetLightState(id, state); err != nil {
+		if err := m.client.SetLightState(m.ctx, id, state); err != nil {
 			return errMsg{err: err}
 		}
 		return lightToggledMsg{id: id, newOn: newOn}
@@ -80,16 +152,42 @@ func (m Model) toggleGroup(id string, anyOn bool) tea.Cmd {
 		// If any light is on, turn all off. Otherwise turn all on.
 		newOn := !anyOn
 		action := hue.GroupAction{On: &newOn}
-		if err := m.client.SetGroupState(id, action); err != nil {
+		if err := m.client.SetGroupState(m.ctx, id, action); err != nil {
 			return errMsg{err: err}
 		}
 		return groupToggledMsg{id: id, newOn: newOn}
 	}
 }
 
+// identifyLight makes a light blink once so it can be found.
+func (m Model) identifyLight(id string) tea.Cmd {
+	return func() tea.Msg {
+		if err := m.client.SetLightState(m.ctx, id, hue.LightState{Alert: hue.AlertSelect}); err != nil {
+			return errMsg{err: err}
+		}
+		return nil
+	}
+}
+
+// sendBrightnessStep changes the brightness of a light or group by delta.
+func (m Model) sendBrightnessStep(step brightnessStep) tea.Cmd {
+	return func() tea.Msg {
+		var err error
+		if step.tab == TabGroups {
+			err = m.client.SetGroupState(m.ctx, step.id, hue.GroupAction{BrightnessInc: &step.delta})
+		} else {
+			err = m.client.SetLightState(m.ctx, step.id, hue.LightState{BrightnessInc: &step.delta})
+		}
+		if err != nil {
+			return errMsg{err: err}
+		}
+		return brightnessSteppedMsg{}
+	}
+}
+
 func (m Model) renameLight(id, name string) tea.Cmd {
 	return func() tea.Msg {
-		if err := m.client.RenameLight(id, name); err != nil {
+		if err := m.client.RenameLight(m.ctx, id, name); err != nil {
 			return errMsg{err: err}
 		}
 		return lightRenamedMsg{id: id, newName: name}
@@ -98,7 +196,7 @@ func (m Model) renameLight(id, name string) tea.Cmd {
 
 func (m Model) renameGroup(id, name string) tea.Cmd {
 	return func() tea.Msg {
-		if err := m.client.RenameGroup(id, name); err != nil {
+		if err := m.client.RenameGroup(m.ctx, id, name); err != nil {
 			return errMsg{err: err}
 		}
 		return groupRenamedMsg{id: id, newName: name}
@@ -107,7 +205,7 @@ func (m Model) renameGroup(id, name string) tea.Cmd {
 
 func (m Model) deleteGroup(id string) tea.Cmd {
 	return func() tea.Msg {
-		if err := m.client.DeleteGroup(id); err != nil {
+		if err := m.client.DeleteGroup(m.ctx, id); err != nil {
 			return errMsg{err: err}
 		}
 		return groupDeletedMsg{id: id}
@@ -116,7 +214,7 @@ func (m Model) deleteGroup(id string) tea.Cmd {
 
 func (m Model) createGroup(name, groupType string, lightIDs []string) tea.Cmd {
 	return func() tea.Msg {
-		id, err := m.client.CreateGroup(name, groupType, lightIDs)
+		id, err := m.client.CreateGroup(m.ctx, name, groupType, lightIDs)
 		if err != nil {
 			return errMsg{err: err}
 		}
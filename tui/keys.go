@@ -4,22 +4,26 @@ import "github.com/charmbracelet/bubbles/key"
 
 // keyMap defines key bindings.
 type keyMap struct {
-	Up      key.Binding
-	Down    key.Binding
-	Toggle  key.Binding
-	Rename  key.Binding
-	Delete  key.Binding
-	Add     key.Binding
-	Info    key.Binding
-	TabNext key.Binding
-	TabPrev key.Binding
-	Quit    key.Binding
-	Confirm key.Binding
-	Cancel  key.Binding
-	Yes     key.Binding
-	No      key.Binding
-	Room    key.Binding
-	Zone    key.Binding
+	Up       key.Binding
+	Down     key.Binding
+	Toggle   key.Binding
+	Rename   key.Binding
+	Delete   key.Binding
+	Add      key.Binding
+	Info     key.Binding
+	Identify key.Binding
+	Brighter key.Binding
+	Dimmer   key.Binding
+	Units    key.Binding
+	TabNext  key.Binding
+	TabPrev  key.Binding
+	Quit     key.Binding
+	Confirm  key.Binding
+	Cancel   key.Binding
+	Yes      key.Binding
+	No       key.Binding
+	Room     key.Binding
+	Zone     key.Binding
 }
 
 var keys = keyMap{
@@ -51,6 +55,22 @@ var keys = keyMap{
 		key.WithKeys("i"),
 		key.WithHelp("i", "info"),
 	),
+	Identify: key.NewBinding(
+		key.WithKeys("i"),
+		key.WithHelp("i", "identify"),
+	),
+	Brighter: key.NewBinding(
+		key.WithKeys("+", "="),
+		key.WithHelp("+", "brighter"),
+	),
+	Dimmer: key.NewBinding(
+		key.WithKeys("-"),
+		key.WithHelp("-", "dimmer"),
+	),
+	Units: key.NewBinding(
+		key.WithKeys("u"),
+		key.WithHelp("u", "°C/°F"),
+	),
 	TabNext: key.NewBinding(
 		key.WithKeys("tab", "l"),
 		key.WithHelp("tab", "next tab"),
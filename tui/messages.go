@@ -2,6 +2,10 @@ package tui
 
 import "github.com/LarsEckart/huey/hue"
 
+type stateLoadedMsg struct {
+	state *hue.BridgeState
+}
+
 type lightsLoadedMsg struct {
 	lights []hue.Light
 }
@@ -10,6 +14,22 @@ type groupsLoadedMsg struct {
 	groups []hue.Group
 }
 
+type sensorsLoadedMsg struct {
+	sensors []hue.Sensor
+}
+
+type sensorLoadedMsg struct {
+	sensor hue.Sensor
+}
+
+type sensorToggledMsg struct {
+	id    string
+	newOn bool
+}
+
+// sensorAgeTickMsg redraws the sensors tab so last-event ages stay current.
+type sensorAgeTickMsg struct{}
+
 type errMsg struct {
 	err error
 }
@@ -58,3 +78,15 @@ type sceneCreatedMsg struct {
 type sceneDeletedMsg struct {
 	id string
 }
+
+type bridgeEventMsg struct {
+	event hue.Event
+}
+
+// brightnessStepDueMsg fires when the +/- keys may have paused. seq tells
+// whether another step came in since.
+type brightnessStepDueMsg struct {
+	seq int
+}
+
+type brightnessSteppedMsg struct{}
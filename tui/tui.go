@@ -1,6 +1,10 @@
 package tui
 
 import (
+	"context"
+	"errors"
+	"time"
+
 	"github.com/LarsEckart/huey/hue"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
@@ -13,6 +17,7 @@ const (
 	TabLights Tab = iota
 	TabGroups
 	TabScenes
+	TabSensors
 )
 
 // Mode represents the current interaction mode.
@@ -29,23 +34,46 @@ const (
 	ModeCreateSceneGroup
 	ModeCreateSceneName
 	ModeDeleteSceneConfirm
+	ModeSensorInfo
 )
 
+// Brightness steps for the +/- keys.
+const (
+	brightnessStepSize  = 25 // about 10%
+	brightnessStepDelay = 150 * time.Millisecond
+)
+
+// brightnessStep is a brightness change not yet sent to the bridge.
+// Steps are summed while a key repeats and sent once it pauses, so
+// holding + or - doesn't flood the bridge.
+type brightnessStep struct {
+	tab   Tab    // TabLights or TabGroups
+	id    string // light or group ID
+	delta int    // bri_inc to send
+	seq   int    // incremented with every step
+}
+
 // Model is the Bubble Tea model for the TUI.
 type Model struct {
-	client       *hue.Client
-	lights       []hue.Light
-	groups       []hue.Group
-	scenes       []hue.Scene
-	lightsLoaded bool
-	groupsLoaded bool
-	scenesLoaded bool
-	activeTab    Tab
-	lightCursor  int
-	groupCursor  int
-	sceneCursor  int
-	err          error
-	quitting     bool
+	ctx           context.Context // cancelled when the TUI exits
+	client        *hue.Client
+	events        chan hue.Event // changes reported by the bridge
+	lights        []hue.Light
+	groups        []hue.Group
+	scenes        []hue.Scene
+	sensors       []hue.Sensor
+	lightsLoaded  bool
+	groupsLoaded  bool
+	scenesLoaded  bool
+	sensorsLoaded bool
+	activeTab     Tab
+	lightCursor   int
+	groupCursor   int
+	sceneCursor   int
+	sensorCursor  int
+	err           error
+	quitting      bool
+	step          brightnessStep
 
 	// Rename mode
 	mode      Mode
@@ -55,6 +83,11 @@ type Model struct {
 	// Group info mode
 	infoGroupID string // ID of group being viewed
 
+	// Sensors tab
+	infoSensorID  string // ID of sensor being viewed
+	fahrenheit    bool   // show temperatures in °F instead of °C
+	sensorTicking bool   // whether sensorAgeTick is scheduled
+
 	// Delete confirmation mode
 	deleteGroupID   string // ID of group to delete
 	deleteGroupName string // Name of group to delete (for display)
@@ -76,28 +109,47 @@ type Model struct {
 	deleteSceneName string // Name of scene to delete (for display)
 }
 
-// New creates a new TUI model.
-func New(client *hue.Client) Model {
+// New creates a new TUI model. Bridge requests use ctx.
+func New(ctx context.Context, client *hue.Client) Model {
 	ti := textinput.New()
 	ti.CharLimit = 32 // Hue names limited to 32 chars
 	ti.Width = 24
 
 	return Model{
+		ctx:       ctx,
 		client:    client,
+		events:    make(chan hue.Event, 64),
 		activeTab: TabLights,
 		mode:      ModeNormal,
 		textInput: ti,
 	}
 }
 
-// Init initializes the model and loads data.
+// Init initializes the model, loads data and starts listening for
+// changes made elsewhere, e.g. with a wall switch or the Hue app.
 func (m Model) Init() tea.Cmd {
-	return tea.Batch(m.loadLights, m.loadGroups, m.loadScenes)
+	return tea.Batch(m.loadState, m.watch, m.waitForEvent)
 }
 
-// Run starts the TUI.
-func Run(client *hue.Client) error {
-	p := tea.NewProgram(New(client))
-	_, err := p.Run()
-	return err
+// Run starts the TUI. It exits when ctx is cancelled, and cancels bridge
+// requests still in flight when it exits. If the bridge rejects the
+// client's credentials, the TUI exits and Run returns an error matching
+// hue.ErrUnauthorized.
+func Run(ctx context.Context, client *hue.Client) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	p := tea.NewProgram(New(ctx, client), tea.WithContext(ctx))
+	final, err := p.Run()
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+
+	if m, ok := final.(Model); ok && errors.Is(m.err, hue.ErrUnauthorized) {
+		return m.err
+	}
+	return nil
 }
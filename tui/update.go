@@ -1,6 +1,11 @@
 package tui
 
 import (
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/LarsEckart/huey/hue"
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
@@ -8,6 +13,31 @@ import (
 
 // Update handles messages and updates the model.
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	// Bridge events update the lists in every mode
+	if msg, ok := msg.(bridgeEventMsg); ok {
+		return m.applyEvent(msg.event)
+	}
+	if msg, ok := msg.(brightnessStepDueMsg); ok {
+		return m.flushBrightnessStep(msg.seq)
+	}
+	// Sensor updates also keep the sensor info view current
+	if msg, ok := msg.(sensorLoadedMsg); ok {
+		for i := range m.sensors {
+			if m.sensors[i].ID == msg.sensor.ID {
+				m.sensors[i] = msg.sensor
+				break
+			}
+		}
+		return m, nil
+	}
+	if _, ok := msg.(sensorAgeTickMsg); ok {
+		if m.activeTab != TabSensors {
+			m.sensorTicking = false
+			return m, nil
+		}
+		return m, sensorAgeTick()
+	}
+
 	// Handle rename mode separately
 	if m.mode == ModeRename {
 		return m.updateRenameMode(msg)
@@ -18,6 +48,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.updateGroupInfoMode(msg)
 	}
 
+	// Handle sensor info mode
+	if m.mode == ModeSensorInfo {
+		return m.updateSensorInfoMode(msg)
+	}
+
 	// Handle delete confirmation mode
 	if m.mode == ModeDeleteConfirm {
 		return m.updateDeleteConfirmMode(msg)
@@ -63,6 +98,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.activeTab = TabScenes
 				return m, m.loadScenes
 			case TabScenes:
+				return m.showSensorsTab()
+			case TabSensors:
 				m.activeTab = TabLights
 				return m, m.loadLights
 			}
@@ -70,6 +107,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case key.Matches(msg, keys.TabPrev):
 			switch m.activeTab {
 			case TabLights:
+				return m.showSensorsTab()
+			case TabSensors:
 				m.activeTab = TabScenes
 				return m, m.loadScenes
 			case TabGroups:
@@ -94,6 +133,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if m.sceneCursor > 0 {
 					m.sceneCursor--
 				}
+			case TabSensors:
+				if m.sensorCursor > 0 {
+					m.sensorCursor--
+				}
 			}
 
 		case key.Matches(msg, keys.Down):
@@ -110,6 +153,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if m.sceneCursor < len(m.scenes)-1 {
 					m.sceneCursor++
 				}
+			case TabSensors:
+				if m.sensorCursor < len(m.sensors)-1 {
+					m.sensorCursor++
+				}
 			}
 
 		case key.Matches(msg, keys.Toggle):
@@ -129,8 +176,28 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					scene := m.scenes[m.sceneCursor]
 					return m, m.activateScene(scene.ID, scene.Name)
 				}
+			case TabSensors:
+				// Motion sensors can be paused, e.g. to keep lights on
+				if len(m.sensors) > 0 {
+					sensor := m.sensors[m.sensorCursor]
+					if sensor.Type == "ZLLPresence" {
+						return m, m.toggleSensor(sensor.ID, sensor.Config.On)
+					}
+				}
+			}
+
+		case key.Matches(msg, keys.Units):
+			if m.activeTab == TabSensors {
+				m.fahrenheit = !m.fahrenheit
+				return m, nil
 			}
 
+		case key.Matches(msg, keys.Brighter):
+			return m.stepBrightness(brightnessStepSize)
+
+		case key.Matches(msg, keys.Dimmer):
+			return m.stepBrightness(-brightnessStepSize)
+
 		case key.Matches(msg, keys.Confirm):
 			// Enter also activates scenes
 			if m.activeTab == TabScenes && len(m.scenes) > 0 {
@@ -158,14 +225,25 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, textinput.Blink
 			}
 
+		case key.Matches(msg, keys.Identify) && m.activeTab == TabLights:
+			// Blink the selected light (Info uses the same key on the groups tab)
+			if len(m.lights) > 0 {
+				return m, m.identifyLight(m.lights[m.lightCursor].ID)
+			}
+
 		case key.Matches(msg, keys.Info):
-			// Enter group info mode (only available on groups tab)
+			// Enter group or sensor info mode
 			if m.activeTab == TabGroups && len(m.groups) > 0 {
 				group := m.groups[m.groupCursor]
 				m.mode = ModeGroupInfo
 				m.infoGroupID = group.ID
 				return m, nil
 			}
+			if m.activeTab == TabSensors && len(m.sensors) > 0 {
+				m.mode = ModeSensorInfo
+				m.infoSensorID = m.sensors[m.sensorCursor].ID
+				return m, nil
+			}
 
 		case key.Matches(msg, keys.Delete):
 			// Enter delete confirmation mode (groups or scenes tab)
@@ -205,6 +283,18 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
+	case stateLoadedMsg:
+		m.lights = msg.state.Lights
+		m.groups = msg.state.Groups
+		m.scenes = msg.state.Scenes
+		m.sensors = visibleSensors(msg.state.Sensors)
+		m.lightsLoaded = true
+		m.groupsLoaded = true
+		m.scenesLoaded = true
+		m.sensorsLoaded = true
+		m.sensorCursor = min(m.sensorCursor, max(len(m.sensors)-1, 0))
+		m.err = nil
+
 	case lightsLoadedMsg:
 		m.lights = msg.lights
 		m.lightsLoaded = true
@@ -215,6 +305,16 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.groupsLoaded = true
 		m.err = nil
 
+	case sensorsLoadedMsg:
+		m.sensors = visibleSensors(msg.sensors)
+		m.sensorsLoaded = true
+		m.sensorCursor = min(m.sensorCursor, max(len(m.sensors)-1, 0))
+		m.err = nil
+
+	case sensorToggledMsg:
+		m.setSensorOn(msg.id, msg.newOn)
+		m.err = nil
+
 	case lightToggledMsg:
 		for i := range m.lights {
 			if m.lights[i].ID == msg.id {
@@ -238,6 +338,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Refresh lights to update individual light states
 		return m, m.loadLights
 
+	case brightnessSteppedMsg:
+		m.err = nil
+		// Refresh lights to show where the bridge clamped the brightness
+		return m, m.loadLights
+
 	case lightRenamedMsg:
 		for i := range m.lights {
 			if m.lights[i].ID == msg.id {
@@ -312,11 +417,71 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case errMsg:
 		m.err = msg.err
+		if errors.Is(msg.err, hue.ErrUnauthorized) {
+			// Nothing works without credentials; let Run offer re-pairing.
+			m.quitting = true
+			return m, tea.Quit
+		}
 	}
 
 	return m, nil
 }
 
+// stepBrightness adds a brightness step for the selected light or group.
+// Steps are sent after brightnessStepDelay without further steps, or
+// right away when the selection changes.
+func (m Model) stepBrightness(delta int) (tea.Model, tea.Cmd) {
+	var id string
+	switch m.activeTab {
+	case TabLights:
+		if len(m.lights) == 0 {
+			return m, nil
+		}
+		light := &m.lights[m.lightCursor]
+		if !light.On {
+			// The bridge rejects brightness changes for lights that are off.
+			return m, nil
+		}
+		id = light.ID
+		// Show the step right away.
+		light.Brightness = min(max(light.Brightness+delta, hue.MinBrightness), hue.MaxBrightness)
+	case TabGroups:
+		if len(m.groups) == 0 {
+			return m, nil
+		}
+		id = m.groups[m.groupCursor].ID
+	default:
+		return m, nil
+	}
+
+	var cmds []tea.Cmd
+	if m.step.delta != 0 && (m.step.tab != m.activeTab || m.step.id != id) {
+		cmds = append(cmds, m.sendBrightnessStep(m.step))
+		m.step.delta = 0
+	}
+
+	m.step.tab = m.activeTab
+	m.step.id = id
+	m.step.delta = min(max(m.step.delta+delta, -hue.MaxBrightnessInc), hue.MaxBrightnessInc)
+	m.step.seq++
+	seq := m.step.seq
+	cmds = append(cmds, tea.Tick(brightnessStepDelay, func(time.Time) tea.Msg {
+		return brightnessStepDueMsg{seq: seq}
+	}))
+	return m, tea.Batch(cmds...)
+}
+
+// flushBrightnessStep sends the pending brightness step unless another
+// step came in after the one that scheduled this flush.
+func (m Model) flushBrightnessStep(seq int) (tea.Model, tea.Cmd) {
+	if seq != m.step.seq || m.step.delta == 0 {
+		return m, nil
+	}
+	step := m.step
+	m.step.delta = 0
+	return m, m.sendBrightnessStep(step)
+}
+
 // updateGroupInfoMode handles input in group info mode.
 func (m Model) updateGroupInfoMode(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
@@ -331,6 +496,71 @@ func (m Model) updateGroupInfoMode(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// updateSensorInfoMode handles input in sensor info mode.
+func (m Model) updateSensorInfoMode(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, keys.Cancel), key.Matches(msg, keys.Quit):
+			m.mode = ModeNormal
+			m.infoSensorID = ""
+			return m, nil
+
+		case key.Matches(msg, keys.Toggle):
+			for _, sensor := range m.sensors {
+				if sensor.ID == m.infoSensorID && sensor.Type == "ZLLPresence" {
+					return m, m.toggleSensor(sensor.ID, sensor.Config.On)
+				}
+			}
+
+		case key.Matches(msg, keys.Units):
+			m.fahrenheit = !m.fahrenheit
+		}
+
+	case sensorToggledMsg:
+		m.setSensorOn(msg.id, msg.newOn)
+		m.err = nil
+
+	case errMsg:
+		m.err = msg.err
+	}
+	return m, nil
+}
+
+// setSensorOn records that a sensor was enabled or disabled.
+func (m Model) setSensorOn(id string, on bool) {
+	for i := range m.sensors {
+		if m.sensors[i].ID == id {
+			m.sensors[i].Config.On = on
+			break
+		}
+	}
+}
+
+// showSensorsTab switches to the sensors tab and keeps its ages current.
+func (m Model) showSensorsTab() (tea.Model, tea.Cmd) {
+	m.activeTab = TabSensors
+	if m.sensorTicking {
+		return m, m.loadSensors
+	}
+	m.sensorTicking = true
+	return m, tea.Batch(m.loadSensors, sensorAgeTick())
+}
+
+// visibleSensors returns the sensors shown on the sensors tab: motion,
+// temperature, light level and switch sensors. The bridge's virtual
+// Daylight and CLIP sensors are left out.
+func visibleSensors(sensors []hue.Sensor) []hue.Sensor {
+	var visible []hue.Sensor
+	for _, sensor := range sensors {
+		switch sensor.Type {
+		case "ZLLPresence", "ZLLTemperature", "ZLLLightLevel", "ZLLSwitch", "ZGPSwitch":
+			visible = append(visible, sensor)
+		}
+	}
+	return visible
+}
+
 // updateDeleteConfirmMode handles input in delete confirmation mode.
 func (m Model) updateDeleteConfirmMode(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
@@ -567,3 +797,66 @@ func (m Model) updateRenameMode(msg tea.Msg) (tea.Model, tea.Cmd) {
 	m.textInput, cmd = m.textInput.Update(msg)
 	return m, cmd
 }
+
+// applyEvent updates the lists with a change reported by the bridge and
+// waits for the next one.
+func (m Model) applyEvent(event hue.Event) (tea.Model, tea.Cmd) {
+	switch e := event.(type) {
+	case hue.LightEvent:
+		for i := range m.lights {
+			if m.lights[i].ID == e.ID {
+				e.Apply(&m.lights[i])
+				break
+			}
+		}
+		if e.On != nil {
+			m.updateGroupsOn()
+		}
+
+	case hue.GroupEvent:
+		for i := range m.groups {
+			if m.groups[i].ID == e.ID {
+				e.Apply(&m.groups[i])
+				break
+			}
+		}
+
+	case hue.SceneEvent:
+		for i := range m.scenes {
+			if m.scenes[i].ID == e.ID {
+				e.Apply(&m.scenes[i])
+				break
+			}
+		}
+
+	case hue.SensorEvent:
+		if slices.ContainsFunc(m.sensors, func(s hue.Sensor) bool { return s.ID == e.ID }) {
+			return m, tea.Batch(m.loadSensor(e.ID), m.waitForEvent)
+		}
+
+	case hue.ResyncEvent:
+		return m, tea.Batch(m.loadState, m.waitForEvent)
+	}
+
+	return m, m.waitForEvent
+}
+
+// updateGroupsOn works out which groups have lights on from the light list.
+func (m Model) updateGroupsOn() {
+	on := make(map[string]bool, len(m.lights))
+	for _, light := range m.lights {
+		on[light.ID] = light.On
+	}
+
+	for i := range m.groups {
+		group := &m.groups[i]
+		if len(group.Lights) == 0 {
+			continue
+		}
+		group.AnyOn, group.AllOn = false, true
+		for _, id := range group.Lights {
+			group.AnyOn = group.AnyOn || on[id]
+			group.AllOn = group.AllOn && on[id]
+		}
+	}
+}
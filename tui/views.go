@@ -2,6 +2,8 @@ package tui
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/LarsEckart/huey/hue"
 )
@@ -12,10 +14,13 @@ func (m Model) View() string {
 		return ""
 	}
 
-	// Group info mode has its own view
+	// Info modes have their own views
 	if m.mode == ModeGroupInfo {
 		return m.renderGroupInfo()
 	}
+	if m.mode == ModeSensorInfo {
+		return m.renderSensorInfo()
+	}
 
 	// Create group modes have their own views
 	if m.mode == ModeCreateGroupType {
@@ -53,6 +58,8 @@ func (m Model) View() string {
 		s += m.renderGroups()
 	case TabScenes:
 		s += m.renderScenes()
+	case TabSensors:
+		s += m.renderSensors()
 	}
 
 	// Render delete confirmation if active
@@ -72,11 +79,13 @@ func (m Model) View() string {
 	default:
 		switch m.activeTab {
 		case TabLights:
-			s += "\n" + helpStyle.Render("↑/↓ navigate • space toggle • r rename • tab switch • q quit")
+			s += "\n" + helpStyle.Render("↑/↓ navigate • space toggle • +/- brightness • i identify • r rename • tab switch • q quit")
 		case TabGroups:
-			s += "\n" + helpStyle.Render("↑/↓ navigate • space toggle • a add • r rename • d delete • i info • tab switch • q quit")
+			s += "\n" + helpStyle.Render("↑/↓ navigate • space toggle • +/- brightness • a add • r rename • d delete • i info • tab switch • q quit")
 		case TabScenes:
 			s += "\n" + helpStyle.Render("↑/↓ navigate • space activate • a add • d delete • tab switch • q quit")
+		case TabSensors:
+			s += "\n" + helpStyle.Render("↑/↓ navigate • space enable/disable motion • i info • u °C/°F • tab switch • q quit")
 		}
 	}
 
@@ -84,24 +93,15 @@ func (m Model) View() string {
 }
 
 func (m Model) renderTabs() string {
-	var lightsTab, groupsTab, scenesTab string
-
-	switch m.activeTab {
-	case TabLights:
-		lightsTab = tabActiveStyle.Render("Lights")
-		groupsTab = tabInactiveStyle.Render("Groups")
-		scenesTab = tabInactiveStyle.Render("Scenes")
-	case TabGroups:
-		lightsTab = tabInactiveStyle.Render("Lights")
-		groupsTab = tabActiveStyle.Render("Groups")
-		scenesTab = tabInactiveStyle.Render("Scenes")
-	case TabScenes:
-		lightsTab = tabInactiveStyle.Render("Lights")
-		groupsTab = tabInactiveStyle.Render("Groups")
-		scenesTab = tabActiveStyle.Render("Scenes")
+	var tabs []string
+	for tab, name := range []string{"Lights", "Groups", "Scenes", "Sensors"} {
+		if Tab(tab) == m.activeTab {
+			tabs = append(tabs, tabActiveStyle.Render(name))
+		} else {
+			tabs = append(tabs, tabInactiveStyle.Render(name))
+		}
 	}
-
-	return lightsTab + "  " + groupsTab + "  " + scenesTab
+	return strings.Join(tabs, "  ")
 }
 
 func (m Model) renderLights() string {
@@ -124,7 +124,8 @@ func (m Model) renderLights() string {
 
 		var status string
 		if light.On {
-			status = onStyle.Render("● on")
+			percent := light.Brightness * 100 / hue.MaxBrightness
+			status = onStyle.Render(fmt.Sprintf("● on %3d%%", percent))
 		} else {
 			status = offStyle.Render("○ off")
 		}
@@ -266,6 +267,150 @@ func (m Model) renderGroupInfo() string {
 	return s
 }
 
+func (m Model) renderSensors() string {
+	if !m.sensorsLoaded && m.err == nil {
+		return "Loading sensors...\n"
+	}
+	if len(m.sensors) == 0 {
+		return "No sensors found.\n"
+	}
+
+	now := time.Now()
+	var s string
+	for i, sensor := range m.sensors {
+		cursor := "  "
+		style := normalStyle
+		if i == m.sensorCursor {
+			cursor = "> "
+			style = selectedStyle
+		}
+
+		reading := fmt.Sprintf("%-16s", m.sensorReading(sensor))
+		if !sensor.Config.On {
+			reading = offStyle.Render(fmt.Sprintf("%-16s", "disabled"))
+		} else if sensor.State.Presence != nil && *sensor.State.Presence {
+			reading = onStyle.Render(reading)
+		}
+
+		var status []string
+		if sensor.Config.Reachable != nil && !*sensor.Config.Reachable {
+			status = append(status, "unreachable")
+		}
+		if sensor.Config.Battery != nil {
+			status = append(status, fmt.Sprintf("%d%%", *sensor.Config.Battery))
+		}
+		status = append(status, eventAge(sensor.State.LastUpdated, now))
+
+		line := fmt.Sprintf("%s%-24s %s %s", cursor, sensor.Name, reading, typeStyle.Render(strings.Join(status, " · ")))
+		s += style.Render(line) + "\n"
+	}
+
+	return s
+}
+
+func (m Model) renderSensorInfo() string {
+	var sensor *hue.Sensor
+	for i := range m.sensors {
+		if m.sensors[i].ID == m.infoSensorID {
+			sensor = &m.sensors[i]
+			break
+		}
+	}
+
+	if sensor == nil {
+		return "Sensor not found\n\n" + helpStyle.Render("esc back")
+	}
+
+	config := sensor.Config
+	s := titleStyle.Render(fmt.Sprintf("Sensor: %s", sensor.Name)) + "\n"
+	s += typeStyle.Render(fmt.Sprintf("Type: %s (%s)", sensor.Type, sensor.ModelID)) + "\n\n"
+
+	if config.On {
+		s += fmt.Sprintf("  %-12s %s\n", "Reading", m.sensorReading(*sensor))
+	} else {
+		s += fmt.Sprintf("  %-12s %s\n", "Reading", offStyle.Render("disabled"))
+	}
+	lastEvent := eventAge(sensor.State.LastUpdated, time.Now())
+	if !sensor.State.LastUpdated.IsZero() {
+		lastEvent = sensor.State.LastUpdated.Local().Format("15:04:05, Jan 2") + " (" + lastEvent + ")"
+	}
+	s += fmt.Sprintf("  %-12s %s\n", "Last event", lastEvent)
+	if config.Reachable != nil {
+		s += fmt.Sprintf("  %-12s %s\n", "Reachable", yesNo(*config.Reachable))
+	}
+	if config.Battery != nil {
+		s += fmt.Sprintf("  %-12s %d%%\n", "Battery", *config.Battery)
+	}
+	if config.Sensitivity != nil && config.SensitivityMax != nil {
+		s += fmt.Sprintf("  %-12s %d of %d\n", "Sensitivity", *config.Sensitivity, *config.SensitivityMax)
+	}
+	if config.LEDIndication != nil {
+		s += fmt.Sprintf("  %-12s %s\n", "LED", yesNo(*config.LEDIndication))
+	}
+
+	help := "u °C/°F • esc back"
+	if sensor.Type == "ZLLPresence" {
+		help = "space enable/disable • " + help
+	}
+	s += "\n" + helpStyle.Render(help)
+	return s
+}
+
+// sensorReading describes a sensor's last reading, e.g. "motion",
+// "21.5°C" or "button 1 pressed".
+func (m Model) sensorReading(sensor hue.Sensor) string {
+	state := sensor.State
+	switch {
+	case state.Presence != nil:
+		if *state.Presence {
+			return "● motion"
+		}
+		return "○ no motion"
+	case state.Temperature != nil:
+		if m.fahrenheit {
+			return fmt.Sprintf("%.1f°F", *state.Temperature*9/5+32)
+		}
+		return fmt.Sprintf("%.1f°C", *state.Temperature)
+	case state.LightLevel != nil:
+		return fmt.Sprintf("%.0f lux", state.Lux())
+	case state.ButtonEvent != nil:
+		event := *state.ButtonEvent
+		if sensor.Type == "ZLLSwitch" {
+			actions := []string{"pressed", "held", "released", "long released"}
+			if action := event % 1000; action < len(actions) {
+				return fmt.Sprintf("button %d %s", event/1000, actions[action])
+			}
+		}
+		return fmt.Sprintf("button event %d", event)
+	}
+	return ""
+}
+
+// eventAge describes how long ago a sensor last reported.
+func eventAge(t, now time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	age := now.Sub(t)
+	switch {
+	case age < time.Minute:
+		return "just now"
+	case age < time.Hour:
+		return fmt.Sprintf("%dm ago", int(age.Minutes()))
+	case age < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(age.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(age.Hours()/24))
+	}
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
 func (m Model) renderCreateGroupType() string {
 	s := titleStyle.Render("Create Group") + "\n\n"
 	s += "Select type:\n\n"